@@ -0,0 +1,130 @@
+/*
+Copyright © 2022 Per G. da Silva <pegoncal@redhat.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/operator-framework/rukpak/api/v1alpha1"
+	"github.com/perdasilva/olmcli/internal/manager"
+	"github.com/spf13/cobra"
+)
+
+// applyCmd represents the apply command
+var applyCmd = &cobra.Command{
+	Use:   "apply",
+	Short: "Converges the installed packages to the state declared in a file",
+	Long: `Resolves the packages declared in a package set file together and installs, upgrades or
+(with --prune) removes packages so the cluster matches the resolution. For example:
+
+  repositories:
+  - image: quay.io/operatorhubio/catalog:latest
+  packages:
+  - name: prometheus
+    channel: beta
+    version: ">=0.47.0 <1.0.0"
+    bundleDeployment:
+      imagePullSecretName: my-secret`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		file, err := cmd.Flags().GetString("filename")
+		if err != nil {
+			return err
+		}
+		prune, err := cmd.Flags().GetBool("prune")
+		if err != nil {
+			return err
+		}
+		dryRunFlag, err := cmd.Flags().GetString("dry-run")
+		if err != nil {
+			return err
+		}
+		dryRun, err := manager.ParseDryRunMode(dryRunFlag)
+		if err != nil {
+			return err
+		}
+		output, err := cmd.Flags().GetString("output")
+		if err != nil {
+			return err
+		}
+
+		packageSet, err := manager.LoadPackageSet(file)
+		if err != nil {
+			return err
+		}
+
+		overrides, err := bundleDeploymentOverrides(cmd)
+		if err != nil {
+			return err
+		}
+		applyOptions := []manager.InstallOption{
+			manager.WithDryRun(dryRun),
+			manager.WithBundleDeploymentOverrides(*overrides),
+		}
+		if prune {
+			applyOptions = append(applyOptions, manager.WithPrune())
+		}
+
+		managerOptions := []manager.Option{manager.WithClusterResolution()}
+		if dryRun != manager.DryRunNone {
+			managerOptions = append(managerOptions, manager.WithClusterDryRun())
+		}
+		manager, err := newManager(managerOptions...)
+		if err != nil {
+			return err
+		}
+		defer manager.Close()
+
+		changes, err := manager.Apply(context.Background(), packageSet, applyOptions...)
+		if err != nil {
+			return err
+		}
+
+		if output != "" {
+			var bundleDeployments []v1alpha1.BundleDeployment
+			for _, change := range changes {
+				bundleDeployments = append(bundleDeployments, *change.BundleDeployment)
+			}
+			return printBundleDeployments(os.Stdout, output, bundleDeployments)
+		}
+
+		// initialize tabwriter
+		w := new(tabwriter.Writer)
+
+		// minwidth, tabwidth, padding, padchar, flags
+		w.Init(os.Stdout, 8, 8, 0, '\t', 0)
+		defer w.Flush()
+
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t\n", "PACKAGE", "ACTION", "FROM", "TO")
+		for _, change := range changes {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t\n", change.PackageName, change.Action, change.FromVersion, change.ToVersion)
+		}
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(applyCmd)
+	applyCmd.Flags().StringP("filename", "f", "", "package set file to apply")
+	applyCmd.Flags().Bool("prune", false, "remove installed packages that are not part of the resolved package set, except held packages")
+	applyCmd.Flags().String("dry-run", string(manager.DryRunNone), "must be \"none\", \"client\", or \"server\". If client, only print the changes that would be made. If server, submit the changes with server-side dry run without persisting them")
+	applyCmd.Flags().StringP("output", "o", "", "print the bundle deployments in the given format: yaml or json")
+	addBundleDeploymentFlags(applyCmd)
+	cobra.CheckErr(applyCmd.MarkFlagRequired("filename"))
+}
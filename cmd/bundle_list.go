@@ -23,14 +23,13 @@ import (
 
 	"github.com/perdasilva/olmcli/internal/manager"
 	"github.com/spf13/cobra"
-	"github.com/spf13/viper"
 )
 
 // listBundleCmd represents the list command
 var listBundleCmd = &cobra.Command{
 	Use: "bundle",
 	RunE: func(cmd *cobra.Command, args []string) error {
-		manager, err := manager.NewManager(viper.GetString("configPath"), &logger)
+		manager, err := newManager(manager.WithReadOnlyDatabase())
 		if err != nil {
 			return err
 		}
@@ -19,25 +19,30 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"strings"
 	"text/tabwriter"
 
 	"github.com/perdasilva/olmcli/internal/manager"
 	"github.com/spf13/cobra"
-	"github.com/spf13/viper"
 )
 
 // searchBundleCmd represents the search command
 var searchBundleCmd = &cobra.Command{
-	Use:  "bundle",
-	Args: cobra.MinimumNArgs(1),
+	Use:   "bundle [terms]...",
+	Short: "Search bundles",
 	RunE: func(cmd *cobra.Command, args []string) error {
-		manager, err := manager.NewManager(viper.GetString("configPath"), &logger)
+		options, err := searchOptions(cmd)
+		if err != nil {
+			return err
+		}
+
+		manager, err := newManager(manager.WithReadOnlyDatabase())
 		if err != nil {
 			return err
 		}
 		defer manager.Close()
 
-		bundles, err := manager.SearchBundles(context.Background(), args[0])
+		bundles, err := manager.SearchBundles(context.Background(), searchTerm(args), options...)
 		if err != nil {
 			return err
 		}
@@ -54,9 +59,9 @@ var searchBundleCmd = &cobra.Command{
 		w.Init(os.Stdout, 8, 8, 0, '\t', 0)
 		defer w.Flush()
 
-		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t\n", "PACKAGE", "CHANNEL", "VERSION", "REPOSITORY")
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t\n", "PACKAGE", "CHANNEL", "VERSION", "REPOSITORY", "MATCHED")
 		for _, bundle := range bundles {
-			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t\n", bundle.PackageName, bundle.ChannelName, bundle.Version, bundle.Repository)
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t\n", bundle.PackageName, bundle.ChannelName, bundle.Version, bundle.Repository, strings.Join(bundle.Matches, ","))
 		}
 		return nil
 	},
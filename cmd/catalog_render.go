@@ -0,0 +1,81 @@
+/*
+Copyright © 2022 Per G. da Silva <pegoncal@redhat.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/operator-framework/operator-registry/alpha/declcfg"
+	"github.com/perdasilva/olmcli/internal/manager"
+	"github.com/spf13/cobra"
+)
+
+// renderCatalogCmd represents the catalog render command
+var renderCatalogCmd = &cobra.Command{
+	Use:   "render",
+	Short: "Render selected packages and their dependencies as a file-based catalog",
+	Long: `Resolves the selected packages and their dependencies from the cached repositories and
+prints a file-based catalog containing the selected channels of the packages, or all of their
+channels without --channel, and the channels their dependencies were resolved from. The channels
+satisfying the dependencies of the other rendered bundles are included too, so the catalog can be
+served on its own. Bundles excluded from resolution, e.g. by the deny list, are left out of the
+channels. Rendering fails if the catalog misses a dependency, other problems the cached
+repositories already have are reported as warnings.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		packageNames, err := cmd.Flags().GetStringSlice("package")
+		if err != nil {
+			return err
+		}
+		channel, err := cmd.Flags().GetString("channel")
+		if err != nil {
+			return err
+		}
+		output, err := cmd.Flags().GetString("output")
+		if err != nil {
+			return err
+		}
+
+		manager, err := newManager(manager.WithReadOnlyDatabase())
+		if err != nil {
+			return err
+		}
+		defer manager.Close()
+
+		cfg, err := manager.RenderCatalog(context.Background(), channel, packageNames...)
+		if err != nil {
+			return err
+		}
+
+		switch output {
+		case outputYAML:
+			return declcfg.WriteYAML(*cfg, os.Stdout)
+		case outputJSON:
+			return declcfg.WriteJSON(*cfg, os.Stdout)
+		}
+		return fmt.Errorf("unsupported output format %q: must be one of %s or %s", output, outputYAML, outputJSON)
+	},
+}
+
+func init() {
+	catalogCmd.AddCommand(renderCatalogCmd)
+	renderCatalogCmd.Flags().StringSlice("package", nil, "packages to render")
+	renderCatalogCmd.Flags().String("channel", "", "channel to render the packages from, all channels when empty")
+	renderCatalogCmd.Flags().StringP("output", "o", outputYAML, "output format: yaml or json")
+	cobra.CheckErr(renderCatalogCmd.MarkFlagRequired("package"))
+}
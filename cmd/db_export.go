@@ -0,0 +1,83 @@
+/*
+Copyright © 2022 Per G. da Silva <pegoncal@redhat.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/perdasilva/olmcli/internal/manager"
+	"github.com/spf13/cobra"
+)
+
+// exportDBCmd represents the db export command
+var exportDBCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Export cached repositories to a snapshot that can be imported into another package database",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		repositories, err := cmd.Flags().GetStringSlice("repo")
+		if err != nil {
+			return err
+		}
+		output, err := cmd.Flags().GetString("output")
+		if err != nil {
+			return err
+		}
+
+		manager, err := newManager(manager.WithReadOnlyDatabase())
+		if err != nil {
+			return err
+		}
+		defer manager.Close()
+
+		if output == "-" {
+			return manager.ExportRepositories(context.Background(), os.Stdout, repositories...)
+		}
+		return writeFileAtomically(output, func(w io.Writer) error {
+			return manager.ExportRepositories(context.Background(), w, repositories...)
+		})
+	},
+}
+
+// writeFileAtomically writes the file through a temporary file, so the file is left untouched if writing fails
+func writeFileAtomically(filePath string, write func(w io.Writer) error) error {
+	file, err := os.CreateTemp(filepath.Dir(filePath), ".olm-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(file.Name())
+	if err := file.Chmod(0644); err != nil {
+		file.Close()
+		return err
+	}
+	if err := write(file); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	return os.Rename(file.Name(), filePath)
+}
+
+func init() {
+	dbCmd.AddCommand(exportDBCmd)
+	exportDBCmd.Flags().StringSlice("repo", nil, "repositories to export, all cached repositories by default")
+	exportDBCmd.Flags().StringP("output", "o", "olm-snapshot.tar.gz", "file to write the snapshot to, - for stdout")
+}
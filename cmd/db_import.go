@@ -0,0 +1,61 @@
+/*
+Copyright © 2022 Per G. da Silva <pegoncal@redhat.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"context"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+// importDBCmd represents the db import command
+var importDBCmd = &cobra.Command{
+	Use:   "import <snapshot>",
+	Short: "Import the repositories of a snapshot created with 'olm db export', replacing their cached content",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		var input io.Reader = os.Stdin
+		if args[0] != "-" {
+			file, err := os.Open(args[0])
+			if err != nil {
+				return err
+			}
+			defer file.Close()
+			input = file
+		}
+
+		manager, err := newManager()
+		if err != nil {
+			return err
+		}
+		defer manager.Close()
+
+		repositories, err := manager.ImportRepositories(context.Background(), input)
+		if err != nil {
+			return err
+		}
+		for _, repository := range repositories {
+			logger.Printf("Imported repository %s (%s)", repository.RepositoryName, repository.RepositorySource)
+		}
+		return nil
+	},
+}
+
+func init() {
+	dbCmd.AddCommand(importDBCmd)
+}
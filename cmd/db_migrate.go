@@ -0,0 +1,56 @@
+/*
+Copyright © 2022 Per G. da Silva <pegoncal@redhat.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"context"
+
+	"github.com/perdasilva/olmcli/internal/manager"
+	"github.com/perdasilva/olmcli/internal/store"
+	"github.com/spf13/cobra"
+)
+
+// migrateDBCmd represents the db migrate command
+var migrateDBCmd = &cobra.Command{
+	Use:   "migrate",
+	Short: "Upgrade the package database to the current schema version",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		manager, err := newManager(manager.WithoutDatabaseMigration())
+		if err != nil {
+			return err
+		}
+		defer manager.Close()
+
+		version, err := manager.SchemaVersion(context.Background())
+		if err != nil {
+			return err
+		}
+		if version == store.CurrentSchemaVersion() {
+			logger.Printf("Package database is up to date (schema version %d)", version)
+			return nil
+		}
+		if err := manager.Migrate(context.Background()); err != nil {
+			return err
+		}
+		logger.Printf("Migrated package database from schema version %d to %d", version, store.CurrentSchemaVersion())
+		return nil
+	},
+}
+
+func init() {
+	dbCmd.AddCommand(migrateDBCmd)
+}
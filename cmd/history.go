@@ -0,0 +1,115 @@
+/*
+Copyright © 2022 Per G. da Silva <pegoncal@redhat.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/perdasilva/olmcli/internal/manager"
+	"github.com/perdasilva/olmcli/internal/store"
+	"github.com/spf13/cobra"
+)
+
+// historyCmd represents the history command
+var historyCmd = &cobra.Command{
+	Use:   "history [operation id]",
+	Short: "List past install operations or inspect one of them",
+	Args:  cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		manager, err := newManager(manager.WithReadOnlyDatabase())
+		if err != nil {
+			return err
+		}
+		defer manager.Close()
+
+		if len(args) == 1 {
+			operation, err := manager.GetOperation(context.Background(), args[0])
+			if err != nil {
+				return err
+			}
+			if operation == nil {
+				return fmt.Errorf("operation %s not found", args[0])
+			}
+			printOperation(operation)
+			return nil
+		}
+
+		operations, err := manager.ListOperations(context.Background())
+		if err != nil {
+			return err
+		}
+
+		if len(operations) == 0 {
+			fmt.Println("No operations found...")
+			return nil
+		}
+
+		// initialize tabwriter
+		w := new(tabwriter.Writer)
+
+		// minwidth, tabwidth, padding, padchar, flags
+		w.Init(os.Stdout, 8, 8, 0, '\t', 0)
+		defer w.Flush()
+
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t\n", "ID", "TIME", "OPERATION", "PACKAGES", "CONTEXT", "OUTCOME")
+		for _, operation := range operations {
+			var packages []string
+			for _, step := range operation.Plan {
+				packages = append(packages, step.PackageName)
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t\n", operation.OperationID, operation.Timestamp.Local().Format(time.RFC3339), operation.Type, strings.Join(packages, ","), operation.Context, operation.Outcome)
+		}
+		return nil
+	},
+}
+
+func printOperation(operation *store.CachedOperation) {
+	fmt.Printf("ID:        %s\n", operation.OperationID)
+	fmt.Printf("Time:      %s\n", operation.Timestamp.Local().Format(time.RFC3339))
+	fmt.Printf("Operation: %s\n", operation.Type)
+	fmt.Printf("Context:   %s\n", operation.Context)
+	fmt.Printf("Cluster:   %s\n", operation.Cluster)
+	fmt.Printf("Outcome:   %s\n", operation.Outcome)
+	if operation.Error != "" {
+		fmt.Printf("Error:     %s\n", operation.Error)
+	}
+	fmt.Println("Requested:")
+	for _, requestedPackage := range operation.RequestedPackages {
+		fmt.Printf("  %s\n", requestedPackage)
+	}
+	fmt.Println("Plan:")
+
+	// initialize tabwriter
+	w := new(tabwriter.Writer)
+
+	// minwidth, tabwidth, padding, padchar, flags
+	w.Init(os.Stdout, 8, 8, 0, '\t', 0)
+	defer w.Flush()
+
+	fmt.Fprintf(w, "  %s\t%s\t%s\t%s\t%s\t\n", "ACTION", "PACKAGE", "FROM", "TO", "BUNDLE")
+	for _, step := range operation.Plan {
+		fmt.Fprintf(w, "  %s\t%s\t%s\t%s\t%s\t\n", step.Action, step.PackageName, step.FromVersion, step.ToVersion, step.BundleID)
+	}
+}
+
+func init() {
+	rootCmd.AddCommand(historyCmd)
+}
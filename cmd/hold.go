@@ -0,0 +1,58 @@
+/*
+Copyright © 2022 Per G. da Silva <pegoncal@redhat.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"context"
+
+	"github.com/perdasilva/olmcli/internal/manager"
+	"github.com/spf13/cobra"
+)
+
+// holdCmd represents the hold command
+var holdCmd = &cobra.Command{
+	Use:   "hold <package>",
+	Short: "Hold an installed package at its version",
+	Long: `Holds an installed package at its installed version, or within the semver range given with --version.
+The hold is stored as an annotation on the package's bundle deployment and is a hard constraint for
+resolution: update and apply never move a held package outside its range, and resolutions failing
+because of holds report them. Release a hold with 'olm unhold'.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		versionRange, err := cmd.Flags().GetString("version")
+		if err != nil {
+			return err
+		}
+
+		manager, err := newManager(manager.WithReadOnlyDatabase())
+		if err != nil {
+			return err
+		}
+		defer manager.Close()
+
+		hold, err := manager.Hold(context.Background(), args[0], versionRange)
+		if err != nil {
+			return err
+		}
+		logger.Printf("Package %s", hold)
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(holdCmd)
+	holdCmd.Flags().String("version", "", "semver range to hold the package within, e.g. \">=1.2.0 <2.0.0\", the installed version when empty")
+}
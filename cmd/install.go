@@ -17,26 +17,156 @@ package cmd
 
 import (
 	"context"
+	"os"
 
+	"github.com/operator-framework/rukpak/api/v1alpha1"
 	"github.com/perdasilva/olmcli/internal/manager"
 	"github.com/spf13/cobra"
-	"github.com/spf13/viper"
 )
 
 // installPackageCmd represents the install command
 var installPackageCmd = &cobra.Command{
 	Use:   "install",
 	Short: "Installs a package",
-	Args:  cobra.MinimumNArgs(1),
+	Args: func(cmd *cobra.Command, args []string) error {
+		if cmd.Flags().Changed("locked") {
+			return cobra.NoArgs(cmd, args)
+		}
+		return cobra.MinimumNArgs(1)(cmd, args)
+	},
 	RunE: func(cmd *cobra.Command, args []string) error {
-		manager, err := manager.NewManager(viper.GetString("configPath"), &logger)
+		lockPath, err := cmd.Flags().GetString("locked")
+		if err != nil {
+			return err
+		}
+		var lockFile *manager.LockFile
+		if lockPath != "" {
+			lockFile, err = manager.LoadLockFile(lockPath)
+			if err != nil {
+				return err
+			}
+		}
+
+		dryRunFlag, err := cmd.Flags().GetString("dry-run")
+		if err != nil {
+			return err
+		}
+		dryRun, err := manager.ParseDryRunMode(dryRunFlag)
+		if err != nil {
+			return err
+		}
+
+		output, err := cmd.Flags().GetString("output")
+		if err != nil {
+			return err
+		}
+		if dryRun != manager.DryRunNone && output == "" {
+			output = outputYAML
+		}
+		overrides, err := bundleDeploymentOverrides(cmd)
+		if err != nil {
+			return err
+		}
+		installOptions := []manager.InstallOption{
+			manager.WithDryRun(dryRun),
+			manager.WithBundleDeploymentOverrides(*overrides),
+		}
+		allowLockMismatch, err := cmd.Flags().GetBool("allow-lock-mismatch")
+		if err != nil {
+			return err
+		}
+		if allowLockMismatch {
+			installOptions = append(installOptions, manager.WithAllowLockMismatch())
+		}
+
+		forCluster, err := cmd.Flags().GetBool("cluster")
+		if err != nil {
+			return err
+		}
+		// client dry runs only render the bundle deployments, so they don't need the cluster unless asked to
+		var managerOptions []manager.Option
+		if dryRun != manager.DryRunClient || forCluster {
+			managerOptions = append(managerOptions, manager.WithClusterResolution())
+		}
+		if dryRun != manager.DryRunNone {
+			managerOptions = append(managerOptions, manager.WithClusterDryRun())
+		}
+		manager, err := newManager(managerOptions...)
 		if err != nil {
 			return err
 		}
-		return manager.Install(context.Background(), args[0])
+		defer manager.Close()
+
+		var bundleDeployments []v1alpha1.BundleDeployment
+		if lockFile != nil {
+			bundleDeployments, err = manager.InstallLocked(context.Background(), lockFile, installOptions...)
+			if err != nil {
+				return err
+			}
+		} else {
+			bundleDeployments, err = manager.Install(context.Background(), args[0], installOptions...)
+			if err != nil {
+				return err
+			}
+		}
+
+		if output == "" {
+			return nil
+		}
+		return printBundleDeployments(os.Stdout, output, bundleDeployments)
 	},
 }
 
 func init() {
 	rootCmd.AddCommand(installPackageCmd)
+	installPackageCmd.Flags().String("dry-run", string(manager.DryRunNone), "must be \"none\", \"client\", or \"server\". If client, only render the objects that would be applied. If server, submit the objects with server-side dry run without persisting them")
+	installPackageCmd.Flags().StringP("output", "o", "", "print the applied objects in the given format: yaml or json")
+	installPackageCmd.Flags().Bool("cluster", false, "with --dry-run=client, resolve for the targeted cluster: exclude bundles incompatible with its platform version and keep held packages within their holds")
+	installPackageCmd.Flags().String("locked", "", "install exactly the bundles recorded in the given lock file without re-running resolution")
+	installPackageCmd.Flags().Bool("allow-lock-mismatch", false, "install the locked bundles even if their repository or bundle image digest changed since they were locked")
+	addBundleDeploymentFlags(installPackageCmd)
+}
+
+// addBundleDeploymentFlags adds the flags that override the configured bundle deployment template
+func addBundleDeploymentFlags(cmd *cobra.Command) {
+	cmd.Flags().String("provisioner-class", "", "provisioner class name of the bundle deployment")
+	cmd.Flags().String("bundle-provisioner-class", "", "provisioner class name used to unpack the bundle")
+	cmd.Flags().String("pull-secret", "", "name of the image pull secret used to pull the bundle image, an empty name removes the default secret")
+	cmd.Flags().StringToString("label", nil, "label to add to the bundle deployment (e.g. --label key=value)")
+	cmd.Flags().StringToString("annotation", nil, "annotation to add to the bundle deployment (e.g. --annotation key=value)")
+}
+
+func bundleDeploymentOverrides(cmd *cobra.Command) (*manager.BundleDeploymentTemplate, error) {
+	provisionerClassName, err := cmd.Flags().GetString("provisioner-class")
+	if err != nil {
+		return nil, err
+	}
+	bundleProvisionerClassName, err := cmd.Flags().GetString("bundle-provisioner-class")
+	if err != nil {
+		return nil, err
+	}
+	// the pull secret is only overridden when the flag is given, an empty name removes it
+	var pullSecret *string
+	if cmd.Flags().Changed("pull-secret") {
+		name, err := cmd.Flags().GetString("pull-secret")
+		if err != nil {
+			return nil, err
+		}
+		pullSecret = &name
+	}
+	labels, err := cmd.Flags().GetStringToString("label")
+	if err != nil {
+		return nil, err
+	}
+	annotations, err := cmd.Flags().GetStringToString("annotation")
+	if err != nil {
+		return nil, err
+	}
+	return &manager.BundleDeploymentTemplate{
+		ProvisionerClassName:       provisionerClassName,
+		BundleProvisionerClassName: bundleProvisionerClassName,
+		ImagePullSecretName:        pullSecret,
+		Labels:                     labels,
+		Annotations:                annotations,
+	}, nil
 }
@@ -0,0 +1,97 @@
+/*
+Copyright © 2022 Per G. da Silva <pegoncal@redhat.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/operator-framework/operator-registry/alpha/declcfg"
+	"github.com/perdasilva/olmcli/internal/manager"
+	"github.com/spf13/cobra"
+)
+
+// mirrorCmd represents the mirror command
+var mirrorCmd = &cobra.Command{
+	Use:   "mirror <package...>",
+	Short: "Prepare mirroring packages to a disconnected registry",
+	Long: `Resolves the packages from the cached repositories and writes an image mapping file
+(mapping.txt, in the format read by 'oc image mirror -f') listing every bundle image and related
+image of the resolved bundles, and a file-based catalog (catalog/index.yaml) whose images point
+to the mirror registry. The images are only copied with --copy, which requires skopeo.
+
+Resolution only reads the cached repositories. Use --kube-version to only mirror bundles that are
+compatible with the disconnected cluster.`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		registry, err := cmd.Flags().GetString("to")
+		if err != nil {
+			return err
+		}
+		output, err := cmd.Flags().GetString("output")
+		if err != nil {
+			return err
+		}
+		copyImages, err := cmd.Flags().GetBool("copy")
+		if err != nil {
+			return err
+		}
+
+		manager, err := newManager(manager.WithReadOnlyDatabase())
+		if err != nil {
+			return err
+		}
+		defer manager.Close()
+
+		plan, err := manager.MirrorPlan(context.Background(), registry, args...)
+		if err != nil {
+			return err
+		}
+
+		catalogDir := filepath.Join(output, "catalog")
+		if err := os.MkdirAll(catalogDir, 0755); err != nil {
+			return err
+		}
+		mappingPath := filepath.Join(output, "mapping.txt")
+		if err := writeFileAtomically(mappingPath, plan.WriteMapping); err != nil {
+			return err
+		}
+		catalogPath := filepath.Join(catalogDir, "index.yaml")
+		if err := writeFileAtomically(catalogPath, func(w io.Writer) error {
+			return declcfg.WriteYAML(*plan.Catalog, w)
+		}); err != nil {
+			return err
+		}
+		fmt.Printf("Wrote %d image mappings to %s\n", len(plan.Mappings), mappingPath)
+		fmt.Printf("Wrote the mirrored catalog (%d bundles) to %s\n", len(plan.Catalog.Bundles), catalogPath)
+
+		if !copyImages {
+			return nil
+		}
+		return plan.Copy(context.Background(), &logger)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(mirrorCmd)
+	mirrorCmd.Flags().String("to", "", "registry, and optionally namespace, to mirror the images to, e.g. registry.internal/olm")
+	mirrorCmd.Flags().StringP("output", "o", "mirror", "directory to write the image mapping and the mirrored catalog to")
+	mirrorCmd.Flags().Bool("copy", false, "copy the images to the mirror registry with skopeo")
+	cobra.CheckErr(mirrorCmd.MarkFlagRequired("to"))
+}
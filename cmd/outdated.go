@@ -0,0 +1,95 @@
+/*
+Copyright © 2022 Per G. da Silva <pegoncal@redhat.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/perdasilva/olmcli/internal/manager"
+	"github.com/spf13/cobra"
+)
+
+// outdatedCmd represents the outdated command
+var outdatedCmd = &cobra.Command{
+	Use:   "outdated",
+	Short: "List installed packages with newer versions available",
+	Long: `Looks up the installed packages in the cached repositories and lists, for each package with a newer
+version, the installed version, the latest version reachable from it through the upgrade graph of its
+channel, and the latest version in any channel of its repository. Use --all to list up to date packages
+too, and -o json or -o yaml for machine-readable output.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		all, err := cmd.Flags().GetBool("all")
+		if err != nil {
+			return err
+		}
+		output, err := cmd.Flags().GetString("output")
+		if err != nil {
+			return err
+		}
+
+		manager, err := newManager(manager.WithReadOnlyDatabase())
+		if err != nil {
+			return err
+		}
+		defer manager.Close()
+
+		installed, err := manager.ListInstalledPackages(context.Background())
+		if err != nil {
+			return err
+		}
+		packages := installed[:0]
+		for _, pkg := range installed {
+			if all || pkg.Outdated || pkg.Message != "" {
+				packages = append(packages, pkg)
+			}
+		}
+
+		if output != "" {
+			return printStructured(os.Stdout, output, packages)
+		}
+		if len(packages) == 0 {
+			fmt.Println("All packages are up to date...")
+			return nil
+		}
+
+		// initialize tabwriter
+		w := new(tabwriter.Writer)
+
+		// minwidth, tabwidth, padding, padchar, flags
+		w.Init(os.Stdout, 8, 8, 0, '\t', 0)
+		defer w.Flush()
+
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t\n", "PACKAGE", "REPOSITORY", "CHANNEL", "CURRENT", "LATEST IN CHANNEL", "LATEST", "HOLD", "MESSAGE")
+		for _, pkg := range packages {
+			latest := pkg.Latest
+			if latest != "" && pkg.LatestChannel != pkg.Channel {
+				latest = fmt.Sprintf("%s (%s)", pkg.Latest, pkg.LatestChannel)
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t\n", pkg.Package, pkg.Repository, pkg.Channel, pkg.Version, pkg.LatestInChannel, latest, pkg.Hold, pkg.Message)
+		}
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(outdatedCmd)
+	outdatedCmd.Flags().Bool("all", false, "also list packages that are up to date")
+	outdatedCmd.Flags().StringP("output", "o", "", "print the packages in the given format: yaml or json")
+}
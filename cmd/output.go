@@ -0,0 +1,87 @@
+/*
+Copyright © 2022 Per G. da Silva <pegoncal@redhat.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/operator-framework/rukpak/api/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	outputYAML = "yaml"
+	outputJSON = "json"
+)
+
+// printBundleDeployments writes the bundle deployments to w as a multi-document yaml stream
+// or as a json v1.List so the output can be piped into kubectl or committed to git
+func printBundleDeployments(w io.Writer, format string, bundleDeployments []v1alpha1.BundleDeployment) error {
+	switch format {
+	case outputYAML:
+		for index := range bundleDeployments {
+			data, err := yaml.Marshal(&bundleDeployments[index])
+			if err != nil {
+				return err
+			}
+			if _, err := fmt.Fprintf(w, "---\n%s", data); err != nil {
+				return err
+			}
+		}
+		return nil
+	case outputJSON:
+		list := &metav1.List{
+			TypeMeta: metav1.TypeMeta{
+				APIVersion: "v1",
+				Kind:       "List",
+			},
+		}
+		for index := range bundleDeployments {
+			list.Items = append(list.Items, runtime.RawExtension{Object: &bundleDeployments[index]})
+		}
+		data, err := json.MarshalIndent(list, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(w, string(data))
+		return err
+	}
+	return fmt.Errorf("unsupported output format %q: must be one of %s or %s", format, outputYAML, outputJSON)
+}
+
+// printStructured writes the value to w as yaml or json, for output read by scripts rather than people
+func printStructured(w io.Writer, format string, value interface{}) error {
+	var data []byte
+	var err error
+	switch format {
+	case outputYAML:
+		data, err = yaml.Marshal(value)
+	case outputJSON:
+		data, err = json.MarshalIndent(value, "", "  ")
+		data = append(data, '\n')
+	default:
+		return fmt.Errorf("unsupported output format %q: must be one of %s or %s", format, outputYAML, outputJSON)
+	}
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(data)
+	return err
+}
@@ -23,14 +23,13 @@ import (
 
 	"github.com/perdasilva/olmcli/internal/manager"
 	"github.com/spf13/cobra"
-	"github.com/spf13/viper"
 )
 
 // listPackageCmd represents the list command
 var listPackageCmd = &cobra.Command{
 	Use: "pkg",
 	RunE: func(cmd *cobra.Command, args []string) error {
-		manager, err := manager.NewManager(viper.GetString("configPath"), &logger)
+		manager, err := newManager(manager.WithReadOnlyDatabase())
 		if err != nil {
 			return err
 		}
@@ -19,47 +19,54 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"strings"
 	"text/tabwriter"
 
 	"github.com/perdasilva/olmcli/internal/manager"
 	"github.com/spf13/cobra"
-	"github.com/spf13/viper"
 )
 
 // searchPackageCmd represents the search command
 var searchPackageCmd = &cobra.Command{
-	Use:  "pkg",
-	Args: cobra.MinimumNArgs(1),
-	RunE: func(cmd *cobra.Command, args []string) error {
-		manager, err := manager.NewManager(viper.GetString("configPath"), &logger)
-		if err != nil {
-			return err
-		}
-		defer manager.Close()
-
-		pkgs, err := manager.SearchPackages(context.Background(), args[0])
-		if err != nil {
-			return err
-		}
+	Use:   "pkg [terms]...",
+	Short: "Search packages",
+	RunE:  runPackageSearch,
+}
 
-		if len(pkgs) == 0 {
-			fmt.Println("No packages found...")
-			return nil
-		}
+func runPackageSearch(cmd *cobra.Command, args []string) error {
+	options, err := searchOptions(cmd)
+	if err != nil {
+		return err
+	}
 
-		// initialize tabwriter
-		w := new(tabwriter.Writer)
+	manager, err := newManager(manager.WithReadOnlyDatabase())
+	if err != nil {
+		return err
+	}
+	defer manager.Close()
 
-		// minwidth, tabwidth, padding, padchar, flags
-		w.Init(os.Stdout, 8, 8, 0, '\t', 0)
-		defer w.Flush()
+	pkgs, err := manager.SearchPackages(context.Background(), searchTerm(args), options...)
+	if err != nil {
+		return err
+	}
 
-		fmt.Fprintf(w, "%s\t%s\t%s\t\n", "PACKAGE", "DEFAULT CHANNEL", "REPOSITORY")
-		for _, pkg := range pkgs {
-			fmt.Fprintf(w, "%s\t%s\t%s\t\n", pkg.Name, pkg.DefaultChannelName, pkg.Repository)
-		}
+	if len(pkgs) == 0 {
+		fmt.Println("No packages found...")
 		return nil
-	},
+	}
+
+	// initialize tabwriter
+	w := new(tabwriter.Writer)
+
+	// minwidth, tabwidth, padding, padchar, flags
+	w.Init(os.Stdout, 8, 8, 0, '\t', 0)
+	defer w.Flush()
+
+	fmt.Fprintf(w, "%s\t%s\t%s\t%s\t\n", "PACKAGE", "DEFAULT CHANNEL", "REPOSITORY", "MATCHED")
+	for _, pkg := range pkgs {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t\n", pkg.Name, pkg.DefaultChannelName, pkg.Repository, strings.Join(pkg.Matches, ","))
+	}
+	return nil
 }
 
 func init() {
@@ -18,9 +18,7 @@ package cmd
 import (
 	"context"
 
-	"github.com/perdasilva/olmcli/internal/manager"
 	"github.com/spf13/cobra"
-	"github.com/spf13/viper"
 )
 
 // addRepoCmd represents the add command
@@ -28,7 +26,7 @@ var addRepoCmd = &cobra.Command{
 	Use:  "repo",
 	Args: cobra.MinimumNArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
-		manager, err := manager.NewManager(viper.GetString("configPath"), &logger)
+		manager, err := newManager()
 		if err != nil {
 			return err
 		}
@@ -0,0 +1,77 @@
+/*
+Copyright © 2022 Per G. da Silva <pegoncal@redhat.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/perdasilva/olmcli/internal/catalog"
+	"github.com/perdasilva/olmcli/internal/manager"
+	"github.com/spf13/cobra"
+)
+
+// diffRepoCmd represents the repo diff command
+var diffRepoCmd = &cobra.Command{
+	Use:   "diff <old> <new>",
+	Short: "Compare two repositories, file-based catalogs or snapshots",
+	Long: `Compare two repositories and report the packages and channels added or removed, the channels whose
+heads moved, the versions added to or removed from channels, and the bundles whose dependencies changed.
+Each side is the name of a cached repository, the path of a file-based catalog, or the path of a snapshot
+written by 'olm db export', e.g. to review a repository against a snapshot taken before it was updated.
+Snapshots holding several repositories take the repository name after a '#': <path>#<repository>.`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		manager, err := newManager(manager.WithReadOnlyDatabase())
+		if err != nil {
+			return err
+		}
+		defer manager.Close()
+
+		from, err := manager.Catalog(context.Background(), args[0])
+		if err != nil {
+			return err
+		}
+		to, err := manager.Catalog(context.Background(), args[1])
+		if err != nil {
+			return err
+		}
+
+		changes := catalog.Diff(from, to)
+		if len(changes) == 0 {
+			fmt.Println("No changes found...")
+			return nil
+		}
+
+		// initialize tabwriter
+		w := new(tabwriter.Writer)
+
+		// minwidth, tabwidth, padding, padchar, flags
+		w.Init(os.Stdout, 8, 8, 0, '\t', 0)
+
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t\n", "CHANGE", "PACKAGE", "CHANNEL", "DETAILS")
+		for _, change := range changes {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t\n", change.Kind, change.Package, change.Channel, change.Message)
+		}
+		return w.Flush()
+	},
+}
+
+func init() {
+	repoCmd.AddCommand(diffRepoCmd)
+}
@@ -0,0 +1,66 @@
+/*
+Copyright © 2022 Per G. da Silva <pegoncal@redhat.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/perdasilva/olmcli/internal/manager"
+	"github.com/spf13/cobra"
+)
+
+// lintRepoCmd represents the repo lint command
+var lintRepoCmd = &cobra.Command{
+	Use:   "lint [repository]...",
+	Short: "Report the invalid versions, ranges and constraints found when repositories were cached",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		manager, err := newManager(manager.WithReadOnlyDatabase())
+		if err != nil {
+			return err
+		}
+		defer manager.Close()
+
+		warnings, err := manager.ListWarnings(context.Background(), args...)
+		if err != nil {
+			return err
+		}
+
+		if len(warnings) == 0 {
+			fmt.Println("No problems found...")
+			return nil
+		}
+
+		// initialize tabwriter
+		w := new(tabwriter.Writer)
+
+		// minwidth, tabwidth, padding, padchar, flags
+		w.Init(os.Stdout, 8, 8, 0, '\t', 0)
+		defer w.Flush()
+
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t\n", "REPOSITORY", "BUNDLE", "FIELD", "MESSAGE")
+		for _, warning := range warnings {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t\n", warning.Repository, warning.BundleID, warning.Field, warning.Message)
+		}
+		return nil
+	},
+}
+
+func init() {
+	repoCmd.AddCommand(lintRepoCmd)
+}
@@ -0,0 +1,93 @@
+/*
+Copyright © 2022 Per G. da Silva <pegoncal@redhat.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/perdasilva/olmcli/internal/catalog"
+	"github.com/perdasilva/olmcli/internal/manager"
+	"github.com/spf13/cobra"
+)
+
+// validateRepoCmd represents the repo validate command
+var validateRepoCmd = &cobra.Command{
+	Use:   "validate <name|path>",
+	Short: "Check a cached repository or a file-based catalog for problems that break resolution",
+	Long: `Check a cached repository or a file-based catalog for problems that break resolution: dangling package
+dependencies, required gvks no bundle provides, missing default channels, broken replaces chains, conflicting csv
+names and dependency cycles. Bundle lint findings, such as invalid versions and ranges, are reported as warnings.
+Exits with a non-zero status if errors are found, or warnings when --strict is set.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		standalone, err := cmd.Flags().GetBool("standalone")
+		if err != nil {
+			return err
+		}
+		strict, err := cmd.Flags().GetBool("strict")
+		if err != nil {
+			return err
+		}
+
+		manager, err := newManager(manager.WithReadOnlyDatabase())
+		if err != nil {
+			return err
+		}
+		defer manager.Close()
+
+		c, err := manager.Catalog(context.Background(), args[0])
+		if err != nil {
+			return err
+		}
+		problems, err := manager.ValidateCatalog(context.Background(), c, standalone)
+		if err != nil {
+			return err
+		}
+
+		if len(problems) == 0 {
+			fmt.Println("No problems found...")
+			return nil
+		}
+
+		// initialize tabwriter
+		w := new(tabwriter.Writer)
+
+		// minwidth, tabwidth, padding, padchar, flags
+		w.Init(os.Stdout, 8, 8, 0, '\t', 0)
+
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t\n", "SEVERITY", "PACKAGE", "BUNDLE", "MESSAGE")
+		for _, problem := range problems {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t\n", problem.Severity, problem.Package, problem.BundleID, problem.Message)
+		}
+		w.Flush()
+
+		if catalog.HasErrors(problems) || strict {
+			// the problems have been reported, don't print the usage
+			cmd.SilenceUsage = true
+			return fmt.Errorf("%s has %d problems", args[0], len(problems))
+		}
+		return nil
+	},
+}
+
+func init() {
+	repoCmd.AddCommand(validateRepoCmd)
+	validateRepoCmd.Flags().Bool("standalone", false, "only look for dependencies in the validated catalog, not in the cached repositories")
+	validateRepoCmd.Flags().Bool("strict", false, "exit with a non-zero status on warnings too")
+}
@@ -17,11 +17,12 @@ package cmd
 
 import (
 	"context"
+	"fmt"
 	"strings"
 
 	"github.com/perdasilva/olmcli/internal/manager"
+	"github.com/perdasilva/olmcli/internal/resolution"
 	"github.com/spf13/cobra"
-	"github.com/spf13/viper"
 
 	"github.com/jedib0t/go-pretty/v6/list"
 )
@@ -32,14 +33,53 @@ var resolveCmd = &cobra.Command{
 	Short: "run resolution on a package",
 	Args:  cobra.MinimumNArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
-		manager, err := manager.NewManager(viper.GetString("configPath"), &logger)
+		lockPath, err := cmd.Flags().GetString("lock")
 		if err != nil {
 			return err
 		}
-		installables, err := manager.Resolve(context.Background(), args[0])
+		explain, err := cmd.Flags().GetBool("explain")
 		if err != nil {
 			return err
 		}
+		forCluster, err := cmd.Flags().GetBool("cluster")
+		if err != nil {
+			return err
+		}
+
+		options := []manager.Option{manager.WithReadOnlyDatabase()}
+		if forCluster {
+			options = append(options, manager.WithClusterResolution())
+		}
+		manager, err := newManager(options...)
+		if err != nil {
+			return err
+		}
+		defer manager.Close()
+
+		var installables []resolution.Installable
+		if explain {
+			// the excluded candidates are printed even if resolution fails, they often explain the failure
+			var excluded []resolution.ExcludedBundle
+			installables, excluded, err = manager.ResolveWithExclusions(context.Background(), args[0])
+			printExcluded(excluded)
+		} else {
+			installables, err = manager.Resolve(context.Background(), args[0])
+		}
+		if err != nil {
+			return err
+		}
+
+		if lockPath != "" {
+			lockFile, err := manager.LockFile(context.Background(), installables)
+			if err != nil {
+				return err
+			}
+			if err := lockFile.Write(lockPath); err != nil {
+				return err
+			}
+			logger.Printf("Wrote lock file %s", lockPath)
+		}
+
 		l := list.NewWriter()
 		l.SetStyle(list.StyleConnectedRounded)
 		l.AppendItem("Resolved Bundles")
@@ -60,6 +100,24 @@ var resolveCmd = &cobra.Command{
 	},
 }
 
+// printExcluded lists the candidate bundles excluded from resolution and why
+func printExcluded(excluded []resolution.ExcludedBundle) {
+	l := list.NewWriter()
+	l.SetStyle(list.StyleConnectedRounded)
+	l.AppendItem("Excluded Candidates")
+	l.Indent()
+	for _, bundle := range excluded {
+		l.AppendItem(fmt.Sprintf("%s: %s", bundle.BundleID, bundle.Reason))
+	}
+	l.UnIndent()
+	for _, line := range strings.Split(l.Render(), "\n") {
+		logger.Printf(line)
+	}
+}
+
 func init() {
 	rootCmd.AddCommand(resolveCmd)
+	resolveCmd.Flags().String("lock", "", "write the resolved bundles to the given lock file")
+	resolveCmd.Flags().Bool("explain", false, "list the candidate bundles excluded from resolution and why, e.g. denied or held bundles")
+	resolveCmd.Flags().Bool("cluster", false, "resolve for the targeted cluster, excluding the bundles that are not compatible with its platform version and keeping held packages within their holds")
 }
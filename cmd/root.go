@@ -19,7 +19,12 @@ import (
 	"fmt"
 	"os"
 	"path"
+	"time"
 
+	"github.com/blang/semver/v4"
+	"github.com/perdasilva/olmcli/internal/manager"
+	"github.com/perdasilva/olmcli/internal/resolution"
+	"github.com/perdasilva/olmcli/internal/store"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 
@@ -28,6 +33,9 @@ import (
 
 var logger logrus.Logger
 
+// defaultDatabaseOpenTimeout is how long commands wait for other olm processes to release the package database
+const defaultDatabaseOpenTimeout = 10 * time.Second
+
 // rootCmd represents the base command when called without any subcommands
 var rootCmd = &cobra.Command{
 	Use:   "olm",
@@ -77,6 +85,98 @@ func init() {
 	cobra.OnInitialize(initConfig)
 	rootCmd.PersistentFlags().BoolP("debug", "d", false, "set debug output level")
 	rootCmd.PersistentFlags().BoolP("trace", "t", false, "set trace output level")
+	rootCmd.PersistentFlags().String("kubeconfig", "", "path to the kubeconfig file used for cluster operations")
+	rootCmd.PersistentFlags().String("context", "", "name of the kubeconfig context used for cluster operations")
+	rootCmd.PersistentFlags().Duration("request-timeout", manager.DefaultClusterRequestTimeout, "how long a request to the cluster may take")
+	rootCmd.PersistentFlags().String("kube-version", "", "kubernetes version to resolve bundles for instead of the version detected from the cluster")
+	rootCmd.PersistentFlags().String("openshift-version", "", "openshift version to resolve bundles for, requires --kube-version")
+	cobra.CheckErr(viper.BindPFlag("kubeconfig", rootCmd.PersistentFlags().Lookup("kubeconfig")))
+	cobra.CheckErr(viper.BindPFlag("context", rootCmd.PersistentFlags().Lookup("context")))
+	cobra.CheckErr(viper.BindPFlag("requestTimeout", rootCmd.PersistentFlags().Lookup("request-timeout")))
+	cobra.CheckErr(viper.BindPFlag("kubeVersion", rootCmd.PersistentFlags().Lookup("kube-version")))
+	cobra.CheckErr(viper.BindPFlag("openshiftVersion", rootCmd.PersistentFlags().Lookup("openshift-version")))
+}
+
+// repositoryConfig holds the per-repository settings in the config file
+type repositoryConfig struct {
+	BundleDeployment manager.BundleDeploymentTemplate `mapstructure:"bundleDeployment"`
+}
+
+// platformVersion reads the platform version to resolve for from the --kube-version and --openshift-version flags.
+// It returns nil if the version should be detected from the cluster
+func platformVersion() (*resolution.PlatformVersion, error) {
+	kubeVersion := viper.GetString("kubeVersion")
+	openShiftVersion := viper.GetString("openshiftVersion")
+	if kubeVersion == "" {
+		if openShiftVersion != "" {
+			return nil, fmt.Errorf("--openshift-version requires --kube-version")
+		}
+		return nil, nil
+	}
+	parsedKubeVersion, err := semver.ParseTolerant(kubeVersion)
+	if err != nil {
+		return nil, fmt.Errorf("invalid kubernetes version %q: %w", kubeVersion, err)
+	}
+	platform := &resolution.PlatformVersion{KubeVersion: &parsedKubeVersion}
+	if openShiftVersion != "" {
+		parsedOpenShiftVersion, err := semver.ParseTolerant(openShiftVersion)
+		if err != nil {
+			return nil, fmt.Errorf("invalid openshift version %q: %w", openShiftVersion, err)
+		}
+		platform.OpenShiftVersion = &parsedOpenShiftVersion
+	}
+	return platform, nil
+}
+
+// newManager creates a manager configured from the flags and the config file
+func newManager(options ...manager.Option) (manager.Manager, error) {
+	configOptions, err := managerOptions()
+	if err != nil {
+		return nil, err
+	}
+	return manager.NewManager(viper.GetString("configPath"), &logger, append(configOptions, options...)...)
+}
+
+// managerOptions returns the manager options read from the config: the storage backend of the
+// package database, how to connect to the cluster, how to template the bundle deployments and
+// the bundles denied from resolution. The flags take precedence over the values set in the config file
+func managerOptions() ([]manager.Option, error) {
+	options := []manager.Option{
+		manager.WithDatabaseBackend(viper.GetString("database.backend")),
+		manager.WithDatabaseOpenTimeout(viper.GetDuration("database.openTimeout")),
+		manager.WithKubeConfig(viper.GetString("kubeconfig")),
+		manager.WithKubeContext(viper.GetString("context")),
+		manager.WithClusterRequestTimeout(viper.GetDuration("requestTimeout")),
+	}
+
+	platformVersion, err := platformVersion()
+	if err != nil {
+		return nil, err
+	}
+	if platformVersion != nil {
+		options = append(options, manager.WithPlatformVersion(*platformVersion))
+	}
+
+	var template manager.BundleDeploymentTemplate
+	if err := viper.UnmarshalKey("bundleDeployment", &template); err != nil {
+		return nil, fmt.Errorf("error reading bundleDeployment config: %w", err)
+	}
+	options = append(options, manager.WithBundleDeploymentTemplate(template))
+
+	var repositories map[string]repositoryConfig
+	if err := viper.UnmarshalKey("repositories", &repositories); err != nil {
+		return nil, fmt.Errorf("error reading repositories config: %w", err)
+	}
+	for repositoryName, repository := range repositories {
+		options = append(options, manager.WithRepositoryBundleDeploymentTemplate(repositoryName, repository.BundleDeployment))
+	}
+
+	var denyList []resolution.DenyRule
+	if err := viper.UnmarshalKey("denyList", &denyList); err != nil {
+		return nil, fmt.Errorf("error reading denyList config: %w", err)
+	}
+	options = append(options, manager.WithDenyList(denyList))
+	return options, nil
 }
 
 // initConfig reads in config file and ENV variables if set.
@@ -98,6 +198,8 @@ func initConfig() {
 	viper.SetConfigType("yaml")
 	viper.SetConfigName("config")
 	viper.SetDefault("configPath", configPath)
+	viper.SetDefault("database.backend", store.DefaultBackend)
+	viper.SetDefault("database.openTimeout", defaultDatabaseOpenTimeout)
 
 	viper.AutomaticEnv() // read in environment variables that match
 
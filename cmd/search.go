@@ -16,15 +16,79 @@ limitations under the License.
 package cmd
 
 import (
+	"strings"
+
+	"github.com/perdasilva/olmcli/internal/store"
 	"github.com/spf13/cobra"
 )
 
 // searchCmd represents the search command
 var searchCmd = &cobra.Command{
-	Use:   "search",
-	Short: "A brief description of your command",
+	Use:   "search [terms]...",
+	Short: "Search packages by name, display name, description, keywords, provider, annotations and provided kinds",
+	Long: `Search packages by name, display name, description, keywords, categories, provider, csv annotations
+and provided api kinds. Matching is case-insensitive and, unless --fuzzy=false, tolerates typos.
+Results are ranked by relevance. Without terms, every package matching the filters is listed.`,
+	RunE: runPackageSearch,
 }
 
 func init() {
 	rootCmd.AddCommand(searchCmd)
+	searchCmd.PersistentFlags().StringSlice("repo", nil, "only search the given repositories")
+	searchCmd.PersistentFlags().String("channel", "", "only search bundles in the given channel")
+	searchCmd.PersistentFlags().String("provides-kind", "", "only search bundles providing an api of the given kind")
+	searchCmd.PersistentFlags().String("category", "", "only search bundles in the given category")
+	searchCmd.PersistentFlags().String("capabilities", "", "only search bundles with the given capability level, e.g. 'Full Lifecycle'")
+	searchCmd.PersistentFlags().Bool("fuzzy", true, "also match terms with small typos")
+}
+
+// searchOptions reads the search filter flags
+func searchOptions(cmd *cobra.Command) ([]store.SearchOption, error) {
+	flags := cmd.Flags()
+	repositories, err := flags.GetStringSlice("repo")
+	if err != nil {
+		return nil, err
+	}
+	channel, err := flags.GetString("channel")
+	if err != nil {
+		return nil, err
+	}
+	providesKind, err := flags.GetString("provides-kind")
+	if err != nil {
+		return nil, err
+	}
+	category, err := flags.GetString("category")
+	if err != nil {
+		return nil, err
+	}
+	capabilities, err := flags.GetString("capabilities")
+	if err != nil {
+		return nil, err
+	}
+	fuzzy, err := flags.GetBool("fuzzy")
+	if err != nil {
+		return nil, err
+	}
+
+	options := []store.SearchOption{store.WithFuzzyMatching(fuzzy)}
+	if len(repositories) > 0 {
+		options = append(options, store.SearchInRepositories(repositories...))
+	}
+	if channel != "" {
+		options = append(options, store.SearchInChannel(channel))
+	}
+	if providesKind != "" {
+		options = append(options, store.SearchProvidingKind(providesKind))
+	}
+	if category != "" {
+		options = append(options, store.SearchInCategory(category))
+	}
+	if capabilities != "" {
+		options = append(options, store.SearchWithCapabilities(capabilities))
+	}
+	return options, nil
+}
+
+func searchTerm(args []string) string {
+	return strings.Join(args, " ")
 }
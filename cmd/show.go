@@ -0,0 +1,121 @@
+/*
+Copyright © 2022 Per G. da Silva <pegoncal@redhat.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/perdasilva/olmcli/internal/manager"
+	"github.com/perdasilva/olmcli/internal/store"
+	"github.com/spf13/cobra"
+)
+
+// showCmd represents the show command
+var showCmd = &cobra.Command{
+	Use:   "show <package>",
+	Short: "Show the description, provider, maintainers and other metadata of a package",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		repositories, err := cmd.Flags().GetStringSlice("repo")
+		if err != nil {
+			return err
+		}
+
+		manager, err := newManager(manager.WithReadOnlyDatabase())
+		if err != nil {
+			return err
+		}
+		defer manager.Close()
+
+		pkgs, err := manager.ListPackages(context.Background())
+		if err != nil {
+			return err
+		}
+
+		found := false
+		for index := range pkgs {
+			pkg := &pkgs[index]
+			if pkg.GetName() != args[0] || (len(repositories) > 0 && !contains(repositories, pkg.Repository)) {
+				continue
+			}
+			if found {
+				fmt.Println()
+			}
+			found = true
+			printPackage(pkg)
+		}
+		if !found {
+			return fmt.Errorf("package %s not found", args[0])
+		}
+		return nil
+	},
+}
+
+func printPackage(pkg *store.CachedPackage) {
+	metadata := pkg.Metadata
+	if metadata == nil {
+		metadata = &store.CSVMetadata{}
+	}
+	var channels []string
+	for _, channel := range pkg.GetChannels() {
+		channels = append(channels, channel.GetName())
+	}
+	fmt.Printf("Package:          %s\n", pkg.GetName())
+	fmt.Printf("Repository:       %s\n", pkg.Repository)
+	fmt.Printf("Display Name:     %s\n", metadata.DisplayName)
+	fmt.Printf("Provider:         %s\n", metadata.Provider)
+	fmt.Printf("Default Channel:  %s\n", pkg.GetDefaultChannelName())
+	fmt.Printf("Channels:         %s\n", strings.Join(channels, ", "))
+	fmt.Printf("Categories:       %s\n", strings.Join(metadata.Categories, ", "))
+	fmt.Printf("Keywords:         %s\n", strings.Join(metadata.Keywords, ", "))
+	fmt.Printf("Capabilities:     %s\n", metadata.Capabilities)
+	fmt.Printf("Install Modes:    %s\n", strings.Join(metadata.SupportedInstallModes(), ", "))
+	fmt.Printf("Min Kube Version: %s\n", metadata.MinKubeVersion)
+	if len(metadata.Maintainers) > 0 {
+		fmt.Println("Maintainers:")
+		for _, maintainer := range metadata.Maintainers {
+			fmt.Printf("  %s <%s>\n", maintainer.Name, maintainer.Email)
+		}
+	}
+	if len(metadata.Links) > 0 {
+		fmt.Println("Links:")
+		for _, link := range metadata.Links {
+			fmt.Printf("  %s: %s\n", link.Name, link.URL)
+		}
+	}
+	if metadata.Description != "" {
+		fmt.Println("Description:")
+		for _, line := range strings.Split(strings.TrimSpace(metadata.Description), "\n") {
+			fmt.Printf("  %s\n", line)
+		}
+	}
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func init() {
+	rootCmd.AddCommand(showCmd)
+	showCmd.Flags().StringSlice("repo", nil, "only show the package from the given repositories")
+}
@@ -0,0 +1,47 @@
+/*
+Copyright © 2022 Per G. da Silva <pegoncal@redhat.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"context"
+
+	"github.com/perdasilva/olmcli/internal/manager"
+	"github.com/spf13/cobra"
+)
+
+// unholdCmd represents the unhold command
+var unholdCmd = &cobra.Command{
+	Use:   "unhold <package>",
+	Short: "Release the hold of an installed package",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		manager, err := newManager(manager.WithReadOnlyDatabase())
+		if err != nil {
+			return err
+		}
+		defer manager.Close()
+
+		if err := manager.Unhold(context.Background(), args[0]); err != nil {
+			return err
+		}
+		logger.Printf("Package %s is no longer held", args[0])
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(unholdCmd)
+}
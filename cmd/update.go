@@ -16,20 +16,119 @@ limitations under the License.
 package cmd
 
 import (
+	"context"
 	"fmt"
+	"os"
+	"text/tabwriter"
 
+	"github.com/operator-framework/rukpak/api/v1alpha1"
+	"github.com/perdasilva/olmcli/internal/manager"
 	"github.com/spf13/cobra"
 )
 
 // updateCmd represents the update command
 var updateCmd = &cobra.Command{
-	Use:   "update",
-	Short: "Update a package",
-	Run: func(cmd *cobra.Command, args []string) {
-		fmt.Println("update called")
+	Use:   "update <package>... | --all",
+	Short: "Update packages",
+	Long: `Upgrades installed packages, or all installed packages with --all, to the latest bundle reachable from
+the installed bundle through the upgrade graph of their channel. With --channel, a single package switches
+to the given channel, which must contain the installed bundle or a bundle that replaces, skips or has a
+skip range including it. The other installed packages are kept at their installed version, and missing
+dependencies are installed. Held packages are only upgraded within the range they are held at: --all
+skips them, naming them fails.`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if all, _ := cmd.Flags().GetBool("all"); all {
+			return cobra.NoArgs(cmd, args)
+		}
+		return cobra.MinimumNArgs(1)(cmd, args)
 	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		channel, err := cmd.Flags().GetString("channel")
+		if err != nil {
+			return err
+		}
+		dryRunFlag, err := cmd.Flags().GetString("dry-run")
+		if err != nil {
+			return err
+		}
+		dryRun, err := manager.ParseDryRunMode(dryRunFlag)
+		if err != nil {
+			return err
+		}
+		output, err := cmd.Flags().GetString("output")
+		if err != nil {
+			return err
+		}
+		overrides, err := bundleDeploymentOverrides(cmd)
+		if err != nil {
+			return err
+		}
+		updateOptions := []manager.InstallOption{
+			manager.WithDryRun(dryRun),
+			manager.WithBundleDeploymentOverrides(*overrides),
+		}
+
+		managerOptions := []manager.Option{manager.WithClusterResolution()}
+		if dryRun != manager.DryRunNone {
+			managerOptions = append(managerOptions, manager.WithClusterDryRun())
+		}
+		manager, err := newManager(managerOptions...)
+		if err != nil {
+			return err
+		}
+		defer manager.Close()
+
+		changes, err := manager.Update(context.Background(), args, channel, updateOptions...)
+		if err != nil {
+			return err
+		}
+
+		reported := updateChanges(args, changes)
+
+		if output != "" {
+			var bundleDeployments []v1alpha1.BundleDeployment
+			for _, change := range reported {
+				bundleDeployments = append(bundleDeployments, *change.BundleDeployment)
+			}
+			return printBundleDeployments(os.Stdout, output, bundleDeployments)
+		}
+
+		// initialize tabwriter
+		w := new(tabwriter.Writer)
+
+		// minwidth, tabwidth, padding, padchar, flags
+		w.Init(os.Stdout, 8, 8, 0, '\t', 0)
+		defer w.Flush()
+
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t\n", "PACKAGE", "ACTION", "FROM", "TO")
+		for _, change := range reported {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t\n", change.PackageName, change.Action, change.FromVersion, change.ToVersion)
+		}
+		return nil
+	},
+}
+
+// updateChanges returns the changes to report for the update of the packages. The other installed packages
+// are part of the resolution, but are only reported if they change
+func updateChanges(packageNames []string, changes []manager.Change) []manager.Change {
+	requested := map[string]struct{}{}
+	for _, packageName := range packageNames {
+		requested[packageName] = struct{}{}
+	}
+	var reported []manager.Change
+	for _, change := range changes {
+		if _, ok := requested[change.PackageName]; ok || change.Action != manager.ChangeActionUnchanged {
+			reported = append(reported, change)
+		}
+	}
+	return reported
 }
 
 func init() {
 	rootCmd.AddCommand(updateCmd)
+	updateCmd.Flags().Bool("all", false, "update all installed packages")
+	updateCmd.Flags().String("channel", "", "channel to switch the package to, its installed channel when empty")
+	updateCmd.Flags().String("dry-run", string(manager.DryRunNone), "must be \"none\", \"client\", or \"server\". If client, only print the changes that would be made. If server, submit the changes with server-side dry run without persisting them")
+	updateCmd.Flags().StringP("output", "o", "", "print the bundle deployments in the given format: yaml or json")
+	addBundleDeploymentFlags(updateCmd)
 }
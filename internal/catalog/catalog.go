@@ -0,0 +1,198 @@
+package catalog
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/operator-framework/operator-registry/alpha/declcfg"
+	"github.com/operator-framework/operator-registry/alpha/model"
+	"github.com/operator-framework/operator-registry/alpha/property"
+	"github.com/operator-framework/operator-registry/pkg/api"
+	"github.com/perdasilva/olmcli/internal/resolution"
+	"github.com/perdasilva/olmcli/internal/store"
+)
+
+// Catalog is the content of a single repository, either cached in the package database or loaded
+// from a file-based catalog
+type Catalog struct {
+	Name     string
+	Packages []store.CachedPackage
+	Bundles  []store.CachedBundle
+	// Warnings are the problems found while loading the catalog
+	Warnings []store.CachedWarning
+}
+
+// FromDatabase returns the cached content of the named repository
+func FromDatabase(ctx context.Context, packageDatabase store.PackageDatabase, repositoryName string) (*Catalog, error) {
+	ok, err := packageDatabase.HasRepository(ctx, repositoryName)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, fmt.Errorf("repository %s not found", repositoryName)
+	}
+
+	catalog := &Catalog{Name: repositoryName}
+	packages, err := packageDatabase.ListPackages(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, pkg := range packages {
+		if pkg.Repository == repositoryName {
+			catalog.Packages = append(catalog.Packages, pkg)
+		}
+	}
+	if err := packageDatabase.IterateBundles(ctx, func(bundle *store.CachedBundle) error {
+		if bundle.Repository == repositoryName {
+			catalog.Bundles = append(catalog.Bundles, *bundle)
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	catalog.Warnings, err = packageDatabase.ListWarnings(ctx, repositoryName)
+	if err != nil {
+		return nil, err
+	}
+	return catalog, nil
+}
+
+// LoadFBC loads a file-based catalog from a directory or file. Unlike the registry's own loader it
+// doesn't reject invalid catalogs, so their problems can be reported
+func LoadFBC(path string) (*Catalog, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	var cfg *declcfg.DeclarativeConfig
+	if info.IsDir() {
+		cfg, err = declcfg.LoadFS(os.DirFS(path))
+	} else {
+		cfg, err = declcfg.LoadFile(os.DirFS(filepath.Dir(path)), filepath.Base(path))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error loading file-based catalog %s: %w", path, err)
+	}
+	return FromDeclarativeConfig(strings.TrimSuffix(filepath.Base(path), filepath.Ext(path)), cfg), nil
+}
+
+// FromDeclarativeConfig converts a declarative config into a catalog with the given repository name
+func FromDeclarativeConfig(name string, cfg *declcfg.DeclarativeConfig) *Catalog {
+	catalog := &Catalog{Name: name}
+	warn := func(bundleID string, field string, format string, args ...interface{}) {
+		catalog.Warnings = append(catalog.Warnings, store.CachedWarning{
+			WarningID:  fmt.Sprintf("%s/%04d", bundleID, len(catalog.Warnings)),
+			Repository: name,
+			BundleID:   bundleID,
+			Field:      field,
+			Message:    fmt.Sprintf(format, args...),
+		})
+	}
+
+	declcfgBundles := map[string]*declcfg.Bundle{}
+	for index := range cfg.Bundles {
+		bundle := &cfg.Bundles[index]
+		declcfgBundles[bundle.Package+"/"+bundle.Name] = bundle
+	}
+
+	packages := map[string]*store.CachedPackage{}
+	for _, pkg := range cfg.Packages {
+		packages[pkg.Name] = store.NewCachedPackage(name, &api.Package{
+			Name:               pkg.Name,
+			DefaultChannelName: pkg.DefaultChannel,
+		})
+	}
+
+	channelBundles := map[string][]store.CachedBundle{}
+	for _, channel := range cfg.Channels {
+		pkg, ok := packages[channel.Package]
+		if !ok {
+			warn(strings.Join([]string{name, channel.Package, channel.Name}, "/"), "channel", "channel %s belongs to unknown package %s", channel.Name, channel.Package)
+			continue
+		}
+		for _, entry := range channel.Entries {
+			bundleID := strings.Join([]string{name, channel.Package, channel.Name, entry.Name}, "/")
+			declcfgBundle, ok := declcfgBundles[channel.Package+"/"+entry.Name]
+			if !ok {
+				warn(bundleID, "channel", "channel %s lists bundle %s, which isn't defined", channel.Name, entry.Name)
+				continue
+			}
+			apiBundle, err := toAPIBundle(declcfgBundle, channel, entry)
+			if err != nil {
+				warn(bundleID, "properties", "%s", err)
+				continue
+			}
+			cachedBundle, warnings := store.NewCachedBundle(name, apiBundle, pkg)
+			catalog.Warnings = append(catalog.Warnings, warnings...)
+			channelBundles[channel.Package+"/"+channel.Name] = append(channelBundles[channel.Package+"/"+channel.Name], *cachedBundle)
+		}
+		pkg.Channels = append(pkg.Channels, &api.Channel{Name: channel.Name})
+	}
+
+	// channel heads and package metadata can only be set once all bundles are known
+	for _, pkg := range packages {
+		for _, channel := range pkg.Channels {
+			bundles := channelBundles[pkg.GetName()+"/"+channel.Name]
+			heads := resolution.NewChannelGraph(bundles).Heads()
+			if len(heads) == 0 {
+				continue
+			}
+			channel.CsvName = heads[0].CsvName
+			if channel.Name == pkg.GetDefaultChannelName() {
+				pkg.Metadata = store.PackageMetadata(&heads[0])
+			}
+		}
+		for index := range channelBundles {
+			if strings.HasPrefix(index, pkg.GetName()+"/") {
+				catalog.Bundles = append(catalog.Bundles, channelBundles[index]...)
+			}
+		}
+		catalog.Packages = append(catalog.Packages, *pkg)
+	}
+
+	sort.Slice(catalog.Packages, func(i, j int) bool {
+		return catalog.Packages[i].PackageID < catalog.Packages[j].PackageID
+	})
+	sort.SliceStable(catalog.Bundles, func(i, j int) bool {
+		return catalog.Bundles[i].BundleID < catalog.Bundles[j].BundleID
+	})
+	return catalog
+}
+
+func toAPIBundle(bundle *declcfg.Bundle, channel declcfg.Channel, entry declcfg.ChannelEntry) (*api.Bundle, error) {
+	hasPackageProperty := false
+	for _, prop := range bundle.Properties {
+		if prop.Type == property.TypePackage {
+			hasPackageProperty = true
+		}
+	}
+	if !hasPackageProperty {
+		return nil, fmt.Errorf("bundle %s has no %s property", bundle.Name, property.TypePackage)
+	}
+	return api.ConvertModelBundleToAPIBundle(model.Bundle{
+		Package:    &model.Package{Name: bundle.Package},
+		Channel:    &model.Channel{Name: channel.Name},
+		Name:       bundle.Name,
+		Image:      bundle.Image,
+		Replaces:   entry.Replaces,
+		Skips:      entry.Skips,
+		SkipRange:  entry.SkipRange,
+		Properties: bundle.Properties,
+		Objects:    bundle.Objects,
+		CsvJSON:    bundle.CsvJSON,
+	})
+}
+
+// FromSnapshot returns the content of a repository snapshot
+func FromSnapshot(snapshot *store.RepositorySnapshot) *Catalog {
+	return &Catalog{
+		Name:     snapshot.Repository.RepositoryName,
+		Packages: snapshot.Packages,
+		Bundles:  snapshot.Bundles,
+		Warnings: snapshot.Warnings,
+	}
+}
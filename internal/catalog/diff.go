@@ -0,0 +1,234 @@
+package catalog
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/perdasilva/olmcli/internal/resolution"
+	"github.com/perdasilva/olmcli/internal/store"
+)
+
+type ChangeKind string
+
+const (
+	PackageAdded          ChangeKind = "package added"
+	PackageRemoved        ChangeKind = "package removed"
+	DefaultChannelChanged ChangeKind = "default channel changed"
+	ChannelAdded          ChangeKind = "channel added"
+	ChannelRemoved        ChangeKind = "channel removed"
+	HeadMoved             ChangeKind = "head moved"
+	VersionAdded          ChangeKind = "version added"
+	VersionRemoved        ChangeKind = "version removed"
+	DependenciesChanged   ChangeKind = "dependencies changed"
+)
+
+// Change is a difference between two catalogs. Channel is empty for package level changes
+type Change struct {
+	Kind    ChangeKind
+	Package string
+	Channel string
+	Message string
+}
+
+// Diff returns the changes from one catalog to another, ordered by package and channel. The versions
+// and heads of added and removed packages and channels aren't reported separately. Dependency changes are
+// reported once per bundle, for the bundles in both catalogs
+func Diff(from *Catalog, to *Catalog) []Change {
+	var changes []Change
+	change := func(kind ChangeKind, packageName string, channelName string, format string, args ...interface{}) {
+		changes = append(changes, Change{
+			Kind:    kind,
+			Package: packageName,
+			Channel: channelName,
+			Message: fmt.Sprintf(format, args...),
+		})
+	}
+
+	oldPackages := diffPackages(from)
+	newPackages := diffPackages(to)
+	for _, packageName := range unionKeys(oldPackages, newPackages) {
+		oldPackage, inOld := oldPackages[packageName]
+		newPackage, inNew := newPackages[packageName]
+		switch {
+		case !inOld:
+			change(PackageAdded, packageName, "", "channels %s", strings.Join(sortedKeys(newPackage.channels), ", "))
+			continue
+		case !inNew:
+			change(PackageRemoved, packageName, "", "channels %s", strings.Join(sortedKeys(oldPackage.channels), ", "))
+			continue
+		}
+
+		if oldPackage.defaultChannel != newPackage.defaultChannel {
+			change(DefaultChannelChanged, packageName, "", "%s -> %s", oldPackage.defaultChannel, newPackage.defaultChannel)
+		}
+
+		for _, channelName := range unionKeys(oldPackage.channels, newPackage.channels) {
+			oldChannel, inOld := oldPackage.channels[channelName]
+			newChannel, inNew := newPackage.channels[channelName]
+			switch {
+			case !inOld:
+				change(ChannelAdded, packageName, channelName, "head %s", channelHead(newChannel))
+				continue
+			case !inNew:
+				change(ChannelRemoved, packageName, channelName, "head %s", channelHead(oldChannel))
+				continue
+			}
+
+			if oldHead, newHead := channelHead(oldChannel), channelHead(newChannel); oldHead != newHead {
+				change(HeadMoved, packageName, channelName, "%s -> %s", oldHead, newHead)
+			}
+			for _, bundle := range newChannel.Bundles() {
+				if oldChannel.Bundle(bundle.CsvName) == nil {
+					change(VersionAdded, packageName, channelName, "%s", bundleVersion(&bundle))
+				}
+			}
+			for _, bundle := range oldChannel.Bundles() {
+				if newChannel.Bundle(bundle.CsvName) == nil {
+					change(VersionRemoved, packageName, channelName, "%s", bundleVersion(&bundle))
+				}
+			}
+		}
+
+		for _, csvName := range sortedKeys(newPackage.bundles) {
+			oldBundle, ok := oldPackage.bundles[csvName]
+			if !ok {
+				continue
+			}
+			newBundle := newPackage.bundles[csvName]
+			oldDependencies := bundleDependencies(oldBundle)
+			newDependencies := bundleDependencies(newBundle)
+			added := difference(newDependencies, oldDependencies)
+			removed := difference(oldDependencies, newDependencies)
+			if len(added) == 0 && len(removed) == 0 {
+				continue
+			}
+			var details []string
+			for _, dependency := range added {
+				details = append(details, "+"+dependency)
+			}
+			for _, dependency := range removed {
+				details = append(details, "-"+dependency)
+			}
+			change(DependenciesChanged, packageName, "", "%s: %s", bundleVersion(newBundle), strings.Join(details, ", "))
+		}
+	}
+	return changes
+}
+
+// diffPackage is a package of a catalog with its channel graphs and its bundles by csv name
+type diffPackage struct {
+	defaultChannel string
+	channels       map[string]*resolution.ChannelGraph
+	bundles        map[string]*store.CachedBundle
+}
+
+func diffPackages(catalog *Catalog) map[string]*diffPackage {
+	packages := map[string]*diffPackage{}
+	getPackage := func(packageName string) *diffPackage {
+		if _, ok := packages[packageName]; !ok {
+			packages[packageName] = &diffPackage{
+				channels: map[string]*resolution.ChannelGraph{},
+				bundles:  map[string]*store.CachedBundle{},
+			}
+		}
+		return packages[packageName]
+	}
+	for _, pkg := range catalog.Packages {
+		getPackage(pkg.GetName()).defaultChannel = pkg.GetDefaultChannelName()
+	}
+
+	channelBundles := map[string]map[string][]store.CachedBundle{}
+	for index := range catalog.Bundles {
+		bundle := &catalog.Bundles[index]
+		getPackage(bundle.PackageName).bundles[bundle.CsvName] = bundle
+		if _, ok := channelBundles[bundle.PackageName]; !ok {
+			channelBundles[bundle.PackageName] = map[string][]store.CachedBundle{}
+		}
+		channelBundles[bundle.PackageName][bundle.ChannelName] = append(channelBundles[bundle.PackageName][bundle.ChannelName], *bundle)
+	}
+	for packageName, channels := range channelBundles {
+		for channelName, bundles := range channels {
+			packages[packageName].channels[channelName] = resolution.NewChannelGraph(bundles)
+		}
+	}
+	return packages
+}
+
+func channelHead(channel *resolution.ChannelGraph) string {
+	var heads []string
+	for _, head := range channel.Heads() {
+		heads = append(heads, bundleVersion(&head))
+	}
+	if len(heads) == 0 {
+		return "none"
+	}
+	return strings.Join(heads, ", ")
+}
+
+func bundleVersion(bundle *store.CachedBundle) string {
+	if bundle.Version == "" {
+		return bundle.CsvName
+	}
+	return fmt.Sprintf("%s (%s)", bundle.Version, bundle.CsvName)
+}
+
+// bundleDependencies describes each package, gvk and generic constraint dependency of the bundle
+func bundleDependencies(bundle *store.CachedBundle) []string {
+	var dependencies []string
+	for _, dependency := range bundle.PackageDependencies {
+		dependencies = append(dependencies, fmt.Sprintf("package %s %s", dependency.PackageName, dependency.Version))
+	}
+	for _, gvk := range bundle.GetRequiredApis() {
+		dependencies = append(dependencies, fmt.Sprintf("gvk %s/%s/%s", gvk.GetGroup(), gvk.GetVersion(), gvk.GetKind()))
+	}
+	for _, constraint := range bundle.Constraints {
+		if constraint.GVK != nil {
+			dependencies = append(dependencies, fmt.Sprintf("gvk %s/%s/%s", constraint.GVK.Group, constraint.GVK.Version, constraint.GVK.Kind))
+			continue
+		}
+		data, err := json.Marshal(constraint)
+		if err != nil {
+			continue
+		}
+		dependencies = append(dependencies, "constraint "+string(data))
+	}
+	sort.Strings(dependencies)
+	return dependencies
+}
+
+// difference returns the entries of a that aren't in b
+func difference(a []string, b []string) []string {
+	inB := map[string]struct{}{}
+	for _, entry := range b {
+		inB[entry] = struct{}{}
+	}
+	var entries []string
+	for _, entry := range a {
+		if _, ok := inB[entry]; !ok {
+			entries = append(entries, entry)
+		}
+	}
+	return entries
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func unionKeys[V any](a map[string]V, b map[string]V) []string {
+	union := map[string]struct{}{}
+	for key := range a {
+		union[key] = struct{}{}
+	}
+	for key := range b {
+		union[key] = struct{}{}
+	}
+	return sortedKeys(union)
+}
@@ -0,0 +1,74 @@
+package catalog_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/perdasilva/olmcli/internal/catalog"
+)
+
+func loadFixture(t *testing.T, path string) *catalog.Catalog {
+	t.Helper()
+	c, err := catalog.LoadFBC(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func TestDiff(t *testing.T) {
+	from := loadFixture(t, "testdata/diff/from.yaml")
+	to := loadFixture(t, "testdata/diff/to.yaml")
+
+	for _, tt := range []struct {
+		name     string
+		from     *catalog.Catalog
+		to       *catalog.Catalog
+		expected []catalog.Change
+	}{
+		{
+			name: "no changes",
+			from: from,
+			to:   from,
+		},
+		{
+			name: "changes ordered by package and channel",
+			from: from,
+			to:   to,
+			expected: []catalog.Change{
+				{Kind: catalog.DefaultChannelChanged, Package: "etcd", Message: "stable -> candidate"},
+				{Kind: catalog.ChannelAdded, Package: "etcd", Channel: "candidate", Message: "head 2.0.0 (etcd.v2.0.0)"},
+				{Kind: catalog.ChannelRemoved, Package: "etcd", Channel: "fast", Message: "head 1.1.0 (etcd.v1.1.0)"},
+				{Kind: catalog.HeadMoved, Package: "etcd", Channel: "stable", Message: "1.1.0 (etcd.v1.1.0) -> 1.2.0 (etcd.v1.2.0)"},
+				{Kind: catalog.VersionAdded, Package: "etcd", Channel: "stable", Message: "1.2.0 (etcd.v1.2.0)"},
+				{Kind: catalog.VersionRemoved, Package: "etcd", Channel: "stable", Message: "1.0.0 (etcd.v1.0.0)"},
+				{Kind: catalog.DependenciesChanged, Package: "etcd", Message: "1.1.0 (etcd.v1.1.0): +gvk vault.example.com/v1/Vault, +package vault >=2.0.0, -package vault >=1.0.0"},
+				{Kind: catalog.PackageRemoved, Package: "legacy", Message: "channels stable"},
+				{Kind: catalog.PackageAdded, Package: "vault", Message: "channels stable"},
+			},
+		},
+		{
+			name: "reversed",
+			from: to,
+			to:   from,
+			expected: []catalog.Change{
+				{Kind: catalog.DefaultChannelChanged, Package: "etcd", Message: "candidate -> stable"},
+				{Kind: catalog.ChannelRemoved, Package: "etcd", Channel: "candidate", Message: "head 2.0.0 (etcd.v2.0.0)"},
+				{Kind: catalog.ChannelAdded, Package: "etcd", Channel: "fast", Message: "head 1.1.0 (etcd.v1.1.0)"},
+				{Kind: catalog.HeadMoved, Package: "etcd", Channel: "stable", Message: "1.2.0 (etcd.v1.2.0) -> 1.1.0 (etcd.v1.1.0)"},
+				{Kind: catalog.VersionAdded, Package: "etcd", Channel: "stable", Message: "1.0.0 (etcd.v1.0.0)"},
+				{Kind: catalog.VersionRemoved, Package: "etcd", Channel: "stable", Message: "1.2.0 (etcd.v1.2.0)"},
+				{Kind: catalog.DependenciesChanged, Package: "etcd", Message: "1.1.0 (etcd.v1.1.0): +package vault >=1.0.0, -gvk vault.example.com/v1/Vault, -package vault >=2.0.0"},
+				{Kind: catalog.PackageAdded, Package: "legacy", Message: "channels stable"},
+				{Kind: catalog.PackageRemoved, Package: "vault", Message: "channels stable"},
+			},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			changes := catalog.Diff(tt.from, tt.to)
+			if !reflect.DeepEqual(changes, tt.expected) {
+				t.Errorf("expected changes:\n%+v\ngot:\n%+v", tt.expected, changes)
+			}
+		})
+	}
+}
@@ -0,0 +1,143 @@
+package catalog
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/operator-framework/operator-registry/alpha/declcfg"
+	"github.com/operator-framework/operator-registry/alpha/property"
+	"github.com/perdasilva/olmcli/internal/store"
+)
+
+// RelatedImages returns the images listed in the spec.relatedImages of the bundle's csv
+func RelatedImages(bundle *store.CachedBundle) ([]declcfg.RelatedImage, error) {
+	if bundle.GetCsvJson() == "" {
+		return nil, nil
+	}
+	csv := &struct {
+		Spec struct {
+			RelatedImages []declcfg.RelatedImage `json:"relatedImages"`
+		} `json:"spec"`
+	}{}
+	if err := json.Unmarshal([]byte(bundle.GetCsvJson()), csv); err != nil {
+		return nil, fmt.Errorf("error reading the csv of bundle %s: %w", bundle.BundleID, err)
+	}
+	return csv.Spec.RelatedImages, nil
+}
+
+// ToDeclarativeConfig renders bundles as a file-based catalog. The bundles of a package are
+// grouped in channels by their channel name, and a bundle listed in several channels is
+// rendered once. packages provides the default channel and description of the rendered
+// packages; a package whose default channel isn't rendered defaults to its first rendered channel
+func ToDeclarativeConfig(packages []store.CachedPackage, bundles []store.CachedBundle) (*declcfg.DeclarativeConfig, error) {
+	cfg := &declcfg.DeclarativeConfig{}
+
+	packagesByName := map[string]store.CachedPackage{}
+	for _, pkg := range packages {
+		packagesByName[pkg.GetName()] = pkg
+	}
+
+	sorted := append([]store.CachedBundle(nil), bundles...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].BundleID < sorted[j].BundleID
+	})
+
+	channels := map[string]*declcfg.Channel{}
+	packageChannels := map[string][]string{}
+	renderedBundles := map[string]struct{}{}
+	for index := range sorted {
+		bundle := &sorted[index]
+		channelKey := bundle.PackageName + "/" + bundle.ChannelName
+		channel, ok := channels[channelKey]
+		if !ok {
+			channel = &declcfg.Channel{
+				Schema:  declcfg.SchemaChannel,
+				Name:    bundle.ChannelName,
+				Package: bundle.PackageName,
+			}
+			channels[channelKey] = channel
+			packageChannels[bundle.PackageName] = append(packageChannels[bundle.PackageName], bundle.ChannelName)
+		}
+		channel.Entries = append(channel.Entries, declcfg.ChannelEntry{
+			Name:      bundle.CsvName,
+			Replaces:  bundle.Replaces,
+			Skips:     bundle.Skips,
+			SkipRange: bundle.SkipRange,
+		})
+
+		bundleKey := bundle.PackageName + "/" + bundle.CsvName
+		if _, ok := renderedBundles[bundleKey]; ok {
+			continue
+		}
+		renderedBundles[bundleKey] = struct{}{}
+		declcfgBundle, err := toDeclcfgBundle(bundle)
+		if err != nil {
+			return nil, err
+		}
+		cfg.Bundles = append(cfg.Bundles, *declcfgBundle)
+	}
+
+	packageNames := make([]string, 0, len(packageChannels))
+	for packageName := range packageChannels {
+		packageNames = append(packageNames, packageName)
+	}
+	sort.Strings(packageNames)
+	for _, packageName := range packageNames {
+		channelNames := packageChannels[packageName]
+		sort.Strings(channelNames)
+		declcfgPackage := declcfg.Package{
+			Schema:         declcfg.SchemaPackage,
+			Name:           packageName,
+			DefaultChannel: channelNames[0],
+		}
+		if pkg, ok := packagesByName[packageName]; ok {
+			for _, channelName := range channelNames {
+				if channelName == pkg.GetDefaultChannelName() {
+					declcfgPackage.DefaultChannel = channelName
+				}
+			}
+			if pkg.Metadata != nil {
+				declcfgPackage.Description = pkg.Metadata.Description
+				if len(pkg.Metadata.Icons) > 0 {
+					if data, err := base64.StdEncoding.DecodeString(pkg.Metadata.Icons[0].Data); err == nil {
+						declcfgPackage.Icon = &declcfg.Icon{Data: data, MediaType: pkg.Metadata.Icons[0].MediaType}
+					}
+				}
+			}
+		}
+		cfg.Packages = append(cfg.Packages, declcfgPackage)
+		for _, channelName := range channelNames {
+			cfg.Channels = append(cfg.Channels, *channels[packageName+"/"+channelName])
+		}
+	}
+	return cfg, nil
+}
+
+func toDeclcfgBundle(bundle *store.CachedBundle) (*declcfg.Bundle, error) {
+	relatedImages, err := RelatedImages(bundle)
+	if err != nil {
+		return nil, err
+	}
+	declcfgBundle := &declcfg.Bundle{
+		Schema:        declcfg.SchemaBundle,
+		Name:          bundle.CsvName,
+		Package:       bundle.PackageName,
+		Image:         bundle.BundlePath,
+		RelatedImages: relatedImages,
+		CsvJSON:       bundle.CsvJson,
+		Objects:       bundle.Object,
+	}
+	for _, prop := range bundle.Properties {
+		declcfgBundle.Properties = append(declcfgBundle.Properties, property.Property{
+			Type:  prop.GetType(),
+			Value: json.RawMessage(prop.GetValue()),
+		})
+	}
+	// the registry api drops the bundle object properties, they are rebuilt from the objects
+	for _, object := range bundle.Object {
+		declcfgBundle.Properties = append(declcfgBundle.Properties, property.MustBuildBundleObjectData([]byte(object)))
+	}
+	return declcfgBundle, nil
+}
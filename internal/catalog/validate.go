@@ -0,0 +1,385 @@
+package catalog
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/blang/semver/v4"
+	"github.com/operator-framework/api/pkg/constraints"
+	"github.com/perdasilva/olmcli/internal/resolution"
+	"github.com/perdasilva/olmcli/internal/store"
+)
+
+type Severity string
+
+const (
+	// SeverityError marks problems that make resolution fail or pick unusable bundles
+	SeverityError Severity = "error"
+	// SeverityWarning marks problems that are legal but likely unintended
+	SeverityWarning Severity = "warning"
+)
+
+// Problem is an issue found in a catalog
+type Problem struct {
+	Severity Severity
+	Package  string
+	BundleID string
+	Message  string
+}
+
+type validateConfig struct {
+	dependencySource store.PackageDatabase
+}
+
+type ValidateOption func(config *validateConfig)
+
+// WithDependencySource also looks for the dependencies of the catalog's bundles in the package database,
+// since the resolver can pick dependencies from any cached repository
+func WithDependencySource(packageDatabase store.PackageDatabase) ValidateOption {
+	return func(config *validateConfig) {
+		config.dependencySource = packageDatabase
+	}
+}
+
+// HasErrors returns true if any of the problems is an error
+func HasErrors(problems []Problem) bool {
+	for _, problem := range problems {
+		if problem.Severity == SeverityError {
+			return true
+		}
+	}
+	return false
+}
+
+// Validate checks a catalog for problems the resolver will trip on: invalid versions and ranges, dangling package
+// dependencies, required gvks and constraints no bundle satisfies, missing default channels, broken replaces chains,
+// conflicting csv names and dependency cycles between packages
+func Validate(ctx context.Context, catalog *Catalog, options ...ValidateOption) ([]Problem, error) {
+	config := &validateConfig{}
+	for _, opt := range options {
+		opt(config)
+	}
+
+	v := &validator{
+		ctx:            ctx,
+		catalog:        catalog,
+		config:         config,
+		celEnvironment: constraints.NewCelEnvironment(),
+	}
+	v.validateWarnings()
+	v.validateChannels()
+	v.validateCSVNames()
+	if err := v.validateDependencies(); err != nil {
+		return nil, err
+	}
+	v.validateDependencyCycles()
+
+	sort.SliceStable(v.problems, func(i, j int) bool {
+		if v.problems[i].Package != v.problems[j].Package {
+			return v.problems[i].Package < v.problems[j].Package
+		}
+		return v.problems[i].BundleID < v.problems[j].BundleID
+	})
+	return v.problems, nil
+}
+
+type validator struct {
+	ctx            context.Context
+	catalog        *Catalog
+	config         *validateConfig
+	celEnvironment *constraints.CelEnvironment
+	problems       []Problem
+	// packageEdges holds the packages each package depends on within the catalog
+	packageEdges   map[string]map[string]struct{}
+	currentPackage string
+}
+
+func (v *validator) report(severity Severity, packageName string, bundleID string, format string, args ...interface{}) {
+	v.problems = append(v.problems, Problem{
+		Severity: severity,
+		Package:  packageName,
+		BundleID: bundleID,
+		Message:  fmt.Sprintf(format, args...),
+	})
+}
+
+func (v *validator) validateWarnings() {
+	for _, warning := range v.catalog.Warnings {
+		packageName := ""
+		if parts := strings.Split(warning.BundleID, "/"); len(parts) > 1 {
+			packageName = parts[1]
+		}
+		v.report(SeverityWarning, packageName, warning.BundleID, "%s: %s", warning.Field, warning.Message)
+	}
+}
+
+func (v *validator) validateChannels() {
+	channels := map[string]map[string][]store.CachedBundle{}
+	for _, bundle := range v.catalog.Bundles {
+		if _, ok := channels[bundle.PackageName]; !ok {
+			channels[bundle.PackageName] = map[string][]store.CachedBundle{}
+		}
+		channels[bundle.PackageName][bundle.ChannelName] = append(channels[bundle.PackageName][bundle.ChannelName], bundle)
+	}
+
+	for _, pkg := range v.catalog.Packages {
+		packageName := pkg.GetName()
+		if pkg.GetDefaultChannelName() == "" {
+			v.report(SeverityError, packageName, "", "package has no default channel")
+		} else if _, ok := channels[packageName][pkg.GetDefaultChannelName()]; !ok {
+			v.report(SeverityError, packageName, "", "default channel %s doesn't exist or has no bundles", pkg.GetDefaultChannelName())
+		}
+	}
+
+	for packageName, packageChannels := range channels {
+		for channelName, bundles := range packageChannels {
+			graph := resolution.NewChannelGraph(bundles)
+			for _, cycle := range graph.ReplacesCycles() {
+				v.report(SeverityError, packageName, "", "channel %s has a replaces cycle: %s", channelName, strings.Join(append(cycle, cycle[0]), " -> "))
+			}
+			heads := graph.Heads()
+			switch {
+			case len(heads) == 0:
+				v.report(SeverityError, packageName, "", "channel %s has no head", channelName)
+			case len(heads) > 1:
+				var names []string
+				for _, head := range heads {
+					names = append(names, head.CsvName)
+				}
+				v.report(SeverityError, packageName, "", "channel %s has %d heads: %s", channelName, len(heads), strings.Join(names, ", "))
+			}
+			for csvName, replaces := range graph.MissingReplaces() {
+				v.report(SeverityWarning, packageName, graph.Bundle(csvName).BundleID, "replaces %s, which isn't in channel %s", replaces, channelName)
+			}
+		}
+	}
+}
+
+func (v *validator) validateCSVNames() {
+	type csvContent struct {
+		packageName string
+		version     string
+		image       string
+	}
+	csvs := map[string][]csvContent{}
+	for _, bundle := range v.catalog.Bundles {
+		content := csvContent{packageName: bundle.PackageName, version: bundle.Version, image: bundle.BundlePath}
+		found := false
+		for _, existing := range csvs[bundle.CsvName] {
+			if existing == content {
+				found = true
+			}
+		}
+		if !found {
+			csvs[bundle.CsvName] = append(csvs[bundle.CsvName], content)
+		}
+	}
+
+	for csvName, contents := range csvs {
+		if len(contents) < 2 {
+			continue
+		}
+		var descriptions []string
+		for _, content := range contents {
+			descriptions = append(descriptions, fmt.Sprintf("%s %s (%s)", content.packageName, content.version, content.image))
+		}
+		sort.Strings(descriptions)
+		v.report(SeverityError, contents[0].packageName, "", "csv %s is used by different bundles across channels: %s", csvName, strings.Join(descriptions, ", "))
+	}
+}
+
+func (v *validator) validateDependencies() error {
+	v.packageEdges = map[string]map[string]struct{}{}
+	for index := range v.catalog.Bundles {
+		bundle := &v.catalog.Bundles[index]
+		v.currentPackage = bundle.PackageName
+		for _, dependency := range bundle.PackageDependencies {
+			versionRange, err := semver.ParseRange(dependency.Version)
+			if err != nil {
+				// reported by the bundle warnings
+				continue
+			}
+			predicate := func(candidate *store.CachedBundle) (bool, error) {
+				version, err := semver.Parse(candidate.Version)
+				return candidate.PackageName == dependency.PackageName && err == nil && versionRange(version), nil
+			}
+			found, err := v.satisfiable(predicate, func() ([]store.CachedBundle, error) {
+				return v.config.dependencySource.GetBundlesForPackage(v.ctx, dependency.PackageName, store.InVersionRange(versionRange))
+			})
+			if err != nil {
+				return err
+			}
+			if !found {
+				v.report(SeverityError, bundle.PackageName, bundle.BundleID, "depends on package %s %s, which no bundle provides", dependency.PackageName, dependency.Version)
+			}
+		}
+
+		requiredGVKs := map[string]constraints.GVKConstraint{}
+		for _, gvk := range bundle.GetRequiredApis() {
+			requiredGVKs[fmt.Sprintf("%s/%s/%s", gvk.GetGroup(), gvk.GetVersion(), gvk.GetKind())] = constraints.GVKConstraint{Group: gvk.GetGroup(), Version: gvk.GetVersion(), Kind: gvk.GetKind()}
+		}
+		var otherConstraints []constraints.Constraint
+		for _, constraint := range bundle.Constraints {
+			switch {
+			case constraint.GVK != nil:
+				requiredGVKs[fmt.Sprintf("%s/%s/%s", constraint.GVK.Group, constraint.GVK.Version, constraint.GVK.Kind)] = *constraint.GVK
+			case constraint.Any != nil && len(constraint.Any.Constraints) == 0:
+				// invalid constraints are reported by the bundle warnings
+			case constraint.Not != nil:
+				// a top level not is a conflict, not a dependency, so nothing needs to satisfy it
+			default:
+				otherConstraints = append(otherConstraints, constraint)
+			}
+		}
+
+		for _, gvk := range requiredGVKs {
+			gvk := gvk
+			predicate, err := resolution.NewConstraintPredicate(constraints.Constraint{GVK: &gvk}, v.celEnvironment)
+			if err != nil {
+				return err
+			}
+			found, err := v.satisfiable(predicate, func() ([]store.CachedBundle, error) {
+				return v.config.dependencySource.ListBundlesForGVK(v.ctx, gvk.Group, gvk.Version, gvk.Kind)
+			})
+			if err != nil {
+				return err
+			}
+			if !found {
+				v.report(SeverityError, bundle.PackageName, bundle.BundleID, "requires %s/%s %s, which no bundle provides", gvk.Group, gvk.Version, gvk.Kind)
+			}
+		}
+
+		for _, constraint := range otherConstraints {
+			predicate, err := resolution.NewConstraintPredicate(constraint, v.celEnvironment)
+			if err != nil {
+				v.report(SeverityError, bundle.PackageName, bundle.BundleID, "invalid constraint: %s", err)
+				continue
+			}
+			found, err := v.satisfiable(predicate, func() ([]store.CachedBundle, error) {
+				var bundles []store.CachedBundle
+				err := v.config.dependencySource.IterateBundles(v.ctx, func(bundle *store.CachedBundle) error {
+					bundles = append(bundles, *bundle)
+					return nil
+				})
+				return bundles, err
+			})
+			if err != nil {
+				return err
+			}
+			if !found {
+				message := constraint.FailureMessage
+				if message == "" {
+					message = "no bundle satisfies its olm.constraint"
+				}
+				v.report(SeverityError, bundle.PackageName, bundle.BundleID, "%s", message)
+			}
+		}
+	}
+	return nil
+}
+
+// satisfiable returns true if a bundle of the catalog, or of the dependency source if set, satisfies the predicate.
+// Catalog bundles satisfying the predicate are recorded as dependencies of the bundle's package for cycle detection
+func (v *validator) satisfiable(predicate resolution.BundlePredicate, dependencySourceCandidates func() ([]store.CachedBundle, error)) (bool, error) {
+	found := false
+	for index := range v.catalog.Bundles {
+		ok, err := predicate(&v.catalog.Bundles[index])
+		if err != nil {
+			return false, err
+		}
+		if ok {
+			found = true
+			v.recordPackageEdge(v.catalog.Bundles[index].PackageName)
+		}
+	}
+	if found || v.config.dependencySource == nil {
+		return found, nil
+	}
+	candidates, err := dependencySourceCandidates()
+	if err != nil {
+		return false, err
+	}
+	for index := range candidates {
+		ok, err := predicate(&candidates[index])
+		if err != nil {
+			return false, err
+		}
+		if ok {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (v *validator) recordPackageEdge(dependencyPackage string) {
+	if dependencyPackage == v.currentPackage {
+		return
+	}
+	if _, ok := v.packageEdges[v.currentPackage]; !ok {
+		v.packageEdges[v.currentPackage] = map[string]struct{}{}
+	}
+	v.packageEdges[v.currentPackage][dependencyPackage] = struct{}{}
+}
+
+// validateDependencyCycles reports packages that depend on each other, directly or transitively. The resolver
+// handles cycles, but they usually point at a packaging mistake and make the install order arbitrary
+func (v *validator) validateDependencyCycles() {
+	// tarjan's strongly connected components
+	index := 0
+	indexes := map[string]int{}
+	lowLinks := map[string]int{}
+	onStack := map[string]bool{}
+	var stack []string
+
+	var packageNames []string
+	for packageName := range v.packageEdges {
+		packageNames = append(packageNames, packageName)
+	}
+	sort.Strings(packageNames)
+
+	var connect func(packageName string)
+	connect = func(packageName string) {
+		indexes[packageName] = index
+		lowLinks[packageName] = index
+		index++
+		stack = append(stack, packageName)
+		onStack[packageName] = true
+
+		for dependency := range v.packageEdges[packageName] {
+			if _, visited := indexes[dependency]; !visited {
+				connect(dependency)
+				if lowLinks[dependency] < lowLinks[packageName] {
+					lowLinks[packageName] = lowLinks[dependency]
+				}
+			} else if onStack[dependency] && indexes[dependency] < lowLinks[packageName] {
+				lowLinks[packageName] = indexes[dependency]
+			}
+		}
+
+		if lowLinks[packageName] != indexes[packageName] {
+			return
+		}
+		var component []string
+		for {
+			member := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			onStack[member] = false
+			component = append(component, member)
+			if member == packageName {
+				break
+			}
+		}
+		if len(component) > 1 {
+			sort.Strings(component)
+			v.report(SeverityWarning, component[0], "", "packages depend on each other: %s", strings.Join(component, ", "))
+		}
+	}
+
+	for _, packageName := range packageNames {
+		if _, visited := indexes[packageName]; !visited {
+			connect(packageName)
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package catalog_test
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/perdasilva/olmcli/internal/catalog"
+)
+
+func TestValidate(t *testing.T) {
+	for _, tt := range []struct {
+		name     string
+		fixture  string
+		expected []catalog.Problem
+	}{
+		{
+			name:    "top level not is a conflict",
+			fixture: "testdata/validate/not-constraint.yaml",
+		},
+		{
+			name:    "bundle lint findings are warnings",
+			fixture: "testdata/validate/lint-warning.yaml",
+			expected: []catalog.Problem{
+				{Severity: catalog.SeverityWarning, Package: "etcd", BundleID: "lint-warning/etcd/stable/etcd.v1.0.0", Message: "skipRange: invalid skip range \"not-a-range\": Could not get version from string: \"not-a-range\""},
+			},
+		},
+		{
+			name:    "packages depending on each other",
+			fixture: "testdata/validate/dependency-cycle.yaml",
+			expected: []catalog.Problem{
+				{Severity: catalog.SeverityWarning, Package: "alpha", Message: "packages depend on each other: alpha, beta"},
+				{Severity: catalog.SeverityWarning, Package: "delta", Message: "packages depend on each other: delta, epsilon, gamma"},
+			},
+		},
+		{
+			name:    "replaces cycle",
+			fixture: "testdata/validate/replaces-cycle.yaml",
+			expected: []catalog.Problem{
+				{Severity: catalog.SeverityError, Package: "etcd", Message: "channel stable has a replaces cycle: etcd.v1.0.0 -> etcd.v1.1.0 -> etcd.v1.0.0"},
+				{Severity: catalog.SeverityError, Package: "etcd", Message: "channel stable has no head"},
+			},
+		},
+		{
+			name:    "multiple heads and missing replaces",
+			fixture: "testdata/validate/multiple-heads.yaml",
+			expected: []catalog.Problem{
+				{Severity: catalog.SeverityError, Package: "etcd", Message: "channel stable has 2 heads: etcd.v2.1.0, etcd.v2.0.0"},
+				{Severity: catalog.SeverityWarning, Package: "etcd", BundleID: "multiple-heads/etcd/stable/etcd.v2.1.0", Message: "replaces etcd.v1.9.0, which isn't in channel stable"},
+			},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			problems, err := catalog.Validate(context.Background(), loadFixture(t, tt.fixture))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(problems, tt.expected) {
+				t.Errorf("expected problems %+v, got %+v", tt.expected, problems)
+			}
+		})
+	}
+}
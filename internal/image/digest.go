@@ -0,0 +1,63 @@
+package image
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+	"sync"
+)
+
+// DigestResolver returns the digest of the manifest an image reference points to, e.g. sha256:0123...
+type DigestResolver func(ctx context.Context, imageRef string) (string, error)
+
+// Digest returns the digest of an image reference, or an empty string if the reference is not by digest
+func Digest(imageRef string) string {
+	if _, digest, ok := strings.Cut(imageRef, "@"); ok {
+		return digest
+	}
+	return ""
+}
+
+// ResolveDigest returns the digest of the image reference. The digest of a reference by tag is looked up
+// in its registry with docker, without pulling the image
+func ResolveDigest(ctx context.Context, imageRef string) (string, error) {
+	if digest := Digest(imageRef); digest != "" {
+		return digest, nil
+	}
+	inspectCmd := []string{"docker", "buildx", "imagetools", "inspect", "--format", "{{.Manifest.Digest}}", imageRef}
+	stdout, err := exec.CommandContext(ctx, inspectCmd[0], inspectCmd[1:]...).Output()
+	if err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok && len(exitErr.Stderr) > 0 {
+			err = fmt.Errorf("%s", strings.TrimSpace(string(exitErr.Stderr)))
+		}
+		return "", fmt.Errorf("error resolving the digest of image %s: %w", imageRef, err)
+	}
+	digest := strings.TrimSpace(string(stdout))
+	if !strings.Contains(digest, ":") {
+		return "", fmt.Errorf("error resolving the digest of image %s: unexpected digest %q", imageRef, digest)
+	}
+	return digest, nil
+}
+
+// CachingDigestResolver resolves the digest of each image reference once, resolutions that failed are retried
+func CachingDigestResolver(resolver DigestResolver) DigestResolver {
+	var lock sync.Mutex
+	digests := map[string]string{}
+	return func(ctx context.Context, imageRef string) (string, error) {
+		lock.Lock()
+		digest, ok := digests[imageRef]
+		lock.Unlock()
+		if ok {
+			return digest, nil
+		}
+		digest, err := resolver(ctx, imageRef)
+		if err != nil {
+			return "", err
+		}
+		lock.Lock()
+		digests[imageRef] = digest
+		lock.Unlock()
+		return digest, nil
+	}
+}
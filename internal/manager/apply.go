@@ -0,0 +1,272 @@
+package manager
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/operator-framework/rukpak/api/v1alpha1"
+	"github.com/perdasilva/olmcli/internal/resolution"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/yaml"
+)
+
+// PackageSet describes the desired set of installed packages
+type PackageSet struct {
+	// Repositories are added to the package database if they are not already present
+	Repositories []RepositorySpec `json:"repositories,omitempty"`
+	// BundleDeployment overrides the configured bundle deployment template for all packages in the set
+	BundleDeployment BundleDeploymentTemplate `json:"bundleDeployment,omitempty"`
+	// Packages are the packages that should be installed
+	Packages []PackageSpec `json:"packages"`
+}
+
+type RepositorySpec struct {
+	Image string `json:"image"`
+}
+
+type PackageSpec struct {
+	Name       string `json:"name"`
+	Repository string `json:"repository,omitempty"`
+	Channel    string `json:"channel,omitempty"`
+	// Version is a semver range, e.g. ">=1.0.0 <2.0.0"
+	Version string `json:"version,omitempty"`
+	// BundleDeployment overrides the bundle deployment template for this package
+	BundleDeployment BundleDeploymentTemplate `json:"bundleDeployment,omitempty"`
+}
+
+// LoadPackageSet reads a package set from a yaml or json file
+func LoadPackageSet(path string) (*PackageSet, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	packageSet := &PackageSet{}
+	if err := yaml.UnmarshalStrict(data, packageSet); err != nil {
+		return nil, fmt.Errorf("error parsing package set %s: %w", path, err)
+	}
+	for index, pkg := range packageSet.Packages {
+		if pkg.Name == "" {
+			return nil, fmt.Errorf("error parsing package set %s: package at index %d has no name", path, index)
+		}
+	}
+	return packageSet, nil
+}
+
+// RequiredPackages converts the package set into the resolver's required packages
+func (s *PackageSet) RequiredPackages() ([]*resolution.RequiredPackage, error) {
+	requiredPackages := make([]*resolution.RequiredPackage, 0, len(s.Packages))
+	for _, pkg := range s.Packages {
+		var options []resolution.Option
+		if pkg.Repository != "" {
+			options = append(options, resolution.InRepo(pkg.Repository))
+		}
+		if pkg.Channel != "" {
+			options = append(options, resolution.InChan(pkg.Channel))
+		}
+		if pkg.Version != "" {
+			options = append(options, resolution.InVersionRange(pkg.Version))
+		}
+		requiredPackage, err := resolution.NewRequiredPackage(pkg.Name, options...)
+		if err != nil {
+			return nil, fmt.Errorf("invalid package %s: %w", pkg.Name, err)
+		}
+		requiredPackages = append(requiredPackages, requiredPackage)
+	}
+	return requiredPackages, nil
+}
+
+// ChangeAction is the action taken on a package to converge the cluster to the desired state
+type ChangeAction string
+
+const (
+	ChangeActionInstall   ChangeAction = "install"
+	ChangeActionUpgrade   ChangeAction = "upgrade"
+	ChangeActionRemove    ChangeAction = "remove"
+	ChangeActionUnchanged ChangeAction = "unchanged"
+	// ChangeActionHeld leaves a held package that would otherwise be removed installed
+	ChangeActionHeld ChangeAction = "held"
+)
+
+// Change describes what happens to a single package when applying a package set
+type Change struct {
+	Action           ChangeAction
+	PackageName      string
+	BundleID         string
+	FromVersion      string
+	ToVersion        string
+	BundleDeployment *v1alpha1.BundleDeployment
+}
+
+// ListInstalled returns the bundle deployments created by olm
+func (p *PackageInstaller) ListInstalled(ctx context.Context) ([]v1alpha1.BundleDeployment, error) {
+	c, err := p.client.Get()
+	if err != nil {
+		return nil, err
+	}
+	bundleDeploymentList := &v1alpha1.BundleDeploymentList{}
+	if err := c.List(ctx, bundleDeploymentList); err != nil {
+		return nil, err
+	}
+	var installed []v1alpha1.BundleDeployment
+	for _, bundleDeployment := range bundleDeploymentList.Items {
+		if _, ok := bundleDeployment.GetAnnotations()[repositoryAnnotation]; ok {
+			installed = append(installed, bundleDeployment)
+		}
+	}
+	return installed, nil
+}
+
+// Apply resolves the required packages together and installs, upgrades, and (when pruning) removes
+// bundle deployments until the cluster matches the resolution
+func (p *PackageInstaller) Apply(ctx context.Context, requiredPackages []*resolution.RequiredPackage, options ...InstallOption) ([]Change, error) {
+	config := newInstallConfig(options...)
+
+	installables, err := p.Resolve(ctx, requiredPackages...)
+	if err != nil {
+		return nil, err
+	}
+
+	installed, err := p.ListInstalled(ctx)
+	if err != nil {
+		return nil, err
+	}
+	changes := p.planChanges(installables, installed, config)
+
+	// the planned changes are returned along with any error so the caller can tell what was attempted
+	for _, change := range changes {
+		if err := p.applyChange(ctx, change, config.dryRun); err != nil {
+			return changes, err
+		}
+	}
+	return changes, nil
+}
+
+// planChanges returns the changes that converge the installed bundle deployments to the installables
+func (p *PackageInstaller) planChanges(installables []resolution.Installable, installed []v1alpha1.BundleDeployment, config *installConfig) []Change {
+	installedByName := make(map[string]*v1alpha1.BundleDeployment, len(installed))
+	for index := range installed {
+		installedByName[installed[index].GetName()] = &installed[index]
+	}
+
+	var changes []Change
+	for _, installable := range installables {
+		desired := p.bundleDeploymentFromInstallable(&installable, config)
+		current, ok := installedByName[desired.GetName()]
+		delete(installedByName, desired.GetName())
+		change := Change{
+			PackageName:      installable.PackageName,
+			BundleID:         installable.BundleID,
+			ToVersion:        installable.Version,
+			BundleDeployment: desired,
+		}
+		switch {
+		case !ok:
+			change.Action = ChangeActionInstall
+		case needsUpgrade(current, desired):
+			change.Action = ChangeActionUpgrade
+			change.FromVersion = current.GetAnnotations()[versionAnnotation]
+		default:
+			change.Action = ChangeActionUnchanged
+			change.FromVersion = current.GetAnnotations()[versionAnnotation]
+		}
+		changes = append(changes, change)
+	}
+
+	if config.prune {
+		var names []string
+		for name := range installedByName {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			current := installedByName[name]
+			change := Change{
+				Action:           ChangeActionRemove,
+				PackageName:      name,
+				FromVersion:      current.GetAnnotations()[versionAnnotation],
+				BundleDeployment: current,
+			}
+			// held packages are never moved, pruning them requires removing the hold first
+			if versionRange, ok := current.GetAnnotations()[holdAnnotation]; ok {
+				p.logger.Warnf("Not removing %s, it is held at %s: unhold it to prune it", name, versionRange)
+				change.Action = ChangeActionHeld
+				change.ToVersion = change.FromVersion
+			}
+			changes = append(changes, change)
+		}
+	}
+	return changes
+}
+
+func (p *PackageInstaller) applyChange(ctx context.Context, change Change, dryRun DryRunMode) error {
+	if change.Action == ChangeActionUnchanged || change.Action == ChangeActionHeld || dryRun == DryRunClient {
+		return nil
+	}
+
+	c, err := p.client.Get()
+	if err != nil {
+		return err
+	}
+
+	var createOptions []client.CreateOption
+	var updateOptions []client.UpdateOption
+	var deleteOptions []client.DeleteOption
+	if dryRun == DryRunServer {
+		createOptions = append(createOptions, client.DryRunAll)
+		updateOptions = append(updateOptions, client.DryRunAll)
+		deleteOptions = append(deleteOptions, client.DryRunAll)
+	}
+
+	switch change.Action {
+	case ChangeActionInstall:
+		p.logger.Printf("Installing %s %s", change.PackageName, change.ToVersion)
+		if err := c.Create(ctx, change.BundleDeployment, createOptions...); err != nil {
+			return err
+		}
+	case ChangeActionUpgrade:
+		p.logger.Printf("Upgrading %s from %s to %s", change.PackageName, change.FromVersion, change.ToVersion)
+		current := &v1alpha1.BundleDeployment{}
+		if err := c.Get(ctx, client.ObjectKeyFromObject(change.BundleDeployment), current); err != nil {
+			return err
+		}
+		current.SetLabels(mergeMaps(current.GetLabels(), change.BundleDeployment.GetLabels()))
+		current.SetAnnotations(mergeMaps(current.GetAnnotations(), change.BundleDeployment.GetAnnotations()))
+		current.Spec = change.BundleDeployment.Spec
+		if err := c.Update(ctx, current, updateOptions...); err != nil {
+			return err
+		}
+	case ChangeActionRemove:
+		p.logger.Printf("Removing %s %s", change.PackageName, change.FromVersion)
+		return c.Delete(ctx, change.BundleDeployment, deleteOptions...)
+	}
+
+	if dryRun == DryRunServer {
+		return nil
+	}
+	return p.watchInstallation(ctx, c, client.ObjectKeyFromObject(change.BundleDeployment))
+}
+
+// needsUpgrade returns true if the installed bundle deployment differs from the desired one
+func needsUpgrade(current *v1alpha1.BundleDeployment, desired *v1alpha1.BundleDeployment) bool {
+	for _, annotation := range []string{repositoryAnnotation, versionAnnotation, channelAnnotation} {
+		if current.GetAnnotations()[annotation] != desired.GetAnnotations()[annotation] {
+			return true
+		}
+	}
+	if current.Spec.ProvisionerClassName != desired.Spec.ProvisionerClassName {
+		return true
+	}
+	if current.Spec.Template == nil || desired.Spec.Template == nil {
+		return current.Spec.Template != desired.Spec.Template
+	}
+	currentSpec, desiredSpec := current.Spec.Template.Spec, desired.Spec.Template.Spec
+	if currentSpec.ProvisionerClassName != desiredSpec.ProvisionerClassName {
+		return true
+	}
+	if currentSpec.Source.Image == nil || desiredSpec.Source.Image == nil {
+		return currentSpec.Source.Image != desiredSpec.Source.Image
+	}
+	return *currentSpec.Source.Image != *desiredSpec.Source.Image
+}
@@ -0,0 +1,161 @@
+package manager
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/operator-framework/rukpak/api/v1alpha1"
+	"github.com/perdasilva/olmcli/internal/resolution"
+)
+
+func TestPlanChanges(t *testing.T) {
+	held := installedBundleDeployment(t, "held", "stable", "1.0.0")
+	held.Annotations[holdAnnotation] = "1.0.0"
+
+	for _, tt := range []struct {
+		name         string
+		installables []resolution.Installable
+		installed    []*v1alpha1.BundleDeployment
+		prune        bool
+		// expected lists the action of each change, keyed by package
+		expected map[string]ChangeAction
+	}{
+		{
+			name:         "install",
+			installables: []resolution.Installable{fixtureInstallable("etcd", "stable", "1.0.0")},
+			expected:     map[string]ChangeAction{"etcd": ChangeActionInstall},
+		},
+		{
+			name:         "unchanged",
+			installables: []resolution.Installable{fixtureInstallable("etcd", "stable", "1.0.0")},
+			installed:    []*v1alpha1.BundleDeployment{installedBundleDeployment(t, "etcd", "stable", "1.0.0")},
+			expected:     map[string]ChangeAction{"etcd": ChangeActionUnchanged},
+		},
+		{
+			name:         "upgrade",
+			installables: []resolution.Installable{fixtureInstallable("etcd", "stable", "1.1.0")},
+			installed:    []*v1alpha1.BundleDeployment{installedBundleDeployment(t, "etcd", "stable", "1.0.0")},
+			expected:     map[string]ChangeAction{"etcd": ChangeActionUpgrade},
+		},
+		{
+			name:         "channel switch",
+			installables: []resolution.Installable{fixtureInstallable("etcd", "fast", "1.0.0")},
+			installed:    []*v1alpha1.BundleDeployment{installedBundleDeployment(t, "etcd", "stable", "1.0.0")},
+			expected:     map[string]ChangeAction{"etcd": ChangeActionUpgrade},
+		},
+		{
+			name:         "kept without prune",
+			installables: []resolution.Installable{fixtureInstallable("etcd", "stable", "1.0.0")},
+			installed:    []*v1alpha1.BundleDeployment{installedBundleDeployment(t, "etcd", "stable", "1.0.0"), installedBundleDeployment(t, "prometheus", "stable", "1.0.0")},
+			expected:     map[string]ChangeAction{"etcd": ChangeActionUnchanged},
+		},
+		{
+			name:         "remove",
+			installables: []resolution.Installable{fixtureInstallable("etcd", "stable", "1.0.0")},
+			installed:    []*v1alpha1.BundleDeployment{installedBundleDeployment(t, "etcd", "stable", "1.0.0"), installedBundleDeployment(t, "prometheus", "stable", "1.0.0")},
+			prune:        true,
+			expected:     map[string]ChangeAction{"etcd": ChangeActionUnchanged, "prometheus": ChangeActionRemove},
+		},
+		{
+			name:      "held",
+			installed: []*v1alpha1.BundleDeployment{held},
+			prune:     true,
+			expected:  map[string]ChangeAction{"held": ChangeActionHeld},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			var installed []v1alpha1.BundleDeployment
+			for _, bundleDeployment := range tt.installed {
+				installed = append(installed, *bundleDeployment.DeepCopy())
+			}
+			config := newInstallConfig()
+			config.prune = tt.prune
+
+			changes := newTestInstaller(t, BundleDeploymentTemplate{}, nil).planChanges(tt.installables, installed, config)
+			actions := map[string]ChangeAction{}
+			for _, change := range changes {
+				actions[change.PackageName] = change.Action
+			}
+			if !reflect.DeepEqual(actions, tt.expected) {
+				t.Errorf("expected changes %v, got %v", tt.expected, actions)
+			}
+		})
+	}
+}
+
+func TestNeedsUpgrade(t *testing.T) {
+	for _, tt := range []struct {
+		name     string
+		modify   func(desired *v1alpha1.BundleDeployment)
+		expected bool
+	}{
+		{
+			name:   "same",
+			modify: func(*v1alpha1.BundleDeployment) {},
+		},
+		{
+			name: "version",
+			modify: func(desired *v1alpha1.BundleDeployment) {
+				desired.Annotations[versionAnnotation] = "1.1.0"
+			},
+			expected: true,
+		},
+		{
+			name: "channel",
+			modify: func(desired *v1alpha1.BundleDeployment) {
+				desired.Annotations[channelAnnotation] = "fast"
+			},
+			expected: true,
+		},
+		{
+			name: "provisioner class",
+			modify: func(desired *v1alpha1.BundleDeployment) {
+				desired.Spec.ProvisionerClassName = "other"
+			},
+			expected: true,
+		},
+		{
+			name: "bundle provisioner class",
+			modify: func(desired *v1alpha1.BundleDeployment) {
+				desired.Spec.Template.Spec.ProvisionerClassName = "other"
+			},
+			expected: true,
+		},
+		{
+			name: "image",
+			modify: func(desired *v1alpha1.BundleDeployment) {
+				desired.Spec.Template.Spec.Source.Image.Ref = "quay.io/fixture/etcd-bundle@sha256:0123"
+			},
+			expected: true,
+		},
+		{
+			name: "pull secret",
+			modify: func(desired *v1alpha1.BundleDeployment) {
+				desired.Spec.Template.Spec.Source.Image.ImagePullSecretName = ""
+			},
+			expected: true,
+		},
+		{
+			name: "labels",
+			modify: func(desired *v1alpha1.BundleDeployment) {
+				desired.Labels = map[string]string{"team": "storage"}
+			},
+		},
+		{
+			name: "no template",
+			modify: func(desired *v1alpha1.BundleDeployment) {
+				desired.Spec.Template = nil
+			},
+			expected: true,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			current := installedBundleDeployment(t, "etcd", "stable", "1.0.0")
+			desired := current.DeepCopy()
+			tt.modify(desired)
+			if upgrade := needsUpgrade(current, desired); upgrade != tt.expected {
+				t.Errorf("expected needsUpgrade to be %t, got %t", tt.expected, upgrade)
+			}
+		})
+	}
+}
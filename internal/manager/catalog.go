@@ -0,0 +1,217 @@
+package manager
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/operator-framework/operator-registry/alpha/declcfg"
+	"github.com/perdasilva/olmcli/internal/catalog"
+	"github.com/perdasilva/olmcli/internal/resolution"
+	"github.com/perdasilva/olmcli/internal/store"
+)
+
+// snapshotRepositorySeparator separates the path of a snapshot from the name of one of its repositories
+const snapshotRepositorySeparator = "#"
+
+// Catalog returns the content of a cached repository, of the file-based catalog at the given path, or of
+// a repository of the snapshot at the given path. Repositories of snapshots holding several repositories
+// are named with <path>#<repository>
+func (m *containerBasedManager) Catalog(ctx context.Context, nameOrPath string) (*catalog.Catalog, error) {
+	if _, err := os.Stat(nameOrPath); err == nil {
+		if isSnapshot(nameOrPath) {
+			return snapshotCatalog(nameOrPath, "")
+		}
+		return catalog.LoadFBC(nameOrPath)
+	}
+	if snapshotPath, repoName, ok := strings.Cut(nameOrPath, snapshotRepositorySeparator); ok {
+		if _, err := os.Stat(snapshotPath); err == nil {
+			return snapshotCatalog(snapshotPath, repoName)
+		}
+	}
+	return catalog.FromDatabase(ctx, m.PackageDatabase, nameOrPath)
+}
+
+// isSnapshot returns true if the file is gzip compressed, which file-based catalogs never are
+func isSnapshot(path string) bool {
+	file, err := os.Open(path)
+	if err != nil {
+		return false
+	}
+	defer file.Close()
+	header, err := bufio.NewReader(file).Peek(2)
+	return err == nil && bytes.Equal(header, []byte{0x1f, 0x8b})
+}
+
+// snapshotCatalog returns the named repository of the snapshot, which may be left empty if the snapshot holds a single repository
+func snapshotCatalog(snapshotPath string, repoName string) (*catalog.Catalog, error) {
+	file, err := os.Open(snapshotPath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	snapshots, err := store.ReadSnapshot(file)
+	if err != nil {
+		return nil, fmt.Errorf("error reading snapshot %s: %w", snapshotPath, err)
+	}
+
+	var repoNames []string
+	for index := range snapshots {
+		if snapshots[index].Repository.RepositoryName == repoName || (repoName == "" && len(snapshots) == 1) {
+			return catalog.FromSnapshot(&snapshots[index]), nil
+		}
+		repoNames = append(repoNames, snapshots[index].Repository.RepositoryName)
+	}
+	if repoName == "" {
+		return nil, fmt.Errorf("snapshot %s holds repositories %s: name one with %s%s<repository>", snapshotPath, strings.Join(repoNames, ", "), snapshotPath, snapshotRepositorySeparator)
+	}
+	return nil, fmt.Errorf("repository %s not found in snapshot %s", repoName, snapshotPath)
+}
+
+// ValidateCatalog validates the catalog. Unless standalone is set, dependencies may be satisfied by any cached repository
+func (m *containerBasedManager) ValidateCatalog(ctx context.Context, c *catalog.Catalog, standalone bool) ([]catalog.Problem, error) {
+	var options []catalog.ValidateOption
+	if !standalone {
+		options = append(options, catalog.WithDependencySource(m.PackageDatabase))
+	}
+	return catalog.Validate(ctx, c, options...)
+}
+
+// RenderCatalog renders the packages and their dependencies as a file-based catalog. The packages
+// and their dependencies are resolved together: the packages are rendered with their given channel,
+// or all their channels if channel is empty, and each dependency with the channel of its resolved bundle.
+// Channels are rendered from the repository the resolved bundle comes from. The dependencies of the other
+// bundles of the rendered channels are rendered too, with the channel of their preferred candidate, so
+// the catalog is self-contained. Resolution doesn't contact the cluster
+func (m *containerBasedManager) RenderCatalog(ctx context.Context, channel string, packageNames ...string) (*declcfg.DeclarativeConfig, error) {
+	var options []resolution.Option
+	if channel != "" {
+		options = append(options, resolution.InChan(channel))
+	}
+	requiredPackages := make([]*resolution.RequiredPackage, 0, len(packageNames))
+	selected := map[string]struct{}{}
+	for _, packageName := range packageNames {
+		requiredPackage, err := resolution.NewRequiredPackage(packageName, options...)
+		if err != nil {
+			return nil, err
+		}
+		requiredPackages = append(requiredPackages, requiredPackage)
+		selected[packageName] = struct{}{}
+	}
+	installables, err := m.offlineSolver.Solve(ctx, requiredPackages...)
+	if err != nil {
+		return nil, err
+	}
+	// channels are rendered without the bundles resolution excludes, e.g. denied bundles
+	entitySource, err := m.offlineSolver.EntitySource(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var bundles []store.CachedBundle
+	rendered := map[string]struct{}{}
+	renderChannel := func(bundle *store.CachedBundle, channel string) error {
+		searchOptions := []store.PackageSearchOption{store.InRepositories(bundle.Repository)}
+		if channel != "" {
+			searchOptions = append(searchOptions, store.InChannel(channel))
+		}
+		channelBundles, err := entitySource.GetBundlesForPackage(ctx, bundle.PackageName, searchOptions...)
+		if err != nil {
+			return err
+		}
+		for _, channelBundle := range channelBundles {
+			if _, ok := rendered[channelBundle.BundleID]; !ok {
+				rendered[channelBundle.BundleID] = struct{}{}
+				bundles = append(bundles, channelBundle)
+			}
+		}
+		return nil
+	}
+	for _, installable := range installables {
+		renderedChannel := channel
+		if _, ok := selected[installable.PackageName]; !ok {
+			renderedChannel = installable.ChannelName
+		}
+		if err := renderChannel(&installable.CachedBundle, renderedChannel); err != nil {
+			return nil, err
+		}
+	}
+	// bundles appended while rendering the dependencies get their dependencies rendered in turn
+	for index := 0; index < len(bundles); index++ {
+		dependencies, err := m.offlineSolver.Dependencies(ctx, bundles[index])
+		if err != nil {
+			return nil, err
+		}
+		for _, candidates := range dependencies {
+			if len(candidates) == 0 || anyRendered(candidates, rendered) {
+				continue
+			}
+			if err := renderChannel(&candidates[0], candidates[0].ChannelName); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	packages, err := m.bundlePackages(ctx, bundles)
+	if err != nil {
+		return nil, err
+	}
+	cfg, err := catalog.ToDeclarativeConfig(packages, bundles)
+	if err != nil {
+		return nil, err
+	}
+	if err := m.validateRenderedCatalog(ctx, cfg); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// anyRendered returns true if one of the bundles is rendered
+func anyRendered(bundles []store.CachedBundle, rendered map[string]struct{}) bool {
+	for _, bundle := range bundles {
+		if _, ok := rendered[bundle.BundleID]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// validateRenderedCatalog fails if rendering introduced errors, i.e. dependencies the cached repositories satisfy
+// but the rendered catalog doesn't. Other problems, e.g. lint findings of the repositories or channels broken up by
+// excluded bundles, are logged as warnings
+func (m *containerBasedManager) validateRenderedCatalog(ctx context.Context, cfg *declcfg.DeclarativeConfig) error {
+	rendered := catalog.FromDeclarativeConfig("rendered", cfg)
+	problems, err := catalog.Validate(ctx, rendered)
+	if err != nil {
+		return err
+	}
+	// problems that remain when the dependencies may come from any cached repository aren't due to rendering
+	cachedProblems, err := catalog.Validate(ctx, rendered, catalog.WithDependencySource(m.PackageDatabase))
+	if err != nil {
+		return err
+	}
+	upstream := map[catalog.Problem]struct{}{}
+	for _, problem := range cachedProblems {
+		upstream[problem] = struct{}{}
+	}
+
+	var messages []string
+	for _, problem := range problems {
+		subject := problem.BundleID
+		if subject == "" {
+			subject = problem.Package
+		}
+		if _, ok := upstream[problem]; ok || problem.Severity != catalog.SeverityError {
+			m.logger.Warnf("Rendered catalog: %s: %s", subject, problem.Message)
+			continue
+		}
+		messages = append(messages, fmt.Sprintf("%s: %s", subject, problem.Message))
+	}
+	if len(messages) > 0 {
+		return fmt.Errorf("the rendered catalog is invalid:\n  %s", strings.Join(messages, "\n  "))
+	}
+	return nil
+}
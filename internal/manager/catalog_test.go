@@ -0,0 +1,97 @@
+package manager
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/operator-framework/operator-registry/alpha/property"
+	"github.com/operator-framework/operator-registry/pkg/api"
+	"github.com/perdasilva/olmcli/internal/catalog"
+	"github.com/perdasilva/olmcli/internal/store"
+	"github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+)
+
+// renderableBundle is a fixture bundle with the properties of its package and dependency, if set
+func renderableBundle(packageName string, version string, replaces string, dependency string) store.CachedBundle {
+	bundle := fixtureBundle(packageName, "stable", version, replaces)
+	bundle.Properties = []*api.Property{
+		{Type: property.TypePackage, Value: fmt.Sprintf(`{"packageName":%q,"version":%q}`, packageName, version)},
+	}
+	if dependency != "" {
+		bundle.Properties = append(bundle.Properties, &api.Property{Type: property.TypePackageRequired, Value: fmt.Sprintf(`{"packageName":%q,"versionRange":">=1.0.0"}`, dependency)})
+	}
+	return bundle
+}
+
+func TestValidateRenderedCatalog(t *testing.T) {
+	vault := renderableBundle("vault", "1.0.0", "", "")
+	m := newTestManager(t, vault)
+
+	for _, tt := range []struct {
+		name    string
+		bundles []store.CachedBundle
+		err     string
+		// warnings are logged for the problems that aren't due to rendering
+		warnings []string
+	}{
+		{
+			name:    "dependency left out of the rendered catalog",
+			bundles: []store.CachedBundle{renderableBundle("etcd", "1.0.0", "", "vault")},
+			err:     "depends on package vault >=1.0.0, which no bundle provides",
+		},
+		{
+			name:    "rendered dependency",
+			bundles: []store.CachedBundle{renderableBundle("etcd", "1.0.0", "", "vault"), vault},
+		},
+		{
+			name:     "dependency no cached bundle provides",
+			bundles:  []store.CachedBundle{renderableBundle("etcd", "1.0.0", "", "ghost")},
+			warnings: []string{"depends on package ghost >=1.0.0, which no bundle provides"},
+		},
+		{
+			name:     "channel with several heads",
+			bundles:  []store.CachedBundle{renderableBundle("etcd", "1.0.0", "", ""), renderableBundle("etcd", "1.1.0", "", "")},
+			warnings: []string{"channel stable has 2 heads"},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			logger, hook := test.NewNullLogger()
+			m.logger = logger
+			packages, err := m.bundlePackages(context.Background(), tt.bundles)
+			if err != nil {
+				t.Fatal(err)
+			}
+			cfg, err := catalog.ToDeclarativeConfig(packages, tt.bundles)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = m.validateRenderedCatalog(context.Background(), cfg)
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("expected error %q, got %v", tt.err, err)
+				}
+			} else if err != nil {
+				t.Fatal(err)
+			}
+			var warnings []string
+			for _, entry := range hook.AllEntries() {
+				if entry.Level == logrus.WarnLevel {
+					warnings = append(warnings, entry.Message)
+				}
+			}
+			for _, expected := range tt.warnings {
+				found := false
+				for _, warning := range warnings {
+					found = found || strings.Contains(warning, expected)
+				}
+				if !found {
+					t.Errorf("expected a warning %q, got %v", expected, warnings)
+				}
+			}
+		})
+	}
+}
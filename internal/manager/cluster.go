@@ -0,0 +1,169 @@
+package manager
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/blang/semver/v4"
+	"github.com/operator-framework/rukpak/api/v1alpha1"
+	"github.com/perdasilva/olmcli/internal/resolution"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// ClusterConfig identifies the cluster targeted by cluster operations
+type ClusterConfig struct {
+	// Kubeconfig is the path to the kubeconfig file. When empty, the KUBECONFIG
+	// environment variable and ~/.kube/config are used
+	Kubeconfig string
+	// Context is the kubeconfig context to use. When empty, the current context is used
+	Context string
+	// RequestTimeout bounds each request to the cluster. When zero, DefaultClusterRequestTimeout is used
+	RequestTimeout time.Duration
+}
+
+// DefaultClusterRequestTimeout is how long a request to the cluster may take, so commands fail
+// instead of hanging on an unreachable api server
+const DefaultClusterRequestTimeout = 30 * time.Second
+
+func (c ClusterConfig) RESTConfig() (*rest.Config, error) {
+	restConfig, err := c.clientConfig().ClientConfig()
+	if err != nil {
+		return nil, err
+	}
+	restConfig.Timeout = c.RequestTimeout
+	if restConfig.Timeout == 0 {
+		restConfig.Timeout = DefaultClusterRequestTimeout
+	}
+	return restConfig, nil
+}
+
+// Describe returns the name of the kubeconfig context and the address of the targeted cluster
+func (c ClusterConfig) Describe() (string, string, error) {
+	clientConfig := c.clientConfig()
+	rawConfig, err := clientConfig.RawConfig()
+	if err != nil {
+		return "", "", err
+	}
+	contextName := rawConfig.CurrentContext
+	if c.Context != "" {
+		contextName = c.Context
+	}
+	restConfig, err := clientConfig.ClientConfig()
+	if err != nil {
+		return contextName, "", err
+	}
+	return contextName, restConfig.Host, nil
+}
+
+func (c ClusterConfig) clientConfig() clientcmd.ClientConfig {
+	loadingRules := clientcmd.NewDefaultClientConfigLoadingRules()
+	if c.Kubeconfig != "" {
+		// the value may come from the KUBECONFIG environment variable, which can hold a list of paths
+		if strings.ContainsRune(c.Kubeconfig, filepath.ListSeparator) {
+			loadingRules.Precedence = filepath.SplitList(c.Kubeconfig)
+		} else {
+			loadingRules.ExplicitPath = c.Kubeconfig
+		}
+	}
+	overrides := &clientcmd.ConfigOverrides{
+		CurrentContext: c.Context,
+	}
+	return clientcmd.NewNonInteractiveDeferredLoadingClientConfig(loadingRules, overrides)
+}
+
+var clusterVersionResource = schema.GroupVersionResource{Group: "config.openshift.io", Version: "v1", Resource: "clusterversions"}
+
+// PlatformVersion detects the Kubernetes version of the cluster through the discovery api and,
+// on OpenShift clusters, the OpenShift version from the cluster's ClusterVersion
+func (c ClusterConfig) PlatformVersion(ctx context.Context) (*resolution.PlatformVersion, error) {
+	restConfig, err := c.RESTConfig()
+	if err != nil {
+		return nil, err
+	}
+	discoveryClient, err := discovery.NewDiscoveryClientForConfig(restConfig)
+	if err != nil {
+		return nil, err
+	}
+	serverVersion, err := discoveryClient.ServerVersion()
+	if err != nil {
+		return nil, fmt.Errorf("error detecting the cluster version: %w", err)
+	}
+	kubeVersion, err := semver.ParseTolerant(serverVersion.GitVersion)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing the cluster version %q: %w", serverVersion.GitVersion, err)
+	}
+	platform := &resolution.PlatformVersion{KubeVersion: &kubeVersion}
+
+	if _, err := discoveryClient.ServerResourcesForGroupVersion(clusterVersionResource.GroupVersion().String()); err != nil {
+		if apierrors.IsNotFound(err) {
+			return platform, nil
+		}
+		return nil, fmt.Errorf("error detecting openshift: %w", err)
+	}
+	dynamicClient, err := dynamic.NewForConfig(restConfig)
+	if err != nil {
+		return nil, err
+	}
+	clusterVersion, err := dynamicClient.Resource(clusterVersionResource).Get(ctx, "version", metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("error detecting the openshift version: %w", err)
+	}
+	desiredVersion, _, err := unstructured.NestedString(clusterVersion.Object, "status", "desired", "version")
+	if err != nil || desiredVersion == "" {
+		return nil, fmt.Errorf("error detecting the openshift version: clusterversion has no desired version")
+	}
+	openShiftVersion, err := semver.ParseTolerant(desiredVersion)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing the openshift version %q: %w", desiredVersion, err)
+	}
+	platform.OpenShiftVersion = &openShiftVersion
+	return platform, nil
+}
+
+// lazyClient only connects to the cluster the first time the client is requested
+// so commands that don't touch the cluster don't require a kubeconfig
+type lazyClient struct {
+	clusterConfig ClusterConfig
+	once          sync.Once
+	client        client.Client
+	err           error
+}
+
+func newLazyClient(clusterConfig ClusterConfig) *lazyClient {
+	return &lazyClient{
+		clusterConfig: clusterConfig,
+	}
+}
+
+func (l *lazyClient) Get() (client.Client, error) {
+	l.once.Do(func() {
+		restConfig, err := l.clusterConfig.RESTConfig()
+		if err != nil {
+			l.err = err
+			return
+		}
+		c, err := client.New(restConfig, client.Options{})
+		if err != nil {
+			l.err = err
+			return
+		}
+		if err := v1alpha1.AddToScheme(c.Scheme()); err != nil {
+			l.err = err
+			return
+		}
+		l.client = c
+	})
+	return l.client, l.err
+}
@@ -0,0 +1,113 @@
+package manager
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/operator-framework/operator-registry/pkg/api"
+	"github.com/operator-framework/rukpak/api/v1alpha1"
+	"github.com/perdasilva/olmcli/internal/resolution"
+	"github.com/perdasilva/olmcli/internal/store"
+	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+const fixtureRepository = "catalog"
+
+func testLogger() *logrus.Logger {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	return logger
+}
+
+// fixtureBundle is a bundle of the fixture repository, replacing the given csv if set
+func fixtureBundle(packageName string, channel string, version string, replaces string) store.CachedBundle {
+	bundle := &api.Bundle{
+		CsvName:     fmt.Sprintf("%s.v%s", packageName, version),
+		PackageName: packageName,
+		ChannelName: channel,
+		Version:     version,
+		BundlePath:  fmt.Sprintf("quay.io/fixture/%s-bundle:v%s", packageName, version),
+		Replaces:    replaces,
+	}
+	return store.CachedBundle{
+		BundleID:   store.GetBundleKey(fixtureRepository, bundle),
+		Repository: fixtureRepository,
+		Bundle:     bundle,
+	}
+}
+
+func fixtureInstallable(packageName string, channel string, version string) resolution.Installable {
+	return resolution.Installable{CachedBundle: fixtureBundle(packageName, channel, version, "")}
+}
+
+// newTestInstaller returns a package installer with the given configured templates that never resolves
+func newTestInstaller(t *testing.T, template BundleDeploymentTemplate, repositoryTemplates map[string]BundleDeploymentTemplate) *PackageInstaller {
+	t.Helper()
+	installer, err := NewPackageInstaller(nil, ClusterConfig{}, template, repositoryTemplates, testLogger())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return installer
+}
+
+// installedBundleDeployment returns the bundle deployment installing the bundle with the default template
+func installedBundleDeployment(t *testing.T, packageName string, channel string, version string) *v1alpha1.BundleDeployment {
+	t.Helper()
+	installable := fixtureInstallable(packageName, channel, version)
+	return newTestInstaller(t, BundleDeploymentTemplate{}, nil).bundleDeploymentFromInstallable(&installable, newInstallConfig())
+}
+
+// withFakeCluster connects the installer to a fake cluster holding the objects
+func withFakeCluster(t *testing.T, installer *PackageInstaller, objects ...client.Object) client.Client {
+	t.Helper()
+	scheme := runtime.NewScheme()
+	if err := v1alpha1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	fakeClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(objects...).Build()
+	installer.client.once.Do(func() {})
+	installer.client.client = fakeClient
+	return fakeClient
+}
+
+// newTestManager returns a manager whose package database holds the bundles in the fixture repository
+func newTestManager(t *testing.T, bundles ...store.CachedBundle) *containerBasedManager {
+	t.Helper()
+	packageDatabase, err := store.OpenPackageDatabase("memory", "", testLogger())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		packageDatabase.Close()
+	})
+
+	snapshot := &store.RepositorySnapshot{
+		Repository: store.CachedRepository{RepositoryName: fixtureRepository, RepositorySource: "quay.io/fixture/catalog:latest"},
+		Bundles:    bundles,
+	}
+	packages := map[string]struct{}{}
+	for _, bundle := range bundles {
+		if _, ok := packages[bundle.PackageName]; ok {
+			continue
+		}
+		packages[bundle.PackageName] = struct{}{}
+		snapshot.Packages = append(snapshot.Packages, store.CachedPackage{
+			PackageID:  store.GetPackageKey(fixtureRepository, bundle.PackageName),
+			Repository: fixtureRepository,
+			Package:    &api.Package{Name: bundle.PackageName},
+		})
+	}
+	if err := packageDatabase.ImportRepository(context.Background(), snapshot); err != nil {
+		t.Fatal(err)
+	}
+	return &containerBasedManager{
+		PackageDatabase: packageDatabase,
+		logger:          testLogger(),
+		installer:       newTestInstaller(t, BundleDeploymentTemplate{}, nil),
+	}
+}
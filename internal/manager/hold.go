@@ -0,0 +1,134 @@
+package manager
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/operator-framework/rukpak/api/v1alpha1"
+	"github.com/perdasilva/olmcli/internal/resolution"
+)
+
+// holdAnnotation holds the package of a bundle deployment within the semver range it is set to
+const holdAnnotation = "annotations.olm.io/hold"
+
+// Holds returns the holds set on the installed bundle deployments. Invalid holds are skipped
+func (p *PackageInstaller) Holds(ctx context.Context) ([]resolution.Hold, error) {
+	installed, err := p.ListInstalled(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var holds []resolution.Hold
+	for index := range installed {
+		hold, err := bundleDeploymentHold(&installed[index])
+		if err != nil {
+			p.logger.Warnf("Skipping hold of %s: %s", installed[index].GetName(), err)
+			continue
+		}
+		if hold != nil {
+			holds = append(holds, *hold)
+		}
+	}
+	return holds, nil
+}
+
+// verifyHolds fails if any of the installables moves a held package outside its hold. On dry runs,
+// holds that can't be read are skipped with a warning
+func (p *PackageInstaller) verifyHolds(ctx context.Context, installables []resolution.Installable, dryRun bool) error {
+	holds, err := p.Holds(ctx)
+	if err != nil && dryRun {
+		p.logger.Warnf("Not verifying package holds, they can't be read: %v", err)
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("error reading package holds: %w", err)
+	}
+	heldExclusion := resolution.HoldExclusion(holds)
+	var held []string
+	for index := range installables {
+		if reason, ok := heldExclusion(&installables[index].CachedBundle); ok {
+			held = append(held, fmt.Sprintf("%s: %s", installables[index].BundleID, reason))
+		}
+	}
+	if len(held) > 0 {
+		return fmt.Errorf("bundles outside the holds of their packages, unhold the packages to install them: %s", strings.Join(held, ", "))
+	}
+	return nil
+}
+
+// setHold sets the hold annotation of the bundle deployment, or removes it if versionRange is empty
+func (p *PackageInstaller) setHold(ctx context.Context, bundleDeployment *v1alpha1.BundleDeployment, versionRange string) error {
+	c, err := p.client.Get()
+	if err != nil {
+		return err
+	}
+	annotations := bundleDeployment.GetAnnotations()
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	if versionRange == "" {
+		delete(annotations, holdAnnotation)
+	} else {
+		annotations[holdAnnotation] = versionRange
+	}
+	bundleDeployment.SetAnnotations(annotations)
+	return c.Update(ctx, bundleDeployment)
+}
+
+// bundleDeploymentHold returns the hold set on the bundle deployment, or nil if it isn't held
+func bundleDeploymentHold(bundleDeployment *v1alpha1.BundleDeployment) (*resolution.Hold, error) {
+	versionRange, ok := bundleDeployment.GetAnnotations()[holdAnnotation]
+	if !ok {
+		return nil, nil
+	}
+	return resolution.NewHold(bundleDeployment.GetName(), versionRange)
+}
+
+// Hold keeps the installed package within the version range, or at its installed version if versionRange is
+// empty, when packages are resolved, updated or applied
+func (m *containerBasedManager) Hold(ctx context.Context, packageName string, versionRange string) (*resolution.Hold, error) {
+	bundleDeployment, err := m.installedBundleDeployment(ctx, packageName)
+	if err != nil {
+		return nil, err
+	}
+	if versionRange == "" {
+		versionRange = bundleDeployment.GetAnnotations()[versionAnnotation]
+	}
+	hold, err := resolution.NewHold(packageName, versionRange)
+	if err != nil {
+		return nil, err
+	}
+	if installedVersion := bundleDeployment.GetAnnotations()[versionAnnotation]; !hold.Allows(installedVersion) {
+		m.logger.Warnf("installed version %s of %s is outside the hold's range %s: the next update or apply will move it into the range", installedVersion, packageName, versionRange)
+	}
+	if err := m.installer.setHold(ctx, bundleDeployment, versionRange); err != nil {
+		return nil, err
+	}
+	return hold, nil
+}
+
+// Unhold releases the hold of the installed package
+func (m *containerBasedManager) Unhold(ctx context.Context, packageName string) error {
+	bundleDeployment, err := m.installedBundleDeployment(ctx, packageName)
+	if err != nil {
+		return err
+	}
+	if _, ok := bundleDeployment.GetAnnotations()[holdAnnotation]; !ok {
+		return fmt.Errorf("package %s is not held", packageName)
+	}
+	return m.installer.setHold(ctx, bundleDeployment, "")
+}
+
+// installedBundleDeployment returns the bundle deployment of the installed package
+func (m *containerBasedManager) installedBundleDeployment(ctx context.Context, packageName string) (*v1alpha1.BundleDeployment, error) {
+	installed, err := m.installer.ListInstalled(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for index := range installed {
+		if installed[index].GetName() == packageName {
+			return &installed[index], nil
+		}
+	}
+	return nil, fmt.Errorf("package %s is not installed", packageName)
+}
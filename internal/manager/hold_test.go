@@ -0,0 +1,102 @@
+package manager
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/operator-framework/rukpak/api/v1alpha1"
+	"github.com/perdasilva/olmcli/internal/resolution"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func TestSetHold(t *testing.T) {
+	for _, tt := range []struct {
+		name         string
+		annotations  map[string]string
+		versionRange string
+		expected     map[string]string
+	}{
+		{
+			name:         "without annotations",
+			versionRange: "<2.0.0",
+			expected:     map[string]string{holdAnnotation: "<2.0.0"},
+		},
+		{
+			name:         "hold",
+			annotations:  map[string]string{versionAnnotation: "1.0.0"},
+			versionRange: "<2.0.0",
+			expected:     map[string]string{versionAnnotation: "1.0.0", holdAnnotation: "<2.0.0"},
+		},
+		{
+			name:        "unhold",
+			annotations: map[string]string{versionAnnotation: "1.0.0", holdAnnotation: "<2.0.0"},
+			expected:    map[string]string{versionAnnotation: "1.0.0"},
+		},
+		{
+			name: "unhold without annotations",
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			bundleDeployment := &v1alpha1.BundleDeployment{}
+			bundleDeployment.SetName("etcd")
+			bundleDeployment.SetAnnotations(tt.annotations)
+			installer := newTestInstaller(t, BundleDeploymentTemplate{}, nil)
+			fakeClient := withFakeCluster(t, installer, bundleDeployment.DeepCopy())
+
+			current := &v1alpha1.BundleDeployment{}
+			if err := fakeClient.Get(context.Background(), client.ObjectKey{Name: "etcd"}, current); err != nil {
+				t.Fatal(err)
+			}
+			if err := installer.setHold(context.Background(), current, tt.versionRange); err != nil {
+				t.Fatal(err)
+			}
+			updated := &v1alpha1.BundleDeployment{}
+			if err := fakeClient.Get(context.Background(), client.ObjectKey{Name: "etcd"}, updated); err != nil {
+				t.Fatal(err)
+			}
+			annotations := updated.GetAnnotations()
+			if len(annotations) != len(tt.expected) {
+				t.Fatalf("expected annotations %v, got %v", tt.expected, annotations)
+			}
+			for key, value := range tt.expected {
+				if annotations[key] != value {
+					t.Errorf("expected annotations %v, got %v", tt.expected, annotations)
+				}
+			}
+		})
+	}
+}
+
+func TestVerifyHolds(t *testing.T) {
+	held := installedBundleDeployment(t, "etcd", "stable", "1.0.0")
+	held.Annotations[holdAnnotation] = "<2.0.0"
+
+	for _, tt := range []struct {
+		name        string
+		version     string
+		unreachable bool
+		dryRun      bool
+		fails       bool
+	}{
+		{name: "within the hold", version: "1.1.0"},
+		{name: "outside the hold", version: "2.0.0", fails: true},
+		{name: "outside the hold on a dry run", version: "2.0.0", dryRun: true, fails: true},
+		{name: "holds can't be read", version: "2.0.0", unreachable: true, fails: true},
+		{name: "holds can't be read on a dry run", version: "2.0.0", unreachable: true, dryRun: true},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			installer := newTestInstaller(t, BundleDeploymentTemplate{}, nil)
+			if tt.unreachable {
+				installer.client.once.Do(func() {})
+				installer.client.err = errors.New("cluster unreachable")
+			} else {
+				withFakeCluster(t, installer, held.DeepCopy())
+			}
+			installables := []resolution.Installable{fixtureInstallable("etcd", "stable", tt.version)}
+			if err := installer.verifyHolds(context.Background(), installables, tt.dryRun); (err != nil) != tt.fails {
+				t.Errorf("expected failure to be %t, got %v", tt.fails, err)
+			}
+		})
+	}
+}
@@ -12,69 +12,195 @@ import (
 	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"sigs.k8s.io/controller-runtime/pkg/client"
-	"sigs.k8s.io/controller-runtime/pkg/client/config"
 )
 
-type PackageInstaller struct {
-	client   client.Client
-	logger   *logrus.Logger
-	resolver *resolution.OLMSolver
+const (
+	repositoryAnnotation = "annotations.olm.io/repository"
+	versionAnnotation    = "annotations.olm.io/version"
+	channelAnnotation    = "annotations.olm.io/channel"
+)
+
+// DryRunMode controls whether installation changes are persisted to the cluster
+type DryRunMode string
+
+const (
+	// DryRunNone applies changes to the cluster
+	DryRunNone DryRunMode = "none"
+	// DryRunClient only renders the objects that would be applied
+	DryRunClient DryRunMode = "client"
+	// DryRunServer submits the objects to the cluster without persisting them
+	DryRunServer DryRunMode = "server"
+)
+
+func ParseDryRunMode(mode string) (DryRunMode, error) {
+	switch DryRunMode(mode) {
+	case DryRunNone, DryRunClient, DryRunServer:
+		return DryRunMode(mode), nil
+	case "":
+		return DryRunNone, nil
+	}
+	return "", fmt.Errorf("invalid dry-run mode %q: must be one of %s, %s, or %s", mode, DryRunNone, DryRunClient, DryRunServer)
 }
 
-func NewPackageInstaller(resolver *resolution.OLMSolver, logger *logrus.Logger) (*PackageInstaller, error) {
-	c, err := client.New(config.GetConfigOrDie(), client.Options{})
-	if err != nil {
-		return nil, err
+type installConfig struct {
+	dryRun            DryRunMode
+	prune             bool
+	allowLockMismatch bool
+	templateDefaults  BundleDeploymentTemplate
+	templateOverrides BundleDeploymentTemplate
+	packageTemplates  map[string]BundleDeploymentTemplate
+}
+
+func newInstallConfig(options ...InstallOption) *installConfig {
+	config := &installConfig{
+		dryRun: DryRunNone,
 	}
-	if err := v1alpha1.AddToScheme(c.Scheme()); err != nil {
-		return nil, err
+	for _, opt := range options {
+		opt(config)
+	}
+	return config
+}
+
+type InstallOption func(config *installConfig)
+
+func WithDryRun(mode DryRunMode) InstallOption {
+	return func(config *installConfig) {
+		config.dryRun = mode
+	}
+}
+
+// WithBundleDeploymentOverrides overrides the configured bundle deployment template for this installation
+func WithBundleDeploymentOverrides(overrides BundleDeploymentTemplate) InstallOption {
+	return func(config *installConfig) {
+		config.templateOverrides = overrides
+	}
+}
+
+// WithBundleDeploymentDefaults sets bundle deployment template values for this installation that
+// take precedence over the configured templates but not over the package or install overrides
+func WithBundleDeploymentDefaults(defaults BundleDeploymentTemplate) InstallOption {
+	return func(config *installConfig) {
+		config.templateDefaults = defaults
+	}
+}
+
+// WithPackageBundleDeploymentOverrides overrides the bundle deployment template of a single package
+func WithPackageBundleDeploymentOverrides(packageName string, overrides BundleDeploymentTemplate) InstallOption {
+	return func(config *installConfig) {
+		if config.packageTemplates == nil {
+			config.packageTemplates = map[string]BundleDeploymentTemplate{}
+		}
+		config.packageTemplates[packageName] = overrides
+	}
+}
+
+// WithPrune removes installed packages that are not part of the applied package set
+func WithPrune() InstallOption {
+	return func(config *installConfig) {
+		config.prune = true
 	}
+}
+
+// WithAllowLockMismatch installs locked bundles even if their repository or image digest changed since they were locked
+func WithAllowLockMismatch() InstallOption {
+	return func(config *installConfig) {
+		config.allowLockMismatch = true
+	}
+}
+
+type PackageInstaller struct {
+	client              *lazyClient
+	logger              *logrus.Logger
+	resolver            *resolution.OLMSolver
+	template            BundleDeploymentTemplate
+	repositoryTemplates map[string]BundleDeploymentTemplate
+}
+
+func NewPackageInstaller(resolver *resolution.OLMSolver, clusterConfig ClusterConfig, template BundleDeploymentTemplate, repositoryTemplates map[string]BundleDeploymentTemplate, logger *logrus.Logger) (*PackageInstaller, error) {
 	return &PackageInstaller{
-		client:   c,
-		resolver: resolver,
-		logger:   logger,
+		client:              newLazyClient(clusterConfig),
+		resolver:            resolver,
+		logger:              logger,
+		template:            DefaultBundleDeploymentTemplate().Merge(template),
+		repositoryTemplates: repositoryTemplates,
 	}, nil
 }
 
-func (p *PackageInstaller) Install(ctx context.Context, requiredPackages ...*resolution.RequiredPackage) error {
+func (p *PackageInstaller) Install(ctx context.Context, requiredPackages []*resolution.RequiredPackage, options ...InstallOption) ([]v1alpha1.BundleDeployment, error) {
+	config := newInstallConfig(options...)
+
 	installables, err := p.Resolve(ctx, requiredPackages...)
 	if err != nil {
-		return err
+		return nil, err
 	}
+	return p.installResolved(ctx, installables, config)
+}
+
+// InstallResolved installs the given installables without running resolution
+func (p *PackageInstaller) InstallResolved(ctx context.Context, installables []resolution.Installable, options ...InstallOption) ([]v1alpha1.BundleDeployment, error) {
+	return p.installResolved(ctx, installables, newInstallConfig(options...))
+}
+
+func (p *PackageInstaller) installResolved(ctx context.Context, installables []resolution.Installable, config *installConfig) ([]v1alpha1.BundleDeployment, error) {
+	bundleDeployments := make([]v1alpha1.BundleDeployment, 0, len(installables))
 	for _, installable := range installables {
-		if err := p.install(ctx, &installable); err != nil {
-			return err
+		bundleDeployment := p.bundleDeploymentFromInstallable(&installable, config)
+		switch config.dryRun {
+		case DryRunClient:
+			// nothing to do, only render the objects
+		case DryRunServer:
+			p.logger.Debugf("Submitting %s with server-side dry run", installable.BundleID)
+			c, err := p.client.Get()
+			if err != nil {
+				return nil, err
+			}
+			if err := c.Create(ctx, bundleDeployment, client.DryRunAll); err != nil {
+				return nil, err
+			}
+		default:
+			if err := p.install(ctx, &installable, bundleDeployment); err != nil {
+				return nil, err
+			}
 		}
+		bundleDeployments = append(bundleDeployments, *bundleDeployment)
 	}
-	return nil
+	return bundleDeployments, nil
 }
 
 func (p *PackageInstaller) Resolve(ctx context.Context, requiredPackages ...*resolution.RequiredPackage) ([]resolution.Installable, error) {
+	installables, _, err := p.ResolveWithExclusions(ctx, requiredPackages...)
+	return installables, err
+}
+
+// ResolveWithExclusions resolves like Resolve, and also returns the candidate bundles excluded from resolution
+func (p *PackageInstaller) ResolveWithExclusions(ctx context.Context, requiredPackages ...*resolution.RequiredPackage) ([]resolution.Installable, []resolution.ExcludedBundle, error) {
 	p.logger.Debugf("resolving dependencies")
 	start := time.Now()
-	installables, err := p.resolver.Solve(ctx, requiredPackages...)
+	installables, excluded, err := p.resolver.SolveWithExclusions(ctx, requiredPackages...)
 	if err != nil {
-		p.logger.Fatal(err)
-		return nil, err
+		return nil, excluded, err
 	}
 	elapsed := time.Since(start)
 	p.logger.Debugf("took %s", elapsed)
-	return installables, nil
+	return installables, excluded, nil
 }
 
-func (p *PackageInstaller) install(ctx context.Context, installable *resolution.Installable) error {
+func (p *PackageInstaller) install(ctx context.Context, installable *resolution.Installable, bundleDeployment *v1alpha1.BundleDeployment) error {
 	p.logger.Printf("Installing %s", installable.BundleID)
-	bundleDeployment := p.bundleDeploymentFromInstallable(installable)
-	if err := p.client.Create(ctx, bundleDeployment); err != nil {
+	c, err := p.client.Get()
+	if err != nil {
+		return err
+	}
+	if err := c.Create(ctx, bundleDeployment); err != nil {
 		return err
 	}
-	return p.watchInstallation(ctx, client.ObjectKeyFromObject(bundleDeployment))
+	return p.watchInstallation(ctx, c, client.ObjectKeyFromObject(bundleDeployment))
 }
 
-func (p *PackageInstaller) watchInstallation(ctx context.Context, bundleDeploymentKey client.ObjectKey) error {
+func (p *PackageInstaller) watchInstallation(ctx context.Context, c client.Client, bundleDeploymentKey client.ObjectKey) error {
 	return retry.Do(func() error {
 		bundleDeployment := &v1alpha1.BundleDeployment{}
-		if err := p.client.Get(ctx, bundleDeploymentKey, bundleDeployment); err != nil {
+		if err := c.Get(ctx, bundleDeploymentKey, bundleDeployment); err != nil {
 			return err
 		}
 		p.logger.Printf("Deployment status conditions:")
@@ -88,26 +214,48 @@ func (p *PackageInstaller) watchInstallation(ctx context.Context, bundleDeployme
 	}, retry.Context(ctx), retry.Attempts(10), retry.Delay(10*time.Second))
 }
 
-func (p *PackageInstaller) bundleDeploymentFromInstallable(installable *resolution.Installable) *v1alpha1.BundleDeployment {
+// templateFor computes the bundle deployment template for the installable. Per-repository settings
+// take precedence over the global settings, followed by the install defaults, the per-package settings,
+// and finally the install overrides
+func (p *PackageInstaller) templateFor(installable *resolution.Installable, config *installConfig) BundleDeploymentTemplate {
+	template := p.template
+	if repositoryTemplate, ok := p.repositoryTemplates[installable.Repository]; ok {
+		template = template.Merge(repositoryTemplate)
+	}
+	template = template.Merge(config.templateDefaults)
+	if packageTemplate, ok := config.packageTemplates[installable.PackageName]; ok {
+		template = template.Merge(packageTemplate)
+	}
+	return template.Merge(config.templateOverrides)
+}
+
+func (p *PackageInstaller) bundleDeploymentFromInstallable(installable *resolution.Installable, config *installConfig) *v1alpha1.BundleDeployment {
+	template := p.templateFor(installable, config)
+	annotations := mergeMaps(template.Annotations, map[string]string{
+		repositoryAnnotation: installable.Repository,
+		versionAnnotation:    installable.Version,
+		channelAnnotation:    installable.ChannelName,
+	})
 	return &v1alpha1.BundleDeployment{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: v1alpha1.GroupVersion.String(),
+			Kind:       v1alpha1.BundleDeploymentKind,
+		},
 		ObjectMeta: metav1.ObjectMeta{
-			Name: installable.PackageName,
-			Annotations: map[string]string{
-				"annotations.olm.io/repository": installable.Repository,
-				"annotations.olm.io/version":    installable.Version,
-				"annotations.olm.io/channel":    installable.ChannelName,
-			},
+			Name:        installable.PackageName,
+			Labels:      template.Labels,
+			Annotations: annotations,
 		},
 		Spec: v1alpha1.BundleDeploymentSpec{
-			ProvisionerClassName: "core-rukpak-io-plain",
+			ProvisionerClassName: template.ProvisionerClassName,
 			Template: &v1alpha1.BundleTemplate{
 				Spec: v1alpha1.BundleSpec{
-					ProvisionerClassName: "core-rukpak-io-registry",
+					ProvisionerClassName: template.BundleProvisionerClassName,
 					Source: v1alpha1.BundleSource{
 						Type: v1alpha1.SourceTypeImage,
 						Image: &v1alpha1.ImageSource{
 							Ref:                 installable.GetBundlePath(),
-							ImagePullSecretName: "regcred",
+							ImagePullSecretName: template.pullSecretName(),
 						},
 					},
 				},
@@ -0,0 +1,219 @@
+package manager
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/perdasilva/olmcli/internal/image"
+	"github.com/perdasilva/olmcli/internal/resolution"
+	"github.com/perdasilva/olmcli/internal/store"
+	"github.com/sirupsen/logrus"
+	"sigs.k8s.io/yaml"
+)
+
+// LockFile records the exact outcome of a resolution so it can be installed again
+// without re-running the solver
+type LockFile struct {
+	Repositories []LockedRepository `json:"repositories"`
+	// Bundles are listed in installation order
+	Bundles []LockedBundle `json:"bundles"`
+}
+
+type LockedRepository struct {
+	Name   string `json:"name"`
+	Source string `json:"source"`
+	Digest string `json:"digest,omitempty"`
+}
+
+type LockedBundle struct {
+	ID                string   `json:"id"`
+	Package           string   `json:"package"`
+	Channel           string   `json:"channel"`
+	Version           string   `json:"version"`
+	Repository        string   `json:"repository"`
+	BundleImage       string   `json:"bundleImage"`
+	BundleImageDigest string   `json:"bundleImageDigest,omitempty"`
+	Dependencies      []string `json:"dependencies,omitempty"`
+}
+
+// NewLockFile creates a lock file for the resolved installables. Only the repositories
+// the installables come from are recorded. Bundle images referenced by tag are locked to
+// the digest the tag currently points to
+func NewLockFile(ctx context.Context, installables []resolution.Installable, repositories []store.CachedRepository, resolveDigest image.DigestResolver) (*LockFile, error) {
+	lockFile := &LockFile{}
+	usedRepositories := map[string]struct{}{}
+	for _, installable := range installables {
+		usedRepositories[installable.Repository] = struct{}{}
+		var dependencies []string
+		for dependencyID := range installable.Dependencies {
+			dependencies = append(dependencies, dependencyID)
+		}
+		sort.Strings(dependencies)
+		digest, err := resolveDigest(ctx, installable.GetBundlePath())
+		if err != nil {
+			return nil, fmt.Errorf("error locking bundle %s: %w", installable.BundleID, err)
+		}
+		lockFile.Bundles = append(lockFile.Bundles, LockedBundle{
+			ID:                installable.BundleID,
+			Package:           installable.PackageName,
+			Channel:           installable.ChannelName,
+			Version:           installable.Version,
+			Repository:        installable.Repository,
+			BundleImage:       installable.GetBundlePath(),
+			BundleImageDigest: digest,
+			Dependencies:      dependencies,
+		})
+	}
+	for _, repository := range repositories {
+		if _, ok := usedRepositories[repository.RepositoryName]; ok {
+			lockFile.Repositories = append(lockFile.Repositories, LockedRepository{
+				Name:   repository.RepositoryName,
+				Source: repository.RepositorySource,
+				Digest: repository.RepositoryDigest,
+			})
+		}
+	}
+	sort.Slice(lockFile.Repositories, func(i, j int) bool {
+		return lockFile.Repositories[i].Name < lockFile.Repositories[j].Name
+	})
+	return lockFile, nil
+}
+
+// LoadLockFile reads a lock file
+func LoadLockFile(path string) (*LockFile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	lockFile := &LockFile{}
+	if err := yaml.UnmarshalStrict(data, lockFile); err != nil {
+		return nil, fmt.Errorf("error parsing lock file %s: %w", path, err)
+	}
+	return lockFile, nil
+}
+
+// Write writes the lock file to path
+func (l *LockFile) Write(path string) error {
+	data, err := yaml.Marshal(l)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+// verifyRepositories fails if a locked repository isn't cached, or if its content changed since it
+// was locked, unless allowMismatch is set
+func (l *LockFile) verifyRepositories(repositories []store.CachedRepository, allowMismatch bool, logger *logrus.Logger) error {
+	cachedRepositories := map[string]store.CachedRepository{}
+	for _, repository := range repositories {
+		cachedRepositories[repository.RepositoryName] = repository
+	}
+	for _, lockedRepository := range l.Repositories {
+		cachedRepository, ok := cachedRepositories[lockedRepository.Name]
+		if !ok {
+			return fmt.Errorf("locked repository %s not found: add it with 'olm add repo %s'", lockedRepository.Name, lockedRepository.Source)
+		}
+		if lockedRepository.Digest == "" || cachedRepository.RepositoryDigest == "" || lockedRepository.Digest == cachedRepository.RepositoryDigest {
+			continue
+		}
+		err := fmt.Errorf("repository %s has changed since it was locked (locked digest %s, cached digest %s)", lockedRepository.Name, lockedRepository.Digest, cachedRepository.RepositoryDigest)
+		if !allowMismatch {
+			return fmt.Errorf("%w: install with --allow-lock-mismatch to install the locked bundles anyway", err)
+		}
+		logger.Warn(err)
+	}
+	return nil
+}
+
+// installables looks up the locked bundles in the package database and verifies they still match
+// what was locked. A bundle image whose digest changed since it was locked is only accepted if
+// allowMismatch is set
+func (l *LockFile) installables(ctx context.Context, packageDatabase store.PackageDatabase, resolveDigest image.DigestResolver, allowMismatch bool, logger *logrus.Logger) ([]resolution.Installable, error) {
+	lockedRepositories := map[string]LockedRepository{}
+	for _, repository := range l.Repositories {
+		lockedRepositories[repository.Name] = repository
+	}
+
+	bundles := map[string]store.CachedBundle{}
+	for _, lockedBundle := range l.Bundles {
+		bundle, err := packageDatabase.GetBundle(ctx, lockedBundle.ID)
+		if err != nil {
+			return nil, err
+		}
+		if bundle == nil {
+			return nil, fmt.Errorf("locked bundle %s not found: add repository %s", lockedBundle.ID, lockedRepositories[lockedBundle.Repository].Source)
+		}
+		if bundle.Version != lockedBundle.Version {
+			return nil, fmt.Errorf("locked bundle %s has version %s, but version %s was locked", lockedBundle.ID, bundle.Version, lockedBundle.Version)
+		}
+		if bundle.GetBundlePath() != lockedBundle.BundleImage {
+			return nil, fmt.Errorf("locked bundle %s has image %s, but image %s was locked", lockedBundle.ID, bundle.GetBundlePath(), lockedBundle.BundleImage)
+		}
+		if err := verifyBundleDigest(ctx, bundle, &lockedBundle, resolveDigest, allowMismatch, logger); err != nil {
+			return nil, err
+		}
+		bundles[lockedBundle.ID] = *bundle
+	}
+
+	installables := make([]resolution.Installable, 0, len(l.Bundles))
+	for _, lockedBundle := range l.Bundles {
+		dependencies := map[string]store.CachedBundle{}
+		for _, dependencyID := range lockedBundle.Dependencies {
+			dependency, ok := bundles[dependencyID]
+			if !ok {
+				return nil, fmt.Errorf("dependency %s of locked bundle %s is not locked", dependencyID, lockedBundle.ID)
+			}
+			dependencies[dependencyID] = dependency
+		}
+		installables = append(installables, resolution.Installable{
+			CachedBundle: bundles[lockedBundle.ID],
+			Dependencies: dependencies,
+		})
+	}
+	return installables, nil
+}
+
+// digestResolver looks up the locked digests of the locked bundle images, and the digests of other images
+// and of images locked without a digest with resolveDigest
+func (l *LockFile) digestResolver(resolveDigest image.DigestResolver) image.DigestResolver {
+	lockedDigests := map[string]string{}
+	for _, lockedBundle := range l.Bundles {
+		if lockedBundle.BundleImageDigest != "" {
+			lockedDigests[lockedBundle.BundleImage] = lockedBundle.BundleImageDigest
+		}
+	}
+	return func(ctx context.Context, imageRef string) (string, error) {
+		if digest, ok := lockedDigests[imageRef]; ok {
+			return digest, nil
+		}
+		return resolveDigest(ctx, imageRef)
+	}
+}
+
+// verifyBundleDigest fails if the image of the cached bundle doesn't have the locked digest anymore,
+// e.g. because its tag was moved, unless allowMismatch is set
+func verifyBundleDigest(ctx context.Context, bundle *store.CachedBundle, lockedBundle *LockedBundle, resolveDigest image.DigestResolver, allowMismatch bool, logger *logrus.Logger) error {
+	if lockedBundle.BundleImageDigest == "" {
+		logger.Warnf("locked bundle %s has no image digest, its image %s can't be verified", lockedBundle.ID, lockedBundle.BundleImage)
+		return nil
+	}
+	digest, err := resolveDigest(ctx, bundle.GetBundlePath())
+	if err != nil {
+		if !allowMismatch {
+			return fmt.Errorf("error verifying locked bundle %s: %w", lockedBundle.ID, err)
+		}
+		logger.Warnf("error verifying locked bundle %s: %s", lockedBundle.ID, err)
+		return nil
+	}
+	if digest == lockedBundle.BundleImageDigest {
+		return nil
+	}
+	err = fmt.Errorf("image %s of locked bundle %s has digest %s, but digest %s was locked", bundle.GetBundlePath(), lockedBundle.ID, digest, lockedBundle.BundleImageDigest)
+	if !allowMismatch {
+		return fmt.Errorf("%w: install with --allow-lock-mismatch to install it anyway", err)
+	}
+	logger.Warn(err)
+	return nil
+}
@@ -0,0 +1,73 @@
+package manager
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/perdasilva/olmcli/internal/image"
+	"github.com/perdasilva/olmcli/internal/resolution"
+)
+
+func TestLockedInstallablesDenyList(t *testing.T) {
+	const denied = "sha256:" + "0000000000000000000000000000000000000000000000000000000000000000"
+	const allowed = "sha256:" + "1111111111111111111111111111111111111111111111111111111111111111"
+	bundle := fixtureBundle("etcd", "stable", "1.0.0", "")
+	m := newTestManager(t, bundle)
+	m.denyList = []resolution.DenyRule{{Digest: denied, Reason: "broken release"}}
+
+	for _, tt := range []struct {
+		name string
+		// locked is the digest recorded in the lock file
+		locked string
+		// current is the digest the bundle image's tag points to now, if it can be looked up
+		current string
+		err     string
+	}{
+		{name: "locked digest denied after the tag moved", locked: denied, current: allowed, err: "denied by digest " + denied},
+		{name: "locked digest allowed after the tag moved", locked: allowed, current: denied},
+		{name: "locked digest denied offline", locked: denied, err: "denied by digest " + denied},
+		{name: "locked digest allowed offline", locked: allowed},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			var resolveDigest image.DigestResolver = func(_ context.Context, imageRef string) (string, error) {
+				if tt.current == "" {
+					return "", fmt.Errorf("no route to quay.io")
+				}
+				return tt.current, nil
+			}
+			var err error
+			if m.denyExclusion, err = resolution.DenyExclusion(m.denyList, resolveDigest, testLogger()); err != nil {
+				t.Fatal(err)
+			}
+			m.resolveDigest = resolveDigest
+			lockFile := &LockFile{
+				Repositories: []LockedRepository{{Name: fixtureRepository, Source: "quay.io/fixture/catalog:latest"}},
+				Bundles: []LockedBundle{{
+					ID:                bundle.BundleID,
+					Package:           bundle.PackageName,
+					Channel:           bundle.ChannelName,
+					Version:           bundle.Version,
+					Repository:        fixtureRepository,
+					BundleImage:       bundle.GetBundlePath(),
+					BundleImageDigest: tt.locked,
+				}},
+			}
+
+			installables, err := m.lockedInstallables(context.Background(), lockFile, true)
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("expected error %q, got %v", tt.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(installables) != 1 || installables[0].BundleID != bundle.BundleID {
+				t.Errorf("expected the locked bundle %s, got %v", bundle.BundleID, installables)
+			}
+		})
+	}
+}
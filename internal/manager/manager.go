@@ -2,8 +2,15 @@ package manager
 
 import (
 	"context"
-	"path"
+	"fmt"
+	"io"
+	"time"
 
+	"github.com/operator-framework/operator-registry/alpha/declcfg"
+	"github.com/operator-framework/rukpak/api/v1alpha1"
+	"github.com/perdasilva/olmcli/internal/catalog"
+	"github.com/perdasilva/olmcli/internal/image"
+	"github.com/perdasilva/olmcli/internal/mirror"
 	"github.com/perdasilva/olmcli/internal/repository"
 	"github.com/perdasilva/olmcli/internal/resolution"
 	"github.com/perdasilva/olmcli/internal/store"
@@ -16,14 +23,35 @@ type Manager interface {
 	ListRepositories(ctx context.Context) ([]store.CachedRepository, error)
 	ListGVKs(ctx context.Context) (map[string][]store.CachedBundle, error)
 	ListBundlesForGVK(ctx context.Context, group string, version string, kind string) ([]store.CachedBundle, error)
-	SearchBundles(ctx context.Context, searchTerm string) ([]store.CachedBundle, error)
-	SearchPackages(ctx context.Context, searchTerm string) ([]store.CachedPackage, error)
+	SearchBundles(ctx context.Context, searchTerm string, options ...store.SearchOption) ([]store.BundleSearchResult, error)
+	SearchPackages(ctx context.Context, searchTerm string, options ...store.SearchOption) ([]store.PackageSearchResult, error)
 	RemoveRepository(ctx context.Context, repoName string) error
 	ListBundles(ctx context.Context) ([]store.CachedBundle, error)
 	ListPackages(ctx context.Context) ([]store.CachedPackage, error)
-	Install(ctx context.Context, packageName string) error
+	Install(ctx context.Context, packageName string, options ...InstallOption) ([]v1alpha1.BundleDeployment, error)
+	InstallLocked(ctx context.Context, lockFile *LockFile, options ...InstallOption) ([]v1alpha1.BundleDeployment, error)
+	LockFile(ctx context.Context, installables []resolution.Installable) (*LockFile, error)
+	Apply(ctx context.Context, packageSet *PackageSet, options ...InstallOption) ([]Change, error)
+	Update(ctx context.Context, packageNames []string, channel string, options ...InstallOption) ([]Change, error)
+	Hold(ctx context.Context, packageName string, versionRange string) (*resolution.Hold, error)
+	Unhold(ctx context.Context, packageName string) error
 	Resolve(ctx context.Context, packageName string) ([]resolution.Installable, error)
+	ResolvePackages(ctx context.Context, requiredPackages ...*resolution.RequiredPackage) ([]resolution.Installable, error)
+	ResolveWithExclusions(ctx context.Context, packageName string) ([]resolution.Installable, []resolution.ExcludedBundle, error)
 	GetBundlesForPackage(ctx context.Context, packageName string, options ...store.PackageSearchOption) ([]store.CachedBundle, error)
+	SchemaVersion(ctx context.Context) (int, error)
+	Migrate(ctx context.Context) error
+	RebuildDatabase(ctx context.Context) error
+	ListOperations(ctx context.Context) ([]store.CachedOperation, error)
+	GetOperation(ctx context.Context, operationID string) (*store.CachedOperation, error)
+	ListWarnings(ctx context.Context, repositories ...string) ([]store.CachedWarning, error)
+	ListInstalledPackages(ctx context.Context) ([]InstalledPackage, error)
+	Catalog(ctx context.Context, nameOrPath string) (*catalog.Catalog, error)
+	ValidateCatalog(ctx context.Context, c *catalog.Catalog, standalone bool) ([]catalog.Problem, error)
+	RenderCatalog(ctx context.Context, channel string, packageNames ...string) (*declcfg.DeclarativeConfig, error)
+	MirrorPlan(ctx context.Context, registry string, packageNames ...string) (*mirror.Plan, error)
+	ExportRepositories(ctx context.Context, w io.Writer, repoNames ...string) error
+	ImportRepositories(ctx context.Context, r io.Reader) ([]store.CachedRepository, error)
 	Close() error
 }
 
@@ -34,37 +62,322 @@ type containerBasedManager struct {
 	logger     *logrus.Logger
 	configPath string
 	installer  *PackageInstaller
+	// offlineSolver resolves without contacting the cluster, for commands that target other clusters
+	offlineSolver *resolution.OLMSolver
+	// denyList holds the validated deny rules, denyExclusion is nil if no bundles are denied
+	denyList      []resolution.DenyRule
+	denyExclusion resolution.Exclusion
+	resolveDigest image.DigestResolver
+	// clusterResolution is set if installs are resolved for the targeted cluster, see WithClusterResolution
+	clusterResolution bool
+	// clusterDryRun is set if the command doesn't change the cluster, see WithClusterDryRun
+	clusterDryRun bool
 }
 
-func NewManager(configPath string, logger *logrus.Logger) (Manager, error) {
+type managerConfig struct {
+	databaseBackend     string
+	databaseOptions     []store.DatabaseOption
+	clusterConfig       ClusterConfig
+	template            BundleDeploymentTemplate
+	repositoryTemplates map[string]BundleDeploymentTemplate
+	platformVersion     *resolution.PlatformVersion
+	clusterResolution   bool
+	clusterDryRun       bool
+	denyList            []resolution.DenyRule
+	digestResolver      image.DigestResolver
+}
+
+type Option func(config *managerConfig)
+
+// WithoutDatabaseMigration opens the package database without upgrading its schema
+func WithoutDatabaseMigration() Option {
+	return func(config *managerConfig) {
+		config.databaseOptions = append(config.databaseOptions, store.WithoutMigration())
+	}
+}
+
+// WithDatabaseBackend sets the storage backend of the package database, see store.Backends
+func WithDatabaseBackend(backend string) Option {
+	return func(config *managerConfig) {
+		config.databaseBackend = backend
+	}
+}
+
+// WithReadOnlyDatabase opens the package database for reading only, so commands that don't
+// change the cache can run in parallel
+func WithReadOnlyDatabase() Option {
+	return func(config *managerConfig) {
+		config.databaseOptions = append(config.databaseOptions, store.WithReadOnly())
+	}
+}
+
+// WithDatabaseOpenTimeout sets how long to wait for other processes to release the package database
+func WithDatabaseOpenTimeout(timeout time.Duration) Option {
+	return func(config *managerConfig) {
+		config.databaseOptions = append(config.databaseOptions, store.WithOpenTimeout(timeout))
+	}
+}
+
+// WithKubeConfig sets the kubeconfig file used to connect to the cluster
+func WithKubeConfig(kubeconfig string) Option {
+	return func(config *managerConfig) {
+		config.clusterConfig.Kubeconfig = kubeconfig
+	}
+}
+
+// WithKubeContext sets the kubeconfig context used to connect to the cluster
+func WithKubeContext(kubeContext string) Option {
+	return func(config *managerConfig) {
+		config.clusterConfig.Context = kubeContext
+	}
+}
+
+// WithClusterRequestTimeout sets how long a request to the cluster may take
+func WithClusterRequestTimeout(timeout time.Duration) Option {
+	return func(config *managerConfig) {
+		config.clusterConfig.RequestTimeout = timeout
+	}
+}
+
+// WithClusterResolution resolves bundles for the targeted cluster: bundles that are not compatible
+// with the cluster's platform version are excluded and held packages are kept within their holds.
+// Without it, resolution doesn't contact the cluster
+func WithClusterResolution() Option {
+	return func(config *managerConfig) {
+		config.clusterResolution = true
+	}
+}
+
+// WithClusterDryRun tells the manager the command only previews its changes to the cluster. Package holds
+// that can't be read are then skipped with a warning instead of failing resolution
+func WithClusterDryRun() Option {
+	return func(config *managerConfig) {
+		config.clusterDryRun = true
+	}
+}
+
+// WithPlatformVersion resolves bundles for the given platform version instead of the version detected from the cluster
+func WithPlatformVersion(platformVersion resolution.PlatformVersion) Option {
+	return func(config *managerConfig) {
+		config.platformVersion = &platformVersion
+	}
+}
+
+// WithDenyList excludes the bundles matched by the rules from resolution
+func WithDenyList(rules []resolution.DenyRule) Option {
+	return func(config *managerConfig) {
+		config.denyList = append(config.denyList, rules...)
+	}
+}
+
+// WithDigestResolver sets how the digests of images referenced by tag are looked up, instead of
+// asking their registry with docker
+func WithDigestResolver(resolver image.DigestResolver) Option {
+	return func(config *managerConfig) {
+		config.digestResolver = resolver
+	}
+}
+
+// WithBundleDeploymentTemplate sets the global bundle deployment template
+func WithBundleDeploymentTemplate(template BundleDeploymentTemplate) Option {
+	return func(config *managerConfig) {
+		config.template = template
+	}
+}
+
+// WithRepositoryBundleDeploymentTemplate sets the bundle deployment template for bundles
+// installed from the given repository
+func WithRepositoryBundleDeploymentTemplate(repositoryName string, template BundleDeploymentTemplate) Option {
+	return func(config *managerConfig) {
+		if config.repositoryTemplates == nil {
+			config.repositoryTemplates = map[string]BundleDeploymentTemplate{}
+		}
+		config.repositoryTemplates[repositoryName] = template
+	}
+}
+
+func NewManager(configPath string, logger *logrus.Logger, options ...Option) (Manager, error) {
 	if logger == nil {
 		panic("no logger specified")
 	}
 
-	packageDatabase, err := store.NewPackageDatabase(path.Join(configPath, "olm.db"), logger)
+	config := &managerConfig{
+		digestResolver: image.ResolveDigest,
+	}
+	for _, opt := range options {
+		opt(config)
+	}
+
+	resolveDigest := image.CachingDigestResolver(config.digestResolver)
+	var denyExclusion resolution.Exclusion
+	if len(config.denyList) > 0 {
+		var err error
+		if denyExclusion, err = resolution.DenyExclusion(config.denyList, resolveDigest, logger); err != nil {
+			return nil, fmt.Errorf("invalid deny list: %w", err)
+		}
+	}
+
+	packageDatabase, err := store.OpenPackageDatabase(config.databaseBackend, configPath, logger, config.databaseOptions...)
 	if err != nil {
 		return nil, err
 	}
 
-	installer, err := NewPackageInstaller(resolution.NewOLMSolver(packageDatabase, logger), logger)
+	// offline resolution only reads the package database, so the platform version is only known if set explicitly
+	var offlineSolverOptions []resolution.SolverOption
+	if config.platformVersion != nil {
+		offlineSolverOptions = append(offlineSolverOptions, resolution.WithPlatformVersionSource(func(context.Context) (*resolution.PlatformVersion, error) {
+			return config.platformVersion, nil
+		}))
+	}
+	if denyExclusion != nil {
+		offlineSolverOptions = append(offlineSolverOptions, resolution.WithExclusions(denyExclusion))
+	}
+	offlineSolver := resolution.NewOLMSolver(packageDatabase, logger, offlineSolverOptions...)
+
+	// the holds are read from the installed bundle deployments with the installer's cluster client
+	var installer *PackageInstaller
+	holdSource := func(ctx context.Context) ([]resolution.Hold, error) {
+		holds, err := installer.Holds(ctx)
+		if err != nil && config.clusterDryRun {
+			logger.Warnf("Resolving without package holds, they can't be read: %v", err)
+			return nil, nil
+		}
+		return holds, err
+	}
+	solverOptions := append([]resolution.SolverOption(nil), offlineSolverOptions...)
+	if config.clusterResolution {
+		solverOptions = append(solverOptions, resolution.WithHoldSource(holdSource))
+		if config.platformVersion == nil {
+			solverOptions = append(solverOptions, resolution.WithPlatformVersionSource(config.clusterConfig.PlatformVersion))
+		}
+	}
+	solver := resolution.NewOLMSolver(packageDatabase, logger, solverOptions...)
+
+	installer, err = NewPackageInstaller(solver, config.clusterConfig, config.template, config.repositoryTemplates, logger)
 	if err != nil {
 		return nil, err
 	}
 
 	return &containerBasedManager{
-		PackageDatabase: packageDatabase,
-		configPath:      configPath,
-		logger:          logger,
-		installer:       installer,
+		PackageDatabase:   packageDatabase,
+		configPath:        configPath,
+		logger:            logger,
+		installer:         installer,
+		offlineSolver:     offlineSolver,
+		denyList:          config.denyList,
+		denyExclusion:     denyExclusion,
+		resolveDigest:     resolveDigest,
+		clusterResolution: config.clusterResolution,
+		clusterDryRun:     config.clusterDryRun,
 	}, nil
 }
 
-func (m *containerBasedManager) Install(ctx context.Context, packageName string) error {
+func (m *containerBasedManager) Install(ctx context.Context, packageName string, options ...InstallOption) ([]v1alpha1.BundleDeployment, error) {
 	packageRequired, err := resolution.NewRequiredPackage(packageName)
 	if err != nil {
-		return err
+		return nil, err
+	}
+
+	operation := store.NewOperation(store.OperationInstall)
+	operation.RequestedPackages = []string{packageRequired.String()}
+
+	installables, err := m.installer.Resolve(ctx, packageRequired)
+	var bundleDeployments []v1alpha1.BundleDeployment
+	if err == nil {
+		bundleDeployments, err = m.installer.InstallResolved(ctx, installables, options...)
 	}
-	return m.installer.Install(ctx, packageRequired)
+	m.recordOperation(ctx, operation, installPlan(installables), err, options...)
+	return bundleDeployments, err
+}
+
+// LockFile locks the resolved installables and the repositories they come from
+func (m *containerBasedManager) LockFile(ctx context.Context, installables []resolution.Installable) (*LockFile, error) {
+	repositories, err := m.ListRepositories(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return NewLockFile(ctx, installables, repositories, m.resolveDigest)
+}
+
+func (m *containerBasedManager) InstallLocked(ctx context.Context, lockFile *LockFile, options ...InstallOption) ([]v1alpha1.BundleDeployment, error) {
+	operation := store.NewOperation(store.OperationInstall)
+	for _, lockedBundle := range lockFile.Bundles {
+		operation.RequestedPackages = append(operation.RequestedPackages, fmt.Sprintf("%s (locked bundle %s)", lockedBundle.Package, lockedBundle.ID))
+	}
+
+	installables, err := m.lockedInstallables(ctx, lockFile, newInstallConfig(options...).allowLockMismatch)
+	if err == nil && m.clusterResolution {
+		// the locked bundles bypass resolution, which keeps held packages within their holds
+		err = m.installer.verifyHolds(ctx, installables, m.clusterDryRun)
+	}
+	var bundleDeployments []v1alpha1.BundleDeployment
+	if err == nil {
+		bundleDeployments, err = m.installer.InstallResolved(ctx, installables, options...)
+	}
+	m.recordOperation(ctx, operation, installPlan(installables), err, options...)
+	return bundleDeployments, err
+}
+
+// lockedInstallables verifies the locked repositories and bundles against the cache and the deny list and
+// returns the locked bundles
+func (m *containerBasedManager) lockedInstallables(ctx context.Context, lockFile *LockFile, allowMismatch bool) ([]resolution.Installable, error) {
+	repositories, err := m.ListRepositories(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := lockFile.verifyRepositories(repositories, allowMismatch, m.logger); err != nil {
+		return nil, err
+	}
+	installables, err := lockFile.installables(ctx, m.PackageDatabase, m.resolveDigest, allowMismatch, m.logger)
+	if err != nil || m.denyExclusion == nil {
+		return installables, err
+	}
+	// the locked bundles bypass resolution, which excludes the denied bundles. Their images are checked
+	// against digest rules by the locked digests, which were verified above
+	denyExclusion, err := resolution.DenyExclusion(m.denyList, lockFile.digestResolver(m.resolveDigest), m.logger)
+	if err != nil {
+		return nil, err
+	}
+	for index := range installables {
+		if reason, denied := denyExclusion(&installables[index].CachedBundle); denied {
+			return nil, fmt.Errorf("locked bundle %s is %s", installables[index].BundleID, reason)
+		}
+	}
+	return installables, nil
+}
+
+func (m *containerBasedManager) Apply(ctx context.Context, packageSet *PackageSet, options ...InstallOption) ([]Change, error) {
+	for _, repo := range packageSet.Repositories {
+		ok, err := m.HasRepository(ctx, store.GetRepositoryName(repo.Image))
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			m.logger.Printf("Adding repository %s", repo.Image)
+			if err := m.AddRepository(ctx, repo.Image); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	requiredPackages, err := packageSet.RequiredPackages()
+	if err != nil {
+		return nil, err
+	}
+
+	applyOptions := []InstallOption{WithBundleDeploymentDefaults(packageSet.BundleDeployment)}
+	for _, pkg := range packageSet.Packages {
+		applyOptions = append(applyOptions, WithPackageBundleDeploymentOverrides(pkg.Name, pkg.BundleDeployment))
+	}
+	applyOptions = append(applyOptions, options...)
+
+	operation := store.NewOperation(store.OperationApply)
+	for _, requiredPackage := range requiredPackages {
+		operation.RequestedPackages = append(operation.RequestedPackages, requiredPackage.String())
+	}
+	changes, err := m.installer.Apply(ctx, requiredPackages, applyOptions...)
+	m.recordOperation(ctx, operation, changePlan(changes), err, applyOptions...)
+	return changes, err
 }
 
 func (m *containerBasedManager) Resolve(ctx context.Context, packageName string) ([]resolution.Installable, error) {
@@ -75,12 +388,111 @@ func (m *containerBasedManager) Resolve(ctx context.Context, packageName string)
 	return m.installer.Resolve(ctx, packageRequired)
 }
 
+// ResolveWithExclusions resolves like Resolve, and also returns the candidate bundles that were excluded from
+// resolution and why: denied bundles, held packages and bundles incompatible with the platform
+func (m *containerBasedManager) ResolveWithExclusions(ctx context.Context, packageName string) ([]resolution.Installable, []resolution.ExcludedBundle, error) {
+	packageRequired, err := resolution.NewRequiredPackage(packageName)
+	if err != nil {
+		return nil, nil, err
+	}
+	return m.installer.ResolveWithExclusions(ctx, packageRequired)
+}
+
+// ResolvePackages resolves the required packages and their dependencies together
+func (m *containerBasedManager) ResolvePackages(ctx context.Context, requiredPackages ...*resolution.RequiredPackage) ([]resolution.Installable, error) {
+	return m.installer.Resolve(ctx, requiredPackages...)
+}
+
+// recordOperation stores the operation in the install history. Dry runs are not recorded since
+// they don't change the cluster, and failing to record an operation doesn't fail the operation
+func (m *containerBasedManager) recordOperation(ctx context.Context, operation *store.CachedOperation, plan []store.OperationBundle, err error, options ...InstallOption) {
+	if newInstallConfig(options...).dryRun != DryRunNone {
+		return
+	}
+	operation.Plan = plan
+	operation.Finish(err)
+	contextName, cluster, describeErr := m.installer.client.clusterConfig.Describe()
+	if describeErr != nil {
+		m.logger.Debugf("error describing cluster: %v", describeErr)
+	}
+	operation.Context = contextName
+	operation.Cluster = cluster
+	if err := m.RecordOperation(ctx, operation); err != nil {
+		m.logger.Warnf("error recording operation in history: %v", err)
+	}
+}
+
+func installPlan(installables []resolution.Installable) []store.OperationBundle {
+	plan := make([]store.OperationBundle, 0, len(installables))
+	for _, installable := range installables {
+		plan = append(plan, store.OperationBundle{
+			Action:      string(ChangeActionInstall),
+			PackageName: installable.PackageName,
+			BundleID:    installable.BundleID,
+			ToVersion:   installable.Version,
+		})
+	}
+	return plan
+}
+
+func changePlan(changes []Change) []store.OperationBundle {
+	plan := make([]store.OperationBundle, 0, len(changes))
+	for _, change := range changes {
+		plan = append(plan, store.OperationBundle{
+			Action:      string(change.Action),
+			PackageName: change.PackageName,
+			BundleID:    change.BundleID,
+			FromVersion: change.FromVersion,
+			ToVersion:   change.ToVersion,
+		})
+	}
+	return plan
+}
+
 // AddRepository adds a new OLM software repository
 func (m *containerBasedManager) AddRepository(ctx context.Context, repositoryImageUrl string) error {
-	repo := repository.FromImageURL(repositoryImageUrl, m.logger)
+	return cacheRepositoryFrom(ctx, m.PackageDatabase, repositoryImageUrl, m.logger)
+}
+
+// cacheRepositoryFrom caches the repository served by the image into the package database
+func cacheRepositoryFrom(ctx context.Context, packageDatabase store.PackageDatabase, repositoryImageUrl string, logger *logrus.Logger) error {
+	repo := repository.FromImageURL(repositoryImageUrl, logger)
 	if err := repo.Connect(ctx); err != nil {
 		return err
 	}
 	defer repo.Close()
-	return m.CacheRepository(ctx, repo)
+	return packageDatabase.CacheRepository(ctx, repo)
+}
+
+// RebuildDatabase discards the cached packages and bundles and caches every repository again from
+// its recorded source. The repositories are cached into a staging database first, the cached content
+// is only replaced once every repository has been cached again
+func (m *containerBasedManager) RebuildDatabase(ctx context.Context) error {
+	repositories, err := m.ListRepositories(ctx)
+	if err != nil {
+		return err
+	}
+	staging, err := store.OpenPackageDatabase("memory", "", m.logger)
+	if err != nil {
+		return err
+	}
+	defer staging.Close()
+
+	snapshots := make([]store.RepositorySnapshot, 0, len(repositories))
+	for _, repo := range repositories {
+		m.logger.Printf("Rebuilding repository %s from %s", repo.RepositoryName, repo.RepositorySource)
+		if err := cacheRepositoryFrom(ctx, staging, repo.RepositorySource, m.logger); err != nil {
+			return fmt.Errorf("error rebuilding repository %s, the package database was left unchanged: %w", repo.RepositoryName, err)
+		}
+		snapshot, err := staging.ExportRepository(ctx, store.GetRepositoryName(repo.RepositorySource))
+		if err != nil {
+			return err
+		}
+		snapshots = append(snapshots, *snapshot)
+	}
+
+	if err := m.ReplaceRepositories(ctx, snapshots); err != nil {
+		return fmt.Errorf("error storing the rebuilt repositories, the package database was left unchanged: %w", err)
+	}
+	return nil
 }
@@ -0,0 +1,58 @@
+package manager
+
+import (
+	"context"
+
+	"github.com/perdasilva/olmcli/internal/mirror"
+	"github.com/perdasilva/olmcli/internal/resolution"
+	"github.com/perdasilva/olmcli/internal/store"
+)
+
+// MirrorPlan resolves the packages from the cached repositories and plans mirroring the resolved
+// bundles and their related images to the registry
+func (m *containerBasedManager) MirrorPlan(ctx context.Context, registry string, packageNames ...string) (*mirror.Plan, error) {
+	requiredPackages := make([]*resolution.RequiredPackage, 0, len(packageNames))
+	for _, packageName := range packageNames {
+		requiredPackage, err := resolution.NewRequiredPackage(packageName)
+		if err != nil {
+			return nil, err
+		}
+		requiredPackages = append(requiredPackages, requiredPackage)
+	}
+	// the mirror targets a disconnected cluster, so the connected cluster's version and holds don't apply
+	installables, err := m.offlineSolver.Solve(ctx, requiredPackages...)
+	if err != nil {
+		return nil, err
+	}
+
+	bundles := make([]store.CachedBundle, 0, len(installables))
+	for _, installable := range installables {
+		bundles = append(bundles, installable.CachedBundle)
+	}
+	packages, err := m.bundlePackages(ctx, bundles)
+	if err != nil {
+		return nil, err
+	}
+	return mirror.NewPlan(registry, packages, bundles)
+}
+
+// bundlePackages returns the cached packages the bundles belong to
+func (m *containerBasedManager) bundlePackages(ctx context.Context, bundles []store.CachedBundle) ([]store.CachedPackage, error) {
+	var packages []store.CachedPackage
+	seen := map[string]struct{}{}
+	for _, bundle := range bundles {
+		packageID := store.GetPackageKey(bundle.Repository, bundle.PackageName)
+		if _, ok := seen[packageID]; ok {
+			continue
+		}
+		seen[packageID] = struct{}{}
+		pkg, err := m.GetPackage(ctx, packageID)
+		if err != nil {
+			return nil, err
+		}
+		if pkg != nil {
+			packages = append(packages, *pkg)
+		}
+	}
+	return packages, nil
+}
@@ -0,0 +1,124 @@
+package manager
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/blang/semver/v4"
+	"github.com/operator-framework/rukpak/api/v1alpha1"
+	"github.com/perdasilva/olmcli/internal/resolution"
+	"github.com/perdasilva/olmcli/internal/store"
+)
+
+// InstalledPackage describes an installed package and the versions it can be upgraded to
+type InstalledPackage struct {
+	Package    string `json:"package"`
+	Repository string `json:"repository"`
+	Channel    string `json:"channel"`
+	Version    string `json:"version"`
+	// LatestInChannel is the highest version reachable from the installed version through the
+	// upgrade graph of its channel. It is the installed version if there is no upgrade
+	LatestInChannel string `json:"latestInChannel,omitempty"`
+	// Latest is the highest version of the package in any channel of its repository, and LatestChannel
+	// the channel it is found in, preferring the installed channel
+	Latest        string `json:"latest,omitempty"`
+	LatestChannel string `json:"latestChannel,omitempty"`
+	// Hold is the version range the package is held within, if it is held
+	Hold string `json:"hold,omitempty"`
+	// Outdated is set when a newer version is available in the channel or in another channel
+	Outdated bool `json:"outdated"`
+	// Message explains why the upgrades couldn't be computed, e.g. when the installed bundle isn't cached anymore
+	Message string `json:"message,omitempty"`
+}
+
+// ListInstalledPackages looks up the installed packages in the cached repositories and the versions they can be upgraded to
+func (m *containerBasedManager) ListInstalledPackages(ctx context.Context) ([]InstalledPackage, error) {
+	installed, err := m.installer.ListInstalled(ctx)
+	if err != nil {
+		return nil, err
+	}
+	packages := make([]InstalledPackage, 0, len(installed))
+	for index := range installed {
+		pkg, err := m.installedPackage(ctx, &installed[index])
+		if err != nil {
+			return nil, err
+		}
+		packages = append(packages, *pkg)
+	}
+	sort.Slice(packages, func(i, j int) bool {
+		return packages[i].Package < packages[j].Package
+	})
+	return packages, nil
+}
+
+func (m *containerBasedManager) installedPackage(ctx context.Context, bundleDeployment *v1alpha1.BundleDeployment) (*InstalledPackage, error) {
+	annotations := bundleDeployment.GetAnnotations()
+	pkg := &InstalledPackage{
+		Package:    bundleDeployment.GetName(),
+		Repository: annotations[repositoryAnnotation],
+		Channel:    annotations[channelAnnotation],
+		Version:    annotations[versionAnnotation],
+		Hold:       annotations[holdAnnotation],
+	}
+
+	bundles, err := m.GetBundlesForPackage(ctx, pkg.Package, store.InRepositories(pkg.Repository))
+	if err != nil {
+		return nil, err
+	}
+	if len(bundles) == 0 {
+		pkg.Message = fmt.Sprintf("package not found in repository %s", pkg.Repository)
+		return pkg, nil
+	}
+
+	// the highest version of any channel, preferring the installed channel over the others
+	resolution.Sort(bundles, resolution.ByVersionIncreasing)
+	latest := &bundles[len(bundles)-1]
+	for index := len(bundles) - 1; index >= 0 && bundles[index].Version == latest.Version; index-- {
+		if bundles[index].ChannelName == pkg.Channel {
+			latest = &bundles[index]
+		}
+	}
+	pkg.Latest = latest.Version
+	pkg.LatestChannel = latest.ChannelName
+
+	var channelBundles []store.CachedBundle
+	for _, bundle := range bundles {
+		if bundle.ChannelName == pkg.Channel {
+			channelBundles = append(channelBundles, bundle)
+		}
+	}
+	current := installedBundle(channelBundles, bundleDeployment)
+	if current == nil {
+		pkg.Message = fmt.Sprintf("installed version not found in channel %s", pkg.Channel)
+		installedVersion, installedErr := semver.Parse(pkg.Version)
+		latestVersion, latestErr := semver.Parse(pkg.Latest)
+		pkg.Outdated = installedErr == nil && latestErr == nil && latestVersion.GT(installedVersion)
+		return pkg, nil
+	}
+	pkg.LatestInChannel = pkg.Version
+	if upgrade := resolution.NewChannelGraph(channelBundles).LatestFrom(current.CsvName); upgrade != nil {
+		pkg.LatestInChannel = upgrade.Version
+	}
+	pkg.Outdated = pkg.LatestInChannel != pkg.Version || resolution.ByVersionIncreasing(current, latest)
+	return pkg, nil
+}
+
+// installedBundle returns the bundle the bundle deployment was created from: the bundle with the installed
+// version, or failing that the bundle with the deployed image
+func installedBundle(channelBundles []store.CachedBundle, bundleDeployment *v1alpha1.BundleDeployment) *store.CachedBundle {
+	version := bundleDeployment.GetAnnotations()[versionAnnotation]
+	for index := range channelBundles {
+		if channelBundles[index].Version == version {
+			return &channelBundles[index]
+		}
+	}
+	if template := bundleDeployment.Spec.Template; template != nil && template.Spec.Source.Image != nil {
+		for index := range channelBundles {
+			if channelBundles[index].GetBundlePath() == template.Spec.Source.Image.Ref {
+				return &channelBundles[index]
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,41 @@
+package manager
+
+import (
+	"context"
+	"testing"
+)
+
+func TestInstalledPackage(t *testing.T) {
+	m := newTestManager(t,
+		fixtureBundle("etcd", "stable", "1.0.0", ""),
+		fixtureBundle("etcd", "stable", "1.1.0", "etcd.v1.0.0"),
+		fixtureBundle("etcd", "fast", "1.1.0", ""),
+		fixtureBundle("etcd", "fast", "2.0.0", "etcd.v1.1.0"),
+	)
+
+	for _, tt := range []struct {
+		name            string
+		channel         string
+		version         string
+		latestInChannel string
+		latest          string
+		outdated        bool
+	}{
+		{name: "upgrade in channel", channel: "stable", version: "1.0.0", latestInChannel: "1.1.0", latest: "2.0.0", outdated: true},
+		{name: "upgrade in another channel", channel: "stable", version: "1.1.0", latestInChannel: "1.1.0", latest: "2.0.0", outdated: true},
+		{name: "up to date", channel: "fast", version: "2.0.0", latestInChannel: "2.0.0", latest: "2.0.0"},
+		{name: "older version not cached", channel: "fast", version: "1.5.0", latest: "2.0.0", outdated: true},
+		{name: "newer version not cached", channel: "fast", version: "3.0.0", latest: "2.0.0"},
+		{name: "invalid version not cached", channel: "fast", version: "latest", latest: "2.0.0"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			pkg, err := m.installedPackage(context.Background(), installedBundleDeployment(t, "etcd", tt.channel, tt.version))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if pkg.LatestInChannel != tt.latestInChannel || pkg.Latest != tt.latest || pkg.Outdated != tt.outdated {
+				t.Errorf("expected latest in channel %q, latest %q and outdated %t, got %q, %q and %t", tt.latestInChannel, tt.latest, tt.outdated, pkg.LatestInChannel, pkg.Latest, pkg.Outdated)
+			}
+		})
+	}
+}
@@ -0,0 +1,50 @@
+package manager
+
+import (
+	"context"
+	"io"
+
+	"github.com/perdasilva/olmcli/internal/store"
+)
+
+// ExportRepositories writes a snapshot of the cached repositories to w. All repositories
+// are exported if none are named
+func (m *containerBasedManager) ExportRepositories(ctx context.Context, w io.Writer, repoNames ...string) error {
+	if len(repoNames) == 0 {
+		repositories, err := m.ListRepositories(ctx)
+		if err != nil {
+			return err
+		}
+		for _, repository := range repositories {
+			repoNames = append(repoNames, repository.RepositoryName)
+		}
+	}
+
+	snapshots := make([]store.RepositorySnapshot, 0, len(repoNames))
+	for _, repoName := range repoNames {
+		snapshot, err := m.ExportRepository(ctx, repoName)
+		if err != nil {
+			return err
+		}
+		snapshots = append(snapshots, *snapshot)
+	}
+	return store.WriteSnapshot(w, snapshots)
+}
+
+// ImportRepositories caches the repositories of a snapshot read from r, replacing the
+// cached content of repositories with the same name, and returns the imported repositories
+func (m *containerBasedManager) ImportRepositories(ctx context.Context, r io.Reader) ([]store.CachedRepository, error) {
+	snapshots, err := store.ReadSnapshot(r)
+	if err != nil {
+		return nil, err
+	}
+	repositories := make([]store.CachedRepository, 0, len(snapshots))
+	for index := range snapshots {
+		m.logger.Debugf("Importing repository %s (%d packages, %d bundles)", snapshots[index].Repository.RepositoryName, len(snapshots[index].Packages), len(snapshots[index].Bundles))
+		if err := m.ImportRepository(ctx, &snapshots[index]); err != nil {
+			return nil, err
+		}
+		repositories = append(repositories, snapshots[index].Repository)
+	}
+	return repositories, nil
+}
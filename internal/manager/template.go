@@ -0,0 +1,79 @@
+package manager
+
+const (
+	defaultProvisionerClassName       = "core-rukpak-io-plain"
+	defaultBundleProvisionerClassName = "core-rukpak-io-registry"
+	defaultImagePullSecretName        = "regcred"
+)
+
+// BundleDeploymentTemplate configures the BundleDeployments created for the installed bundles
+type BundleDeploymentTemplate struct {
+	// ProvisionerClassName is the provisioner that reconciles the BundleDeployment
+	ProvisionerClassName string `mapstructure:"provisionerClassName" json:"provisionerClassName,omitempty"`
+	// BundleProvisionerClassName is the provisioner that unpacks the bundle
+	BundleProvisionerClassName string `mapstructure:"bundleProvisionerClassName" json:"bundleProvisionerClassName,omitempty"`
+	// ImagePullSecretName is the secret used to pull the bundle image. It is left as is when unset,
+	// and an empty name removes the secret, e.g. for clusters that don't need one
+	ImagePullSecretName *string `mapstructure:"imagePullSecretName" json:"imagePullSecretName,omitempty"`
+	// Labels are added to the BundleDeployment
+	Labels map[string]string `mapstructure:"labels" json:"labels,omitempty"`
+	// Annotations are added to the BundleDeployment
+	Annotations map[string]string `mapstructure:"annotations" json:"annotations,omitempty"`
+}
+
+// DefaultBundleDeploymentTemplate returns the template used when nothing else is configured
+func DefaultBundleDeploymentTemplate() BundleDeploymentTemplate {
+	return BundleDeploymentTemplate{
+		ProvisionerClassName:       defaultProvisionerClassName,
+		BundleProvisionerClassName: defaultBundleProvisionerClassName,
+		ImagePullSecretName:        pointer(defaultImagePullSecretName),
+	}
+}
+
+// Merge returns a copy of the template with the values set in overrides taking precedence.
+// Labels and annotations are merged key by key
+func (t BundleDeploymentTemplate) Merge(overrides BundleDeploymentTemplate) BundleDeploymentTemplate {
+	merged := BundleDeploymentTemplate{
+		ProvisionerClassName:       t.ProvisionerClassName,
+		BundleProvisionerClassName: t.BundleProvisionerClassName,
+		ImagePullSecretName:        t.ImagePullSecretName,
+		Labels:                     mergeMaps(t.Labels, overrides.Labels),
+		Annotations:                mergeMaps(t.Annotations, overrides.Annotations),
+	}
+	if overrides.ProvisionerClassName != "" {
+		merged.ProvisionerClassName = overrides.ProvisionerClassName
+	}
+	if overrides.BundleProvisionerClassName != "" {
+		merged.BundleProvisionerClassName = overrides.BundleProvisionerClassName
+	}
+	if overrides.ImagePullSecretName != nil {
+		merged.ImagePullSecretName = pointer(*overrides.ImagePullSecretName)
+	}
+	return merged
+}
+
+// pullSecretName returns the name of the image pull secret, empty if there is none
+func (t BundleDeploymentTemplate) pullSecretName() string {
+	if t.ImagePullSecretName == nil {
+		return ""
+	}
+	return *t.ImagePullSecretName
+}
+
+func pointer[T any](value T) *T {
+	return &value
+}
+
+func mergeMaps(base map[string]string, overrides map[string]string) map[string]string {
+	if len(base) == 0 && len(overrides) == 0 {
+		return nil
+	}
+	merged := make(map[string]string, len(base)+len(overrides))
+	for key, value := range base {
+		merged[key] = value
+	}
+	for key, value := range overrides {
+		merged[key] = value
+	}
+	return merged
+}
@@ -0,0 +1,150 @@
+package manager
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestBundleDeploymentTemplateMerge(t *testing.T) {
+	base := BundleDeploymentTemplate{
+		ProvisionerClassName:       "plain",
+		BundleProvisionerClassName: "registry",
+		ImagePullSecretName:        pointer("regcred"),
+		Labels:                     map[string]string{"team": "storage", "tier": "base"},
+		Annotations:                map[string]string{"owner": "base"},
+	}
+
+	for _, tt := range []struct {
+		name      string
+		overrides BundleDeploymentTemplate
+		expected  BundleDeploymentTemplate
+	}{
+		{
+			name:     "empty overrides",
+			expected: base,
+		},
+		{
+			name: "overrides take precedence",
+			overrides: BundleDeploymentTemplate{
+				ProvisionerClassName:       "helm",
+				BundleProvisionerClassName: "git",
+				ImagePullSecretName:        pointer("other"),
+			},
+			expected: BundleDeploymentTemplate{
+				ProvisionerClassName:       "helm",
+				BundleProvisionerClassName: "git",
+				ImagePullSecretName:        pointer("other"),
+				Labels:                     base.Labels,
+				Annotations:                base.Annotations,
+			},
+		},
+		{
+			name:      "empty pull secret removes the secret",
+			overrides: BundleDeploymentTemplate{ImagePullSecretName: pointer("")},
+			expected: BundleDeploymentTemplate{
+				ProvisionerClassName:       "plain",
+				BundleProvisionerClassName: "registry",
+				ImagePullSecretName:        pointer(""),
+				Labels:                     base.Labels,
+				Annotations:                base.Annotations,
+			},
+		},
+		{
+			name: "labels and annotations are merged by key",
+			overrides: BundleDeploymentTemplate{
+				Labels:      map[string]string{"tier": "override", "extra": "yes"},
+				Annotations: map[string]string{"reviewed": "true"},
+			},
+			expected: BundleDeploymentTemplate{
+				ProvisionerClassName:       "plain",
+				BundleProvisionerClassName: "registry",
+				ImagePullSecretName:        pointer("regcred"),
+				Labels:                     map[string]string{"team": "storage", "tier": "override", "extra": "yes"},
+				Annotations:                map[string]string{"owner": "base", "reviewed": "true"},
+			},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			merged := base.Merge(tt.overrides)
+			if !reflect.DeepEqual(merged, tt.expected) {
+				t.Errorf("expected %+v, got %+v", tt.expected, merged)
+			}
+		})
+	}
+
+	// the merged template doesn't share the pull secret with the overrides
+	overrides := BundleDeploymentTemplate{ImagePullSecretName: pointer("other")}
+	merged := base.Merge(overrides)
+	*overrides.ImagePullSecretName = "changed"
+	if merged.pullSecretName() != "other" {
+		t.Errorf("expected pull secret other, got %s", merged.pullSecretName())
+	}
+}
+
+func TestTemplateFor(t *testing.T) {
+	// each level overrides the label set by the previous levels, and sets a label of its own
+	level := func(name string) BundleDeploymentTemplate {
+		return BundleDeploymentTemplate{
+			ProvisionerClassName: name,
+			Labels:               map[string]string{"level": name, name: "true"},
+		}
+	}
+	installer := newTestInstaller(t, level("global"), map[string]BundleDeploymentTemplate{
+		fixtureRepository: level("repository"),
+		"other":           level("other-repository"),
+	})
+	installable := fixtureInstallable("etcd", "stable", "1.0.0")
+
+	for _, tt := range []struct {
+		name     string
+		options  []InstallOption
+		expected string
+		labels   []string
+	}{
+		{
+			name:     "repository over global",
+			expected: "repository",
+			labels:   []string{"global", "repository"},
+		},
+		{
+			name:     "defaults over repository",
+			options:  []InstallOption{WithBundleDeploymentDefaults(level("defaults"))},
+			expected: "defaults",
+			labels:   []string{"global", "repository", "defaults"},
+		},
+		{
+			name: "package over defaults",
+			options: []InstallOption{
+				WithBundleDeploymentDefaults(level("defaults")),
+				WithPackageBundleDeploymentOverrides("etcd", level("package")),
+				WithPackageBundleDeploymentOverrides("prometheus", level("other-package")),
+			},
+			expected: "package",
+			labels:   []string{"global", "repository", "defaults", "package"},
+		},
+		{
+			name: "overrides over package",
+			options: []InstallOption{
+				WithBundleDeploymentOverrides(level("overrides")),
+				WithBundleDeploymentDefaults(level("defaults")),
+				WithPackageBundleDeploymentOverrides("etcd", level("package")),
+			},
+			expected: "overrides",
+			labels:   []string{"global", "repository", "defaults", "package", "overrides"},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			template := installer.templateFor(&installable, newInstallConfig(tt.options...))
+			if template.ProvisionerClassName != tt.expected || template.Labels["level"] != tt.expected {
+				t.Errorf("expected the %s template to take precedence, got %+v", tt.expected, template)
+			}
+			expectedLabels := map[string]string{"level": tt.expected}
+			for _, label := range tt.labels {
+				expectedLabels[label] = "true"
+			}
+			if !reflect.DeepEqual(template.Labels, expectedLabels) {
+				t.Errorf("expected labels %v, got %v", expectedLabels, template.Labels)
+			}
+		})
+	}
+}
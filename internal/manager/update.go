@@ -0,0 +1,168 @@
+package manager
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/operator-framework/rukpak/api/v1alpha1"
+	"github.com/perdasilva/olmcli/internal/resolution"
+	"github.com/perdasilva/olmcli/internal/store"
+)
+
+// Update upgrades installed packages, or all installed packages if packageNames is empty, to the latest bundle
+// reachable from the installed bundle through the upgrade graph of their channel. A single package can switch
+// to another channel, which requires the installed bundle to be part of the new channel, or to be replaced,
+// skipped or included in the skip range of one of its bundles. The upgrades are resolved together with the
+// other installed packages, which are kept at their installed version, and install the new dependencies they
+// need. Held packages are only upgraded within their hold's range: updating all packages skips the held packages
+// that can't be upgraded, updating a named package fails
+func (m *containerBasedManager) Update(ctx context.Context, packageNames []string, channel string, options ...InstallOption) ([]Change, error) {
+	if channel != "" && len(packageNames) != 1 {
+		return nil, fmt.Errorf("a channel can only be given when updating a single package")
+	}
+	installed, err := m.installer.ListInstalled(ctx)
+	if err != nil {
+		return nil, err
+	}
+	installedByName := map[string]*v1alpha1.BundleDeployment{}
+	for index := range installed {
+		installedByName[installed[index].GetName()] = &installed[index]
+	}
+	all := len(packageNames) == 0
+	if all {
+		for name := range installedByName {
+			packageNames = append(packageNames, name)
+		}
+		sort.Strings(packageNames)
+	}
+
+	var requiredPackages []*resolution.RequiredPackage
+	updated := map[string]struct{}{}
+	for _, packageName := range packageNames {
+		bundleDeployment, ok := installedByName[packageName]
+		if !ok {
+			return nil, fmt.Errorf("package %s is not installed", packageName)
+		}
+		target, err := m.updateTarget(ctx, bundleDeployment, channel)
+		if err == nil {
+			err = checkHold(bundleDeployment, target)
+		}
+		if err != nil {
+			if !all {
+				return nil, err
+			}
+			m.logger.Warnf("Skipping %s: %s", packageName, err)
+			continue
+		}
+		requiredPackage, err := resolution.NewRequiredPackage(packageName, resolution.InRepo(target.Repository), resolution.InChan(target.ChannelName), resolution.InVersionRange(target.Version))
+		if err != nil {
+			return nil, err
+		}
+		requiredPackages = append(requiredPackages, requiredPackage)
+		updated[packageName] = struct{}{}
+	}
+
+	operation := store.NewOperation(store.OperationUpdate)
+	for _, requiredPackage := range requiredPackages {
+		operation.RequestedPackages = append(operation.RequestedPackages, requiredPackage.String())
+	}
+	for index := range installed {
+		if _, ok := updated[installed[index].GetName()]; ok {
+			continue
+		}
+		requiredPackage, err := m.installedRequiredPackage(ctx, &installed[index])
+		if err != nil {
+			return nil, err
+		}
+		if requiredPackage != nil {
+			requiredPackages = append(requiredPackages, requiredPackage)
+		}
+	}
+
+	changes, err := m.installer.Apply(ctx, requiredPackages, options...)
+	m.recordOperation(ctx, operation, changePlan(changes), err, options...)
+	return changes, err
+}
+
+// checkHold fails if the package of the bundle deployment is held outside the version of the target bundle
+func checkHold(bundleDeployment *v1alpha1.BundleDeployment, target *store.CachedBundle) error {
+	hold, err := bundleDeploymentHold(bundleDeployment)
+	if err != nil {
+		return err
+	}
+	if hold != nil && !hold.Allows(target.Version) {
+		return fmt.Errorf("package %s, it can't be updated to %s: release it with 'olm unhold %s'", hold, target.Version, hold.Package)
+	}
+	return nil
+}
+
+// updateTarget returns the bundle of the channel the installed package is upgraded to
+func (m *containerBasedManager) updateTarget(ctx context.Context, bundleDeployment *v1alpha1.BundleDeployment, channel string) (*store.CachedBundle, error) {
+	annotations := bundleDeployment.GetAnnotations()
+	packageName := bundleDeployment.GetName()
+	repository := annotations[repositoryAnnotation]
+	installedChannel := annotations[channelAnnotation]
+	if channel == "" {
+		channel = installedChannel
+	}
+
+	bundles, err := m.GetBundlesForPackage(ctx, packageName, store.InRepositories(repository))
+	if err != nil {
+		return nil, err
+	}
+	var installedChannelBundles, channelBundles []store.CachedBundle
+	for _, bundle := range bundles {
+		if bundle.ChannelName == installedChannel {
+			installedChannelBundles = append(installedChannelBundles, bundle)
+		}
+		if bundle.ChannelName == channel {
+			channelBundles = append(channelBundles, bundle)
+		}
+	}
+	current := installedBundle(installedChannelBundles, bundleDeployment)
+	if current == nil {
+		return nil, fmt.Errorf("installed bundle of %s %s not found in channel %s of repository %s", packageName, annotations[versionAnnotation], installedChannel, repository)
+	}
+	if len(channelBundles) == 0 {
+		return nil, fmt.Errorf("channel %s of package %s not found in repository %s", channel, packageName, repository)
+	}
+
+	// the installed bundle is added to the graph of the new channel so skip ranges of the channel's bundles apply to it
+	graph := resolution.NewChannelGraph(channelBundles)
+	inChannel := graph.Bundle(current.CsvName)
+	if inChannel == nil {
+		graph = resolution.NewChannelGraph(append(channelBundles, *current))
+	}
+	if latest := graph.LatestFrom(current.CsvName); latest != nil {
+		return latest, nil
+	}
+	if inChannel == nil {
+		return nil, fmt.Errorf("no upgrade path from %s %s into channel %s: no bundle of the channel replaces or skips %s, or includes it in its skip range", packageName, current.Version, channel, current.CsvName)
+	}
+	return inChannel, nil
+}
+
+// installedRequiredPackage requires the installed version of the package, so resolution keeps it unless it
+// was installed from a bundle that isn't cached anymore, in which case it is left out of resolution. A package
+// held outside its installed version is required within its hold's range instead, which moves it into the range
+func (m *containerBasedManager) installedRequiredPackage(ctx context.Context, bundleDeployment *v1alpha1.BundleDeployment) (*resolution.RequiredPackage, error) {
+	annotations := bundleDeployment.GetAnnotations()
+	hold, err := bundleDeploymentHold(bundleDeployment)
+	if err != nil {
+		return nil, err
+	}
+	if hold != nil && !hold.Allows(annotations[versionAnnotation]) {
+		return resolution.NewRequiredPackage(hold.Package, resolution.InRepo(annotations[repositoryAnnotation]), resolution.InChan(annotations[channelAnnotation]), resolution.InVersionRange(hold.Version))
+	}
+	bundles, err := m.GetBundlesForPackage(ctx, bundleDeployment.GetName(), store.InRepositories(annotations[repositoryAnnotation]), store.InChannel(annotations[channelAnnotation]))
+	if err != nil {
+		return nil, err
+	}
+	bundle := installedBundle(bundles, bundleDeployment)
+	if bundle == nil {
+		m.logger.Warnf("installed bundle of %s %s is not cached, it is left out of resolution", bundleDeployment.GetName(), annotations[versionAnnotation])
+		return nil, nil
+	}
+	return resolution.NewRequiredPackage(bundle.PackageName, resolution.InRepo(bundle.Repository), resolution.InChan(bundle.ChannelName), resolution.InVersionRange(bundle.Version))
+}
@@ -0,0 +1,76 @@
+package manager
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestUpdateTarget(t *testing.T) {
+	candidate := fixtureBundle("etcd", "candidate", "3.0.0", "")
+	candidate.SkipRange = "<3.0.0"
+	m := newTestManager(t,
+		fixtureBundle("etcd", "stable", "1.0.0", ""),
+		fixtureBundle("etcd", "stable", "1.1.0", "etcd.v1.0.0"),
+		fixtureBundle("etcd", "stable", "1.2.0", "etcd.v1.1.0"),
+		fixtureBundle("etcd", "fast", "1.1.0", ""),
+		fixtureBundle("etcd", "fast", "2.0.0", "etcd.v1.1.0"),
+		candidate,
+		fixtureBundle("etcd", "isolated", "4.0.0", ""),
+	)
+
+	for _, tt := range []struct {
+		name      string
+		version   string
+		installed string
+		channel   string
+		expected  string
+		err       string
+	}{
+		{name: "latest in the installed channel", installed: "stable", version: "1.0.0", expected: "etcd.v1.2.0"},
+		{name: "head of the installed channel", installed: "stable", version: "1.2.0", expected: "etcd.v1.2.0"},
+		{name: "switch to a channel holding the installed bundle", installed: "stable", version: "1.1.0", channel: "fast", expected: "etcd.v2.0.0"},
+		{name: "switch through a skip range", installed: "stable", version: "1.0.0", channel: "candidate", expected: "etcd.v3.0.0"},
+		{name: "switch without an upgrade path", installed: "stable", version: "1.0.0", channel: "isolated", err: "no upgrade path"},
+		{name: "unknown channel", installed: "stable", version: "1.0.0", channel: "unknown", err: "channel unknown of package etcd not found"},
+		{name: "installed bundle not cached", installed: "stable", version: "0.9.0", err: "installed bundle of etcd 0.9.0 not found"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			bundleDeployment := installedBundleDeployment(t, "etcd", tt.installed, tt.version)
+			target, err := m.updateTarget(context.Background(), bundleDeployment, tt.channel)
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("expected error %q, got %v", tt.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if target.CsvName != tt.expected {
+				t.Errorf("expected %s, got %s", tt.expected, target.CsvName)
+			}
+			if tt.channel != "" && target.ChannelName != tt.channel {
+				t.Errorf("expected a bundle of channel %s, got %s", tt.channel, target.ChannelName)
+			}
+		})
+	}
+}
+
+func TestCheckHold(t *testing.T) {
+	bundleDeployment := installedBundleDeployment(t, "etcd", "stable", "1.0.0")
+	bundleDeployment.Annotations[holdAnnotation] = "<1.2.0"
+	for _, tt := range []struct {
+		version string
+		held    bool
+	}{
+		{version: "1.1.0"},
+		{version: "1.2.0", held: true},
+	} {
+		target := fixtureBundle("etcd", "stable", tt.version, "")
+		err := checkHold(bundleDeployment, &target)
+		if (err != nil) != tt.held {
+			t.Errorf("expected update to %s held to be %t, got %v", tt.version, tt.held, err)
+		}
+	}
+}
@@ -0,0 +1,152 @@
+package mirror
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os/exec"
+	"sort"
+	"strings"
+
+	"github.com/operator-framework/operator-registry/alpha/declcfg"
+	"github.com/perdasilva/olmcli/internal/catalog"
+	"github.com/perdasilva/olmcli/internal/store"
+	"github.com/sirupsen/logrus"
+)
+
+// ImageMapping maps an image to its copy in the mirror registry
+type ImageMapping struct {
+	Source string
+	// Target is the reference the image is pushed to. Registries don't accept pushes by digest,
+	// so images referenced by digest are pushed to a tag derived from the digest
+	Target string
+	// Mirrored is the reference to the mirrored image, it keeps the digest of images referenced by digest
+	Mirrored string
+}
+
+// Plan lists the images to mirror and the catalog rewritten to pull them from the mirror
+type Plan struct {
+	Registry string
+	Mappings []ImageMapping
+	Catalog  *declcfg.DeclarativeConfig
+}
+
+// NewPlan renders the bundles as a file-based catalog whose bundle and related images point to
+// the mirror registry, and maps each image to its mirrored reference
+func NewPlan(registry string, packages []store.CachedPackage, bundles []store.CachedBundle) (*Plan, error) {
+	registry = strings.TrimSuffix(registry, "/")
+	if registry == "" {
+		return nil, fmt.Errorf("no mirror registry given")
+	}
+	cfg, err := catalog.ToDeclarativeConfig(packages, bundles)
+	if err != nil {
+		return nil, err
+	}
+
+	mappings := map[string]ImageMapping{}
+	mirror := func(image string) (string, error) {
+		if image == "" {
+			return "", nil
+		}
+		mapping, ok := mappings[image]
+		if !ok {
+			mapping, err = MirrorImage(registry, image)
+			if err != nil {
+				return "", err
+			}
+			mappings[image] = mapping
+		}
+		return mapping.Mirrored, nil
+	}
+	for index := range cfg.Bundles {
+		bundle := &cfg.Bundles[index]
+		if bundle.Image, err = mirror(bundle.Image); err != nil {
+			return nil, fmt.Errorf("bundle %s: %w", bundle.Name, err)
+		}
+		for relatedIndex := range bundle.RelatedImages {
+			relatedImage := &bundle.RelatedImages[relatedIndex]
+			if relatedImage.Image, err = mirror(relatedImage.Image); err != nil {
+				return nil, fmt.Errorf("bundle %s: related image %s: %w", bundle.Name, relatedImage.Name, err)
+			}
+		}
+	}
+
+	plan := &Plan{Registry: registry, Catalog: cfg}
+	for _, mapping := range mappings {
+		plan.Mappings = append(plan.Mappings, mapping)
+	}
+	sort.Slice(plan.Mappings, func(i, j int) bool {
+		return plan.Mappings[i].Source < plan.Mappings[j].Source
+	})
+	return plan, nil
+}
+
+// MirrorImage maps the image to the same repository path in the mirror registry
+func MirrorImage(registry string, image string) (ImageMapping, error) {
+	repository, tag, digest, err := parseImage(image)
+	if err != nil {
+		return ImageMapping{}, err
+	}
+	target := registry + "/" + repository
+	mapping := ImageMapping{Source: image}
+	switch {
+	case digest != "":
+		mapping.Target = target + ":" + strings.Replace(digest, ":", "-", 1)
+		mapping.Mirrored = target + "@" + digest
+	case tag != "":
+		mapping.Target = target + ":" + tag
+		mapping.Mirrored = mapping.Target
+	default:
+		mapping.Target = target + ":latest"
+		mapping.Mirrored = mapping.Target
+	}
+	return mapping, nil
+}
+
+// parseImage splits an image reference into its repository path, without the registry, its tag and its digest
+func parseImage(image string) (string, string, string, error) {
+	name, digest, _ := strings.Cut(image, "@")
+	var tag string
+	if index := strings.LastIndex(name, ":"); index > strings.LastIndex(name, "/") {
+		name, tag = name[:index], name[index+1:]
+	}
+
+	repository := name
+	if registry, path, ok := strings.Cut(name, "/"); ok && (strings.ContainsAny(registry, ".:") || registry == "localhost") {
+		repository = path
+	} else if !strings.Contains(name, "/") {
+		// official images live in the library namespace of the default registry
+		repository = "library/" + name
+	}
+	if repository == "" || name == "" {
+		return "", "", "", fmt.Errorf("invalid image reference %q", image)
+	}
+	if digest != "" && !strings.Contains(digest, ":") {
+		return "", "", "", fmt.Errorf("invalid digest in image reference %q", image)
+	}
+	return repository, tag, digest, nil
+}
+
+// WriteMapping writes the mappings as source=target lines, the format read by 'oc image mirror -f'
+func (p *Plan) WriteMapping(w io.Writer) error {
+	for _, mapping := range p.Mappings {
+		if _, err := fmt.Fprintf(w, "%s=%s\n", mapping.Source, mapping.Target); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Copy copies the images to the mirror registry with skopeo, keeping their digests
+func (p *Plan) Copy(ctx context.Context, logger *logrus.Logger) error {
+	for _, mapping := range p.Mappings {
+		logger.Printf("Copying %s to %s", mapping.Source, mapping.Target)
+		copyCmd := []string{"skopeo", "copy", "--all", "--preserve-digests", "docker://" + mapping.Source, "docker://" + mapping.Target}
+		logger.Debugln("Executing ", strings.Join(copyCmd, " "))
+		output, err := exec.CommandContext(ctx, copyCmd[0], copyCmd[1:]...).CombinedOutput()
+		if err != nil {
+			return fmt.Errorf("error copying %s to %s: %w: %s", mapping.Source, mapping.Target, err, strings.TrimSpace(string(output)))
+		}
+	}
+	return nil
+}
@@ -11,6 +11,8 @@ type Repository interface {
 	Connect(ctx context.Context) error
 	Close() error
 	Source() string
+	// Digest returns the digest of the repository content, if known
+	Digest() string
 }
 
 type RepositoryContainer interface {
@@ -18,4 +20,6 @@ type RepositoryContainer interface {
 	Stop() error
 	ImageURL() string
 	RepositoryURL() string
+	// ImageDigest returns the digest of the started image, if known
+	ImageDigest() string
 }
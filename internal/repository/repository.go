@@ -58,6 +58,10 @@ func (r *URLBasedRepository) Source() string {
 	return r.repositoryURL
 }
 
+func (r *URLBasedRepository) Digest() string {
+	return ""
+}
+
 func (r *URLBasedRepository) Connect(ctx context.Context) error {
 	var err error
 	r.logger.Debugln("Connecting to registry...")
@@ -97,6 +101,10 @@ func (r *ImageBasedRepository) Source() string {
 	return r.repositoryContainer.ImageURL()
 }
 
+func (r *ImageBasedRepository) Digest() string {
+	return r.repositoryContainer.ImageDigest()
+}
+
 func (r *ImageBasedRepository) Connect(ctx context.Context) error {
 	if err := r.repositoryContainer.Start(); err != nil {
 		return err
@@ -11,6 +11,7 @@ var _ RepositoryContainer = &simpleRepositoryContainer{}
 
 type simpleRepositoryContainer struct {
 	containerID        string
+	imageDigest        string
 	repositoryImageUrl string
 	logger             *logrus.Logger
 }
@@ -23,6 +24,10 @@ func (s *simpleRepositoryContainer) ImageURL() string {
 	return s.repositoryImageUrl
 }
 
+func (s *simpleRepositoryContainer) ImageDigest() string {
+	return s.imageDigest
+}
+
 func (s *simpleRepositoryContainer) Start() error {
 	s.logger.Debugln("Starting container...")
 	startContainerCmd := []string{"docker", "run", "--rm", "-d", "-p", "50051:50051", s.repositoryImageUrl}
@@ -34,6 +39,21 @@ func (s *simpleRepositoryContainer) Start() error {
 	}
 	// save container EntryID for clean up
 	s.containerID = strings.TrimSpace(string(stdout))
+
+	// record the digest of the image that was pulled so resolutions can be locked to the repository content
+	inspectImageCmd := []string{"docker", "image", "inspect", "--format", "{{join .RepoDigests \"\\n\"}}", s.repositoryImageUrl}
+	s.logger.Debugln("Executing ", strings.Join(inspectImageCmd, " "))
+	stdout, err = exec.Command(inspectImageCmd[0], inspectImageCmd[1:]...).Output()
+	if err != nil {
+		s.logger.Debugf("error inspecting image: %v", err)
+		return nil
+	}
+	for _, repoDigest := range strings.Split(strings.TrimSpace(string(stdout)), "\n") {
+		if _, digest, ok := strings.Cut(repoDigest, "@"); ok {
+			s.imageDigest = digest
+			break
+		}
+	}
 	return nil
 }
 
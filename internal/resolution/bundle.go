@@ -2,8 +2,10 @@ package resolution
 
 import (
 	"context"
+	"fmt"
 
 	"github.com/blang/semver/v4"
+	"github.com/operator-framework/api/pkg/constraints"
 	v2 "github.com/operator-framework/deppy/pkg/v2"
 	"github.com/perdasilva/olmcli/internal/store"
 )
@@ -11,12 +13,14 @@ import (
 var _ v2.VariableSource[*store.CachedBundle, OLMVariable, *OLMEntitySource] = &DependenciesVariableSource{}
 
 type DependenciesVariableSource struct {
-	queue []store.CachedBundle
+	queue          []store.CachedBundle
+	celEnvironment *constraints.CelEnvironment
 }
 
 func NewBundleVariableSource(seedEntities ...store.CachedBundle) *DependenciesVariableSource {
 	return &DependenciesVariableSource{
-		queue: seedEntities,
+		queue:          seedEntities,
+		celEnvironment: constraints.NewCelEnvironment(),
 	}
 }
 
@@ -32,26 +36,88 @@ func (r *DependenciesVariableSource) GetVariables(ctx context.Context, source *O
 		}
 		processedEntities[head.ID()] = struct{}{}
 
-		// extract package and gvk dependencies
-		var dependencyEntities []store.CachedBundle
-		for _, packageDependency := range head.PackageDependencies {
-			bundles, err := source.GetBundlesForPackage(ctx, packageDependency.PackageName, store.InVersionRange(semver.MustParseRange(packageDependency.Version)))
-			if err != nil {
-				return nil, err
-			}
-			dependencyEntities = append(dependencyEntities, bundles...)
+		dependencies, conflicts, err := r.bundleDependencies(ctx, source, &head)
+		if err != nil {
+			return nil, err
+		}
+		for _, dependencyEntities := range dependencies {
+			r.queue = append(r.queue, dependencyEntities...)
+		}
+		// conflicting bundles need variables of their own for the conflicts to refer to
+		r.queue = append(r.queue, conflicts...)
+		variables = append(variables, NewBundleVariable(&head, conflicts, dependencies...))
+	}
+	return variables, nil
+}
+
+// bundleDependencies returns the candidates of each dependency of the bundle, most preferred first, and the
+// bundles it conflicts with
+func (r *DependenciesVariableSource) bundleDependencies(ctx context.Context, source *OLMEntitySource, head *store.CachedBundle) ([][]store.CachedBundle, []store.CachedBundle, error) {
+	// extract package, gvk and generic constraint dependencies, each of which must be
+	// satisfied by one of its candidate bundles
+	var dependencies [][]store.CachedBundle
+	for _, packageDependency := range head.PackageDependencies {
+		versionRange, err := semver.ParseRange(packageDependency.Version)
+		if err != nil {
+			// dependencies with an invalid version range can't be satisfied
+			dependencies = append(dependencies, nil)
+			continue
+		}
+		bundles, err := source.GetBundlesForPackage(ctx, packageDependency.PackageName, store.InVersionRange(versionRange))
+		if err != nil {
+			return nil, nil, err
+		}
+		dependencies = append(dependencies, bundles)
+	}
+
+	for _, gvkDependency := range head.RequiredApis {
+		bundles, err := source.ListBundlesForGVK(ctx, gvkDependency.GetGroup(), gvkDependency.GetVersion(), gvkDependency.GetKind())
+		if err != nil {
+			return nil, nil, err
 		}
+		dependencies = append(dependencies, bundles)
+	}
 
-		for _, gvkDependency := range head.RequiredApis {
-			bundles, err := source.ListBundlesForGVK(ctx, gvkDependency.GetGroup(), gvkDependency.GetVersion(), gvkDependency.GetKind())
-			if err != nil {
-				return nil, err
+	// as in OLM, a not constraint makes the bundle conflict with the bundles satisfying any of the
+	// nested constraints, while other constraints are dependencies
+	var conflicts []store.CachedBundle
+	for _, constraint := range head.Constraints {
+		if constraint.Not != nil {
+			for _, nested := range constraint.Not.Constraints {
+				bundles, err := constraintCandidates(ctx, source, nested, r.celEnvironment)
+				if err != nil {
+					return nil, nil, fmt.Errorf("error evaluating constraint of bundle %s: %w", head.BundleID, err)
+				}
+				conflicts = appendMissing(conflicts, bundles...)
 			}
-			dependencyEntities = append(dependencyEntities, bundles...)
+			continue
+		}
+		bundles, err := constraintCandidates(ctx, source, constraint, r.celEnvironment)
+		if err != nil {
+			return nil, nil, fmt.Errorf("error evaluating constraint of bundle %s: %w", head.BundleID, err)
 		}
+		dependencies = append(dependencies, bundles)
+	}
+
+	for _, dependencyEntities := range dependencies {
 		Sort(dependencyEntities, ByChannelAndVersionPreferRepository(head.Repository))
-		r.queue = append(r.queue, dependencyEntities...)
-		variables = append(variables, NewBundleVariable(&head, dependencyEntities...))
 	}
-	return variables, nil
+	return dependencies, conflicts, nil
+}
+
+// appendMissing appends the bundles that aren't in the list yet
+func appendMissing(list []store.CachedBundle, bundles ...store.CachedBundle) []store.CachedBundle {
+	for _, bundle := range bundles {
+		found := false
+		for index := range list {
+			if list[index].BundleID == bundle.BundleID {
+				found = true
+				break
+			}
+		}
+		if !found {
+			list = append(list, bundle)
+		}
+	}
+	return list
 }
@@ -0,0 +1,208 @@
+package resolution
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/blang/semver/v4"
+	"github.com/operator-framework/api/pkg/constraints"
+	"github.com/perdasilva/olmcli/internal/store"
+)
+
+// BundlePredicate decides whether a bundle satisfies a constraint
+type BundlePredicate func(bundle *store.CachedBundle) (bool, error)
+
+// NewConstraintPredicate translates an olm.constraint into a bundle predicate. As in OLM, compound
+// constraints apply to a single bundle: all, any and not require a bundle to satisfy all, any or
+// none of the nested constraints. A bundle's top level not constraint isn't a predicate but a
+// conflict, see DependenciesVariableSource
+func NewConstraintPredicate(constraint constraints.Constraint, celEnvironment *constraints.CelEnvironment) (BundlePredicate, error) {
+	switch {
+	case constraint.Package != nil:
+		return packagePredicate(constraint.Package.PackageName, constraint.Package.VersionRange)
+	case constraint.GVK != nil:
+		gvk := constraint.GVK
+		return func(bundle *store.CachedBundle) (bool, error) {
+			for _, providedAPI := range bundle.GetProvidedApis() {
+				if providedAPI.GetGroup() == gvk.Group && providedAPI.GetVersion() == gvk.Version && providedAPI.GetKind() == gvk.Kind {
+					return true, nil
+				}
+			}
+			return false, nil
+		}, nil
+	case constraint.Cel != nil:
+		program, err := celEnvironment.Validate(constraint.Cel.Rule)
+		if err != nil {
+			return nil, fmt.Errorf("invalid cel rule %q: %w", constraint.Cel.Rule, err)
+		}
+		return func(bundle *store.CachedBundle) (bool, error) {
+			properties, err := celProperties(bundle)
+			if err != nil {
+				return false, err
+			}
+			return program.Evaluate(map[string]interface{}{constraints.PropertiesKey: properties})
+		}, nil
+	case constraint.All != nil:
+		predicates, err := compoundPredicates(constraint.All, celEnvironment)
+		if err != nil {
+			return nil, err
+		}
+		return func(bundle *store.CachedBundle) (bool, error) {
+			for _, predicate := range predicates {
+				if ok, err := predicate(bundle); err != nil || !ok {
+					return false, err
+				}
+			}
+			return true, nil
+		}, nil
+	case constraint.Any != nil:
+		predicates, err := compoundPredicates(constraint.Any, celEnvironment)
+		if err != nil {
+			return nil, err
+		}
+		return func(bundle *store.CachedBundle) (bool, error) {
+			for _, predicate := range predicates {
+				if ok, err := predicate(bundle); err != nil || ok {
+					return ok, err
+				}
+			}
+			return false, nil
+		}, nil
+	case constraint.Not != nil:
+		predicates, err := compoundPredicates(constraint.Not, celEnvironment)
+		if err != nil {
+			return nil, err
+		}
+		return func(bundle *store.CachedBundle) (bool, error) {
+			for _, predicate := range predicates {
+				if ok, err := predicate(bundle); err != nil || ok {
+					return false, err
+				}
+			}
+			return true, nil
+		}, nil
+	}
+	return nil, fmt.Errorf("constraint has no package, gvk, cel, all, any or not clause")
+}
+
+func compoundPredicates(compound *constraints.CompoundConstraint, celEnvironment *constraints.CelEnvironment) ([]BundlePredicate, error) {
+	predicates := make([]BundlePredicate, 0, len(compound.Constraints))
+	for _, constraint := range compound.Constraints {
+		predicate, err := NewConstraintPredicate(constraint, celEnvironment)
+		if err != nil {
+			return nil, err
+		}
+		predicates = append(predicates, predicate)
+	}
+	return predicates, nil
+}
+
+func packagePredicate(packageName string, versionRange string) (BundlePredicate, error) {
+	inRange := func(semver.Version) bool { return true }
+	if versionRange != "" {
+		var err error
+		if inRange, err = semver.ParseRange(versionRange); err != nil {
+			return nil, fmt.Errorf("invalid version range %q for package %s: %w", versionRange, packageName, err)
+		}
+	}
+	return func(bundle *store.CachedBundle) (bool, error) {
+		if bundle.PackageName != packageName {
+			return false, nil
+		}
+		version, err := semver.Parse(bundle.Version)
+		if err != nil {
+			return false, nil
+		}
+		return inRange(version), nil
+	}, nil
+}
+
+// celProperties converts the bundle properties to the cel input format: a list of type/value maps
+// with the json values decoded
+func celProperties(bundle *store.CachedBundle) ([]map[string]interface{}, error) {
+	properties := make([]map[string]interface{}, 0, len(bundle.GetProperties()))
+	for _, prop := range bundle.GetProperties() {
+		var value interface{}
+		if err := json.Unmarshal([]byte(prop.GetValue()), &value); err != nil {
+			return nil, fmt.Errorf("error decoding property %s of bundle %s: %w", prop.GetType(), bundle.BundleID, err)
+		}
+		properties = append(properties, map[string]interface{}{"type": prop.GetType(), "value": value})
+	}
+	return properties, nil
+}
+
+// constraintCandidates returns the bundles satisfying a constraint. Candidates are read from the package
+// database indexes if the constraint or one of the constraints it requires is a package or gvk
+// constraint, otherwise the constraint is evaluated against every bundle
+func constraintCandidates(ctx context.Context, source *OLMEntitySource, constraint constraints.Constraint, celEnvironment *constraints.CelEnvironment) ([]store.CachedBundle, error) {
+	predicate, err := NewConstraintPredicate(constraint, celEnvironment)
+	if err != nil {
+		return nil, err
+	}
+
+	var candidates []store.CachedBundle
+	keep := func(bundle *store.CachedBundle) error {
+		ok, err := predicate(bundle)
+		if ok {
+			candidates = append(candidates, *bundle)
+		}
+		return err
+	}
+
+	bundles, indexed, err := indexedCandidates(ctx, source, constraint)
+	if err != nil {
+		return nil, err
+	}
+	if !indexed {
+		if err := source.IterateBundles(ctx, keep); err != nil {
+			return nil, err
+		}
+		return candidates, nil
+	}
+	for index := range bundles {
+		if err := keep(&bundles[index]); err != nil {
+			return nil, err
+		}
+	}
+	return candidates, nil
+}
+
+// indexedCandidates returns the bundles that may satisfy the constraint, read from the package database
+// indexes. It returns false if the candidates can't be narrowed without evaluating every bundle
+func indexedCandidates(ctx context.Context, source *OLMEntitySource, constraint constraints.Constraint) ([]store.CachedBundle, bool, error) {
+	switch {
+	case constraint.Package != nil:
+		bundles, err := source.GetBundlesForPackage(ctx, constraint.Package.PackageName)
+		return bundles, true, err
+	case constraint.GVK != nil:
+		bundles, err := source.ListBundlesForGVK(ctx, constraint.GVK.Group, constraint.GVK.Version, constraint.GVK.Kind)
+		return bundles, true, err
+	case constraint.All != nil:
+		// a bundle satisfying all constraints is a candidate of each of them
+		for _, required := range constraint.All.Constraints {
+			if bundles, indexed, err := indexedCandidates(ctx, source, required); err != nil || indexed {
+				return bundles, indexed, err
+			}
+		}
+	case constraint.Any != nil:
+		// a bundle satisfying any constraint is a candidate of one of them, so an empty any
+		// (the constraint invalid constraints are cached as) has no candidates
+		var bundles []store.CachedBundle
+		seen := map[string]struct{}{}
+		for _, alternative := range constraint.Any.Constraints {
+			alternativeBundles, indexed, err := indexedCandidates(ctx, source, alternative)
+			if err != nil || !indexed {
+				return nil, indexed, err
+			}
+			for _, bundle := range alternativeBundles {
+				if _, ok := seen[bundle.BundleID]; !ok {
+					seen[bundle.BundleID] = struct{}{}
+					bundles = append(bundles, bundle)
+				}
+			}
+		}
+		return bundles, true, nil
+	}
+	return nil, false, nil
+}
@@ -0,0 +1,232 @@
+package resolution_test
+
+import (
+	"context"
+	"io"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/operator-framework/api/pkg/constraints"
+	"github.com/operator-framework/operator-registry/pkg/api"
+	"github.com/perdasilva/olmcli/internal/catalog"
+	"github.com/perdasilva/olmcli/internal/resolution"
+	"github.com/perdasilva/olmcli/internal/store"
+	"github.com/sirupsen/logrus"
+)
+
+func testLogger() *logrus.Logger {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	return logger
+}
+
+// fixtureSource serves the bundles of a file-based catalog fixture from an in-memory package database
+func fixtureSource(t *testing.T, path string, exclusions ...resolution.Exclusion) *resolution.OLMEntitySource {
+	t.Helper()
+	c, err := catalog.LoadFBC(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, warning := range c.Warnings {
+		t.Fatalf("invalid fixture %s: %s: %s", path, warning.BundleID, warning.Message)
+	}
+	packageDatabase, err := store.OpenPackageDatabase("memory", "", testLogger())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		packageDatabase.Close()
+	})
+	if err := packageDatabase.ImportRepository(context.Background(), &store.RepositorySnapshot{
+		Repository: store.CachedRepository{RepositoryName: c.Name, RepositorySource: path},
+		Packages:   c.Packages,
+		Bundles:    c.Bundles,
+	}); err != nil {
+		t.Fatal(err)
+	}
+	return resolution.NewOLMEntitySource(packageDatabase, testLogger(), exclusions...)
+}
+
+func csvNames(bundles []store.CachedBundle) []string {
+	names := make([]string, 0, len(bundles))
+	for _, bundle := range bundles {
+		names = append(names, bundle.CsvName)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func TestConstraintVariables(t *testing.T) {
+	source := fixtureSource(t, "testdata/constraints.yaml")
+
+	for _, tt := range []struct {
+		name string
+		pkg  string
+		// dependencies lists the candidates of each dependency of the package's bundle
+		dependencies [][]string
+		conflicts    []string
+	}{
+		{
+			name:         "package",
+			pkg:          "needs-package",
+			dependencies: [][]string{{"etcd.v1.0.0", "etcd.v1.5.0"}},
+		},
+		{
+			name:         "gvk",
+			pkg:          "needs-gvk",
+			dependencies: [][]string{{"prometheus.v1.0.0"}},
+		},
+		{
+			name:         "cel",
+			pkg:          "needs-cel",
+			dependencies: [][]string{{"etcd.v1.5.0"}},
+		},
+		{
+			name:         "all with a nested not",
+			pkg:          "needs-all",
+			dependencies: [][]string{{"etcd.v1.5.0"}},
+		},
+		{
+			name:         "any",
+			pkg:          "needs-any",
+			dependencies: [][]string{{"etcd.v2.0.0", "prometheus.v1.0.0"}},
+		},
+		{
+			name:         "not",
+			pkg:          "needs-not",
+			dependencies: [][]string{{"etcd.v1.0.0", "etcd.v1.5.0", "etcd.v2.0.0"}},
+			conflicts:    []string{"etcd.v1.0.0", "prometheus.v1.0.0"},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			bundles, err := source.GetBundlesForPackage(ctx, tt.pkg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(bundles) != 1 {
+				t.Fatalf("expected a single bundle of package %s, got %d", tt.pkg, len(bundles))
+			}
+
+			variables, err := resolution.NewBundleVariableSource(bundles...).GetVariables(ctx, source)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var variable *resolution.BundleVariable
+			for _, v := range variables {
+				if bundleVariable, ok := v.(*resolution.BundleVariable); ok && bundleVariable.BundleID == bundles[0].BundleID {
+					variable = bundleVariable
+				}
+			}
+			if variable == nil {
+				t.Fatalf("no variable for bundle %s", bundles[0].BundleID)
+			}
+
+			var dependencies [][]string
+			for _, candidates := range variable.Dependencies() {
+				dependencies = append(dependencies, csvNames(candidates))
+			}
+			if !reflect.DeepEqual(dependencies, tt.dependencies) {
+				t.Errorf("expected dependencies %v, got %v", tt.dependencies, dependencies)
+			}
+			if conflicts := csvNames(variable.Conflicts()); !reflect.DeepEqual(conflicts, append([]string{}, tt.conflicts...)) {
+				t.Errorf("expected conflicts %v, got %v", tt.conflicts, conflicts)
+			}
+			// the bundles the variable refers to need variables of their own
+			for _, conflict := range variable.Conflicts() {
+				found := false
+				for _, v := range variables {
+					found = found || string(v.Identifier()) == conflict.BundleID
+				}
+				if !found {
+					t.Errorf("no variable for conflicting bundle %s", conflict.BundleID)
+				}
+			}
+		})
+	}
+}
+
+func TestConstraintCandidatesReadIndexedBundles(t *testing.T) {
+	etcdPackage := constraints.Constraint{Package: &constraints.PackageConstraint{PackageName: "etcd"}}
+	prometheusPackage := constraints.Constraint{Package: &constraints.PackageConstraint{PackageName: "prometheus"}}
+	etcdClusterGVK := constraints.Constraint{GVK: &constraints.GVKConstraint{Group: "etcd.database.coreos.com", Version: "v1beta2", Kind: "EtcdCluster"}}
+	certified := constraints.Constraint{Cel: &constraints.Cel{Rule: `properties.exists(p, p.type == "certified")`}}
+	allPackages := []string{"etcd", "needs-all", "needs-any", "needs-cel", "needs-gvk", "needs-not", "needs-package", "prometheus"}
+
+	for _, tt := range []struct {
+		name       string
+		constraint constraints.Constraint
+		candidates []string
+		// read are the packages whose bundles are read to find the candidates
+		read []string
+	}{
+		{
+			name:       "all with a package",
+			constraint: constraints.Constraint{All: &constraints.CompoundConstraint{Constraints: []constraints.Constraint{certified, etcdPackage}}},
+			candidates: []string{"etcd.v1.5.0"},
+			read:       []string{"etcd"},
+		},
+		{
+			name:       "any of a package and a gvk",
+			constraint: constraints.Constraint{Any: &constraints.CompoundConstraint{Constraints: []constraints.Constraint{prometheusPackage, etcdClusterGVK}}},
+			candidates: []string{"etcd.v1.0.0", "etcd.v1.5.0", "etcd.v2.0.0", "prometheus.v1.0.0"},
+			read:       []string{"etcd", "prometheus"},
+		},
+		{
+			name:       "any with a cel rule",
+			constraint: constraints.Constraint{Any: &constraints.CompoundConstraint{Constraints: []constraints.Constraint{prometheusPackage, certified}}},
+			candidates: []string{"etcd.v1.5.0", "prometheus.v1.0.0"},
+			read:       allPackages,
+		},
+		{
+			name:       "cel",
+			constraint: certified,
+			candidates: []string{"etcd.v1.5.0"},
+			read:       allPackages,
+		},
+		{
+			name:       "empty any",
+			constraint: constraints.Constraint{Any: &constraints.CompoundConstraint{}},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			read := map[string]struct{}{}
+			source := fixtureSource(t, "testdata/constraints.yaml", func(bundle *store.CachedBundle) (string, bool) {
+				read[bundle.PackageName] = struct{}{}
+				return "", false
+			})
+			bundle := store.CachedBundle{
+				BundleID:    "fixture/needs-constraint/stable/needs-constraint.v1.0.0",
+				Repository:  "fixture",
+				Bundle:      &api.Bundle{CsvName: "needs-constraint.v1.0.0", PackageName: "needs-constraint", ChannelName: "stable", Version: "1.0.0"},
+				Constraints: []constraints.Constraint{tt.constraint},
+			}
+
+			variables, err := resolution.NewBundleVariableSource(bundle).GetVariables(context.Background(), source)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var dependencies [][]store.CachedBundle
+			for _, v := range variables {
+				if bundleVariable, ok := v.(*resolution.BundleVariable); ok && bundleVariable.BundleID == bundle.BundleID {
+					dependencies = bundleVariable.Dependencies()
+				}
+			}
+			if len(dependencies) != 1 {
+				t.Fatalf("expected a single dependency, got %d", len(dependencies))
+			}
+			if candidates := csvNames(dependencies[0]); !reflect.DeepEqual(candidates, append([]string{}, tt.candidates...)) {
+				t.Errorf("expected candidates %v, got %v", tt.candidates, candidates)
+			}
+			var packages []string
+			for pkg := range read {
+				packages = append(packages, pkg)
+			}
+			sort.Strings(packages)
+			if !reflect.DeepEqual(packages, tt.read) {
+				t.Errorf("expected to read the bundles of %v, got %v", tt.read, packages)
+			}
+		})
+	}
+}
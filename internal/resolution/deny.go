@@ -0,0 +1,158 @@
+package resolution
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/blang/semver/v4"
+	"github.com/perdasilva/olmcli/internal/image"
+	"github.com/perdasilva/olmcli/internal/store"
+	"github.com/sirupsen/logrus"
+)
+
+// digestResolutionTimeout bounds looking up the digest of a bundle image referenced by tag
+const digestResolutionTimeout = 30 * time.Second
+
+// DenyRule blocks bundles from resolution, e.g. releases known to be broken. A rule matches a bundle by its
+// id, or by its package, a version range and the digest of its bundle image, whichever are set
+type DenyRule struct {
+	// Bundle is the id of the denied bundle, as listed by 'olm list bundle' and 'olm resolve'
+	Bundle string `mapstructure:"bundle" json:"bundle,omitempty"`
+	// Package denies the package's bundles within Version, a semver range, or all of them if Version is empty
+	Package string `mapstructure:"package" json:"package,omitempty"`
+	Version string `mapstructure:"version" json:"version,omitempty"`
+	// Digest denies the bundles whose image has this digest, e.g. sha256:0123... Images referenced by tag
+	// are matched by the digest the tag points to, which is only looked up for bundles of Package if set
+	Digest string `mapstructure:"digest" json:"digest,omitempty"`
+	// Reason is reported when the rule excludes a bundle
+	Reason string `mapstructure:"reason" json:"reason,omitempty"`
+}
+
+func (r *DenyRule) String() string {
+	var criteria []string
+	switch {
+	case r.Bundle != "":
+		return "bundle " + r.Bundle
+	case r.Version != "":
+		criteria = append(criteria, fmt.Sprintf("package %s %s", r.Package, r.Version))
+	case r.Package != "":
+		criteria = append(criteria, "package "+r.Package)
+	}
+	if r.Digest != "" {
+		criteria = append(criteria, "digest "+r.Digest)
+	}
+	return strings.Join(criteria, " ")
+}
+
+// DenyExclusion excludes the bundles matched by any of the rules. Digest rules look up the digests of bundle
+// images referenced by tag with resolveDigest. If a digest can't be looked up, e.g. offline, a warning is
+// logged and digest rules no longer match images referenced by tag, rather than excluding bundles that may
+// not be denied. Without resolveDigest, digest rules only match images referenced by digest
+func DenyExclusion(rules []DenyRule, resolveDigest image.DigestResolver, logger *logrus.Logger) (Exclusion, error) {
+	matchers := make([]denyMatcher, 0, len(rules))
+	for index, rule := range rules {
+		switch {
+		case rule.Bundle != "" && (rule.Package != "" || rule.Version != "" || rule.Digest != ""):
+			return nil, fmt.Errorf("invalid deny rule %d: a bundle can't be combined with a package, version or digest", index)
+		case rule.Bundle == "" && rule.Package == "" && rule.Digest == "":
+			return nil, fmt.Errorf("invalid deny rule %d: one of bundle, package or digest must be set", index)
+		case rule.Version != "" && rule.Package == "":
+			return nil, fmt.Errorf("invalid deny rule %d: a version range requires a package", index)
+		case rule.Digest != "" && !strings.Contains(rule.Digest, ":"):
+			return nil, fmt.Errorf("invalid deny rule %d: digest %q must be of the form <algorithm>:<hex>", index, rule.Digest)
+		}
+		matcher := denyMatcher{rule: rule}
+		if rule.Version != "" {
+			versionRange, err := semver.ParseRange(rule.Version)
+			if err != nil {
+				return nil, fmt.Errorf("invalid deny rule %d: invalid version range %q: %w", index, rule.Version, err)
+			}
+			matcher.versionRange = versionRange
+		}
+		matchers = append(matchers, matcher)
+	}
+
+	digests := &digestLookup{resolveDigest: resolveDigest, logger: logger}
+	return func(bundle *store.CachedBundle) (string, bool) {
+		for index := range matchers {
+			matcher := &matchers[index]
+			if !matcher.matches(bundle, digests) {
+				continue
+			}
+			if matcher.rule.Reason != "" {
+				return fmt.Sprintf("denied by %s: %s", &matcher.rule, matcher.rule.Reason), true
+			}
+			return fmt.Sprintf("denied by %s", &matcher.rule), true
+		}
+		return "", false
+	}, nil
+}
+
+type denyMatcher struct {
+	rule         DenyRule
+	versionRange semver.Range
+}
+
+// matches checks the bundle against the rule's criteria, looking up the digest of its image last
+func (m *denyMatcher) matches(bundle *store.CachedBundle, digests *digestLookup) bool {
+	if m.rule.Bundle != "" {
+		return bundle.BundleID == m.rule.Bundle
+	}
+	if m.rule.Package != "" && bundle.PackageName != m.rule.Package {
+		return false
+	}
+	if m.versionRange != nil {
+		version, err := semver.Parse(bundle.Version)
+		if err != nil || !m.versionRange(version) {
+			return false
+		}
+	}
+	if m.rule.Digest == "" {
+		return true
+	}
+	digest, ok := digests.digestOf(bundle)
+	return ok && digest == m.rule.Digest
+}
+
+// digestLookup looks up the digests of bundle images until a lookup fails
+type digestLookup struct {
+	resolveDigest image.DigestResolver
+	logger        *logrus.Logger
+
+	lock   sync.Mutex
+	failed bool
+}
+
+func (d *digestLookup) digestOf(bundle *store.CachedBundle) (string, bool) {
+	bundlePath := bundle.GetBundlePath()
+	if bundlePath == "" {
+		return "", false
+	}
+	if digest := image.Digest(bundlePath); digest != "" {
+		return digest, true
+	}
+	if d.resolveDigest == nil {
+		return "", false
+	}
+
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	if d.failed {
+		return "", false
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), digestResolutionTimeout)
+	defer cancel()
+	digest, err := d.resolveDigest(ctx, bundlePath)
+	if err != nil {
+		// every other lookup would likely fail the same way, each waiting for the timeout
+		d.failed = true
+		if d.logger != nil {
+			d.logger.Warnf("Cannot verify the deny list: %s, digest rules won't match bundle images referenced by tag", err)
+		}
+		return "", false
+	}
+	return digest, true
+}
@@ -0,0 +1,165 @@
+package resolution_test
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/operator-framework/operator-registry/pkg/api"
+	"github.com/perdasilva/olmcli/internal/image"
+	"github.com/perdasilva/olmcli/internal/resolution"
+	"github.com/perdasilva/olmcli/internal/store"
+)
+
+func TestDenyExclusionDigest(t *testing.T) {
+	const denied = "sha256:" + "0000000000000000000000000000000000000000000000000000000000000000"
+	const allowed = "sha256:" + "1111111111111111111111111111111111111111111111111111111111111111"
+	tags := map[string]string{
+		"quay.io/fixture/denied-bundle:v1":  denied,
+		"quay.io/fixture/allowed-bundle:v1": allowed,
+	}
+	var registry image.DigestResolver = func(_ context.Context, imageRef string) (string, error) {
+		if digest, ok := tags[imageRef]; ok {
+			return digest, nil
+		}
+		return "", fmt.Errorf("image %s not found", imageRef)
+	}
+
+	for _, tt := range []struct {
+		name          string
+		bundlePath    string
+		resolveDigest image.DigestResolver
+		denied        bool
+	}{
+		{name: "referenced by denied digest", bundlePath: "quay.io/fixture/bundle@" + denied, resolveDigest: registry, denied: true},
+		{name: "referenced by other digest", bundlePath: "quay.io/fixture/bundle@" + allowed, resolveDigest: registry},
+		{name: "tag of denied digest", bundlePath: "quay.io/fixture/denied-bundle:v1", resolveDigest: registry, denied: true},
+		{name: "tag of other digest", bundlePath: "quay.io/fixture/allowed-bundle:v1", resolveDigest: registry},
+		{name: "tag that can't be resolved", bundlePath: "quay.io/fixture/unknown-bundle:v1", resolveDigest: registry},
+		{name: "tag without digest resolver", bundlePath: "quay.io/fixture/denied-bundle:v1"},
+		{name: "referenced by denied digest without digest resolver", bundlePath: "quay.io/fixture/bundle@" + denied, denied: true},
+		{name: "no bundle image", resolveDigest: registry},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			exclusion, err := resolution.DenyExclusion([]resolution.DenyRule{{Digest: denied, Reason: "broken release"}}, tt.resolveDigest, testLogger())
+			if err != nil {
+				t.Fatal(err)
+			}
+			bundle := &store.CachedBundle{BundleID: "fixture/bundle/stable/bundle.v1.0.0", Bundle: &api.Bundle{BundlePath: tt.bundlePath}}
+			reason, excluded := exclusion(bundle)
+			if excluded != tt.denied {
+				t.Errorf("expected denied to be %t, got %t (%s)", tt.denied, excluded, reason)
+			}
+		})
+	}
+}
+
+func TestDenyExclusionDigestLookups(t *testing.T) {
+	const denied = "sha256:" + "0000000000000000000000000000000000000000000000000000000000000000"
+	const allowed = "sha256:" + "1111111111111111111111111111111111111111111111111111111111111111"
+	bundle := func(packageName string, version string) *store.CachedBundle {
+		return &store.CachedBundle{
+			BundleID: fmt.Sprintf("fixture/%s/stable/%s.v%s", packageName, packageName, version),
+			Bundle: &api.Bundle{
+				PackageName: packageName,
+				Version:     version,
+				BundlePath:  fmt.Sprintf("quay.io/fixture/%s-bundle:v%s", packageName, version),
+			},
+		}
+	}
+	bundles := []*store.CachedBundle{bundle("etcd", "1.0.0"), bundle("etcd", "2.0.0"), bundle("vault", "1.0.0")}
+
+	for _, tt := range []struct {
+		name string
+		rule resolution.DenyRule
+		// offline fails every lookup
+		offline bool
+		denied  []string
+		lookups []string
+	}{
+		{
+			name:    "unscoped digest rule",
+			rule:    resolution.DenyRule{Digest: denied},
+			denied:  []string{"etcd"},
+			lookups: []string{"etcd", "etcd", "vault"},
+		},
+		{
+			name:    "package scoped digest rule",
+			rule:    resolution.DenyRule{Package: "etcd", Digest: denied},
+			denied:  []string{"etcd"},
+			lookups: []string{"etcd", "etcd"},
+		},
+		{
+			name:    "version scoped digest rule",
+			rule:    resolution.DenyRule{Package: "etcd", Version: ">=2.0.0", Digest: denied},
+			denied:  []string{"etcd"},
+			lookups: []string{"etcd"},
+		},
+		{
+			name:    "offline looks up once and denies nothing",
+			rule:    resolution.DenyRule{Digest: denied},
+			offline: true,
+			lookups: []string{"etcd"},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			var lookups []string
+			resolveDigest := func(_ context.Context, imageRef string) (string, error) {
+				packageName := strings.SplitN(strings.TrimPrefix(imageRef, "quay.io/fixture/"), "-bundle:", 2)[0]
+				lookups = append(lookups, packageName)
+				if tt.offline {
+					return "", fmt.Errorf("no route to quay.io")
+				}
+				if packageName == "etcd" {
+					return denied, nil
+				}
+				return allowed, nil
+			}
+			exclusion, err := resolution.DenyExclusion([]resolution.DenyRule{tt.rule}, resolveDigest, testLogger())
+			if err != nil {
+				t.Fatal(err)
+			}
+			deniedPackages := map[string]struct{}{}
+			for _, bundle := range bundles {
+				if _, excluded := exclusion(bundle); excluded {
+					deniedPackages[bundle.PackageName] = struct{}{}
+				}
+			}
+			if len(deniedPackages) != len(tt.denied) {
+				t.Errorf("expected packages %v to be denied, got %v", tt.denied, deniedPackages)
+			}
+			for _, packageName := range tt.denied {
+				if _, ok := deniedPackages[packageName]; !ok {
+					t.Errorf("expected package %s to be denied", packageName)
+				}
+			}
+			if !reflect.DeepEqual(lookups, tt.lookups) {
+				t.Errorf("expected digest lookups for %v, got %v", tt.lookups, lookups)
+			}
+		})
+	}
+}
+
+func TestDenyExclusionInvalidRules(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		rule resolution.DenyRule
+		err  string
+	}{
+		{name: "empty rule", rule: resolution.DenyRule{Reason: "broken"}, err: "one of bundle, package or digest must be set"},
+		{name: "bundle and package", rule: resolution.DenyRule{Bundle: "fixture/etcd/stable/etcd.v1.0.0", Package: "etcd"}, err: "a bundle can't be combined"},
+		{name: "bundle and digest", rule: resolution.DenyRule{Bundle: "fixture/etcd/stable/etcd.v1.0.0", Digest: "sha256:00"}, err: "a bundle can't be combined"},
+		{name: "version without package", rule: resolution.DenyRule{Version: "<1.0.0", Digest: "sha256:00"}, err: "a version range requires a package"},
+		{name: "malformed digest", rule: resolution.DenyRule{Package: "etcd", Digest: "00"}, err: "must be of the form"},
+		{name: "invalid version range", rule: resolution.DenyRule{Package: "etcd", Version: "latest"}, err: "invalid version range"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := resolution.DenyExclusion([]resolution.DenyRule{tt.rule}, nil, testLogger())
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("expected error %q, got %v", tt.err, err)
+			}
+		})
+	}
+}
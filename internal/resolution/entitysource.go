@@ -5,19 +5,95 @@ import (
 
 	"github.com/operator-framework/deppy/pkg/v2"
 	"github.com/perdasilva/olmcli/internal/store"
+	"github.com/sirupsen/logrus"
 )
 
 var _ v2.EntitySource[*store.CachedBundle] = &OLMEntitySource{}
 
+// Exclusion returns the reason a bundle must not be considered for resolution, if any
+type Exclusion func(bundle *store.CachedBundle) (string, bool)
+
+// OLMEntitySource serves bundles from the package database, hiding the bundles rejected by its exclusions
 type OLMEntitySource struct {
 	store.PackageDatabase
+	exclusions []Exclusion
+	excluded   map[string]string
+	logger     *logrus.Logger
+}
+
+func NewOLMEntitySource(packageDB store.PackageDatabase, logger *logrus.Logger, exclusions ...Exclusion) *OLMEntitySource {
+	return &OLMEntitySource{
+		PackageDatabase: packageDB,
+		exclusions:      exclusions,
+		excluded:        map[string]string{},
+		logger:          logger,
+	}
+}
+
+// Excluded returns the reasons candidate bundles were excluded, keyed by bundle id
+func (s *OLMEntitySource) Excluded() map[string]string {
+	return s.excluded
+}
+
+func (s *OLMEntitySource) GetBundlesForPackage(ctx context.Context, packageName string, options ...store.PackageSearchOption) ([]store.CachedBundle, error) {
+	bundles, err := s.PackageDatabase.GetBundlesForPackage(ctx, packageName, options...)
+	if err != nil {
+		return nil, err
+	}
+	return s.filter(bundles), nil
+}
+
+func (s *OLMEntitySource) ListBundlesForGVK(ctx context.Context, group string, version string, kind string) ([]store.CachedBundle, error) {
+	bundles, err := s.PackageDatabase.ListBundlesForGVK(ctx, group, version, kind)
+	if err != nil {
+		return nil, err
+	}
+	return s.filter(bundles), nil
+}
+
+func (s *OLMEntitySource) IterateBundles(ctx context.Context, fn func(bundle *store.CachedBundle) error) error {
+	return s.PackageDatabase.IterateBundles(ctx, func(bundle *store.CachedBundle) error {
+		if s.isExcluded(bundle) {
+			return nil
+		}
+		return fn(bundle)
+	})
+}
+
+func (s *OLMEntitySource) filter(bundles []store.CachedBundle) []store.CachedBundle {
+	if len(s.exclusions) == 0 {
+		return bundles
+	}
+	kept := bundles[:0]
+	for index := range bundles {
+		if !s.isExcluded(&bundles[index]) {
+			kept = append(kept, bundles[index])
+		}
+	}
+	return kept
+}
+
+func (s *OLMEntitySource) isExcluded(bundle *store.CachedBundle) bool {
+	if _, ok := s.excluded[bundle.BundleID]; ok {
+		return true
+	}
+	for _, exclusion := range s.exclusions {
+		if reason, excluded := exclusion(bundle); excluded {
+			s.excluded[bundle.BundleID] = reason
+			if s.logger != nil {
+				s.logger.Debugf("Excluding %s: %s", bundle.BundleID, reason)
+			}
+			return true
+		}
+	}
+	return false
 }
 
-func (s OLMEntitySource) ID() v2.EntitySourceID {
+func (s *OLMEntitySource) ID() v2.EntitySourceID {
 	return "packageManager"
 }
 
-func (s OLMEntitySource) Get(ctx context.Context, id v2.EntityID) (*store.CachedBundle, error) {
+func (s *OLMEntitySource) Get(ctx context.Context, id v2.EntityID) (*store.CachedBundle, error) {
 	bundle, err := s.GetBundle(ctx, string(id))
 	if err != nil {
 		return nil, err
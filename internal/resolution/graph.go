@@ -0,0 +1,181 @@
+package resolution
+
+import (
+	"sort"
+
+	"github.com/blang/semver/v4"
+	"github.com/perdasilva/olmcli/internal/store"
+)
+
+// ChannelGraph is the upgrade graph of a package channel. A bundle can be upgraded to the bundles that
+// replace it, skip it, or whose skip range includes its version
+type ChannelGraph struct {
+	bundles  map[string]*store.CachedBundle
+	upgrades map[string]map[string]struct{}
+}
+
+// NewChannelGraph builds the upgrade graph of the given bundles, which must belong to the same package channel
+func NewChannelGraph(bundles []store.CachedBundle) *ChannelGraph {
+	graph := &ChannelGraph{
+		bundles:  map[string]*store.CachedBundle{},
+		upgrades: map[string]map[string]struct{}{},
+	}
+	for index := range bundles {
+		graph.bundles[bundles[index].CsvName] = &bundles[index]
+	}
+
+	addEdge := func(from string, to string) {
+		if from == to {
+			return
+		}
+		if _, ok := graph.upgrades[from]; !ok {
+			graph.upgrades[from] = map[string]struct{}{}
+		}
+		graph.upgrades[from][to] = struct{}{}
+	}
+	for _, bundle := range graph.bundles {
+		if bundle.Replaces != "" {
+			addEdge(bundle.Replaces, bundle.CsvName)
+		}
+		for _, skip := range bundle.Skips {
+			addEdge(skip, bundle.CsvName)
+		}
+		if bundle.SkipRange == "" {
+			continue
+		}
+		skipRange, err := semver.ParseRange(bundle.SkipRange)
+		if err != nil {
+			continue
+		}
+		for _, other := range graph.bundles {
+			if version, err := semver.Parse(other.Version); err == nil && skipRange(version) {
+				addEdge(other.CsvName, bundle.CsvName)
+			}
+		}
+	}
+	return graph
+}
+
+// Bundle returns the bundle with the given csv name, or nil if it isn't in the channel
+func (g *ChannelGraph) Bundle(csvName string) *store.CachedBundle {
+	return g.bundles[csvName]
+}
+
+// Bundles returns the bundles of the channel, highest version first
+func (g *ChannelGraph) Bundles() []store.CachedBundle {
+	bundles := make([]store.CachedBundle, 0, len(g.bundles))
+	for _, bundle := range g.bundles {
+		bundles = append(bundles, *bundle)
+	}
+	sortByVersionDecreasing(bundles)
+	return bundles
+}
+
+// Heads returns the bundles that can't be upgraded to any other bundle of the channel, highest version first.
+// A well formed channel has a single head
+func (g *ChannelGraph) Heads() []store.CachedBundle {
+	var heads []store.CachedBundle
+	for csvName, bundle := range g.bundles {
+		if len(g.UpgradesFrom(csvName)) == 0 {
+			heads = append(heads, *bundle)
+		}
+	}
+	sortByVersionDecreasing(heads)
+	return heads
+}
+
+// UpgradesFrom returns the bundles of the channel the given csv can be directly upgraded to, highest version first.
+// The csv doesn't need to be in the channel, e.g. when it was installed from a bundle that has since been removed
+func (g *ChannelGraph) UpgradesFrom(csvName string) []store.CachedBundle {
+	var upgrades []store.CachedBundle
+	for to := range g.upgrades[csvName] {
+		if bundle, ok := g.bundles[to]; ok {
+			upgrades = append(upgrades, *bundle)
+		}
+	}
+	sortByVersionDecreasing(upgrades)
+	return upgrades
+}
+
+// LatestFrom returns the highest version bundle reachable by upgrades from the given csv, or nil if
+// the csv can't be upgraded. A lower direct upgrade may lead to a higher version than the highest one
+func (g *ChannelGraph) LatestFrom(csvName string) *store.CachedBundle {
+	visited := map[string]struct{}{csvName: {}}
+	var reachable []store.CachedBundle
+	for queue := []string{csvName}; len(queue) > 0; queue = queue[1:] {
+		for _, upgrade := range g.UpgradesFrom(queue[0]) {
+			if _, ok := visited[upgrade.CsvName]; ok {
+				continue
+			}
+			visited[upgrade.CsvName] = struct{}{}
+			reachable = append(reachable, upgrade)
+			queue = append(queue, upgrade.CsvName)
+		}
+	}
+	if len(reachable) == 0 {
+		return nil
+	}
+	sortByVersionDecreasing(reachable)
+	return &reachable[0]
+}
+
+// MissingReplaces returns the csvs replaced by bundles of the channel that aren't part of the channel, keyed by
+// the csv name of the replacing bundle
+func (g *ChannelGraph) MissingReplaces() map[string]string {
+	missing := map[string]string{}
+	for csvName, bundle := range g.bundles {
+		if bundle.Replaces == "" {
+			continue
+		}
+		if _, ok := g.bundles[bundle.Replaces]; !ok {
+			missing[csvName] = bundle.Replaces
+		}
+	}
+	return missing
+}
+
+// ReplacesCycles returns the csv names of each cycle in the channel's replaces chains
+func (g *ChannelGraph) ReplacesCycles() [][]string {
+	var cycles [][]string
+	inCycle := map[string]struct{}{}
+	csvNames := make([]string, 0, len(g.bundles))
+	for csvName := range g.bundles {
+		csvNames = append(csvNames, csvName)
+	}
+	sort.Strings(csvNames)
+
+	for _, start := range csvNames {
+		position := map[string]int{}
+		var chain []string
+		for current := start; current != ""; {
+			if _, ok := inCycle[current]; ok {
+				break
+			}
+			if index, ok := position[current]; ok {
+				cycle := chain[index:]
+				for _, csvName := range cycle {
+					inCycle[csvName] = struct{}{}
+				}
+				cycles = append(cycles, cycle)
+				break
+			}
+			bundle, ok := g.bundles[current]
+			if !ok {
+				break
+			}
+			position[current] = len(chain)
+			chain = append(chain, current)
+			current = bundle.Replaces
+		}
+	}
+	return cycles
+}
+
+func sortByVersionDecreasing(bundles []store.CachedBundle) {
+	Sort(bundles, func(e1 *store.CachedBundle, e2 *store.CachedBundle) bool {
+		if c := compareVersions(e1.Version, e2.Version); c != 0 {
+			return c > 0
+		}
+		return e1.CsvName < e2.CsvName
+	})
+}
@@ -0,0 +1,72 @@
+package resolution_test
+
+import (
+	"testing"
+
+	"github.com/operator-framework/operator-registry/pkg/api"
+	"github.com/perdasilva/olmcli/internal/resolution"
+	"github.com/perdasilva/olmcli/internal/store"
+)
+
+func graphBundle(csvName string, version string, replaces string, skips ...string) store.CachedBundle {
+	return store.CachedBundle{
+		BundleID: csvName,
+		Bundle:   &api.Bundle{CsvName: csvName, Version: version, Replaces: replaces, Skips: skips},
+	}
+}
+
+func TestChannelGraphLatestFrom(t *testing.T) {
+	for _, tt := range []struct {
+		name     string
+		bundles  []store.CachedBundle
+		from     string
+		expected string
+	}{
+		{
+			name:     "replaces chain",
+			bundles:  []store.CachedBundle{graphBundle("a", "1.0.0", ""), graphBundle("b", "1.1.0", "a"), graphBundle("c", "1.2.0", "b")},
+			from:     "a",
+			expected: "c",
+		},
+		{
+			name: "higher version through a lower upgrade",
+			bundles: []store.CachedBundle{
+				graphBundle("a", "1.0.0", ""),
+				graphBundle("b", "2.0.0", "a"),
+				graphBundle("c", "1.5.0", "", "a"),
+				graphBundle("d", "3.0.0", "c"),
+			},
+			from:     "a",
+			expected: "d",
+		},
+		{
+			name:     "cycle back to the csv",
+			bundles:  []store.CachedBundle{graphBundle("a", "1.0.0", "b"), graphBundle("b", "1.1.0", "a")},
+			from:     "a",
+			expected: "b",
+		},
+		{
+			name:    "head",
+			bundles: []store.CachedBundle{graphBundle("a", "1.0.0", ""), graphBundle("b", "1.1.0", "a")},
+			from:    "b",
+		},
+		{
+			name:     "csv removed from the channel",
+			bundles:  []store.CachedBundle{graphBundle("b", "1.1.0", "a"), graphBundle("c", "1.2.0", "b")},
+			from:     "a",
+			expected: "c",
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			latest := resolution.NewChannelGraph(tt.bundles).LatestFrom(tt.from)
+			switch {
+			case tt.expected == "" && latest != nil:
+				t.Errorf("expected no upgrade, got %s", latest.CsvName)
+			case tt.expected != "" && latest == nil:
+				t.Errorf("expected %s, got no upgrade", tt.expected)
+			case latest != nil && latest.CsvName != tt.expected:
+				t.Errorf("expected %s, got %s", tt.expected, latest.CsvName)
+			}
+		})
+	}
+}
@@ -0,0 +1,79 @@
+package resolution
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/blang/semver/v4"
+	"github.com/perdasilva/olmcli/internal/store"
+)
+
+// Hold keeps a package within a version range: resolution never picks the package's bundles outside the range
+type Hold struct {
+	Package string
+	// Version is a semver range, a single version pins the package to that version
+	Version      string
+	versionRange semver.Range
+}
+
+func NewHold(packageName string, versionRange string) (*Hold, error) {
+	r, err := semver.ParseRange(versionRange)
+	if err != nil {
+		return nil, fmt.Errorf("invalid hold version range %q for package %s: %w", versionRange, packageName, err)
+	}
+	return &Hold{
+		Package:      packageName,
+		Version:      versionRange,
+		versionRange: r,
+	}, nil
+}
+
+// Allows returns true if the version is within the hold's range. Invalid versions are never allowed
+func (h *Hold) Allows(version string) bool {
+	v, err := semver.Parse(version)
+	return err == nil && h.versionRange(v)
+}
+
+func (h *Hold) String() string {
+	return fmt.Sprintf("%s is held at %s", h.Package, h.Version)
+}
+
+// HoldSource provides the package holds at resolution time, e.g. from the installed bundle deployments
+type HoldSource func(ctx context.Context) ([]Hold, error)
+
+// HoldExclusion excludes the bundles of held packages whose version is outside the hold's range
+func HoldExclusion(holds []Hold) Exclusion {
+	holdsByPackage := map[string]*Hold{}
+	for index := range holds {
+		holdsByPackage[holds[index].Package] = &holds[index]
+	}
+	return func(bundle *store.CachedBundle) (string, bool) {
+		hold, ok := holdsByPackage[bundle.PackageName]
+		if !ok || hold.Allows(bundle.Version) {
+			return "", false
+		}
+		return fmt.Sprintf("package %s", hold), true
+	}
+}
+
+// holdsError explains a resolution failure in which held packages had bundles excluded, since the holds are
+// likely the cause of the failure
+func holdsError(err error, holds []Hold, excluded map[string]string) error {
+	excludedCounts := map[string]int{}
+	for _, reason := range excluded {
+		excludedCounts[reason]++
+	}
+	var details []string
+	for index := range holds {
+		if count := excludedCounts[fmt.Sprintf("package %s", &holds[index])]; count > 0 {
+			details = append(details, fmt.Sprintf("%s (%d bundles excluded)", &holds[index], count))
+		}
+	}
+	if len(details) == 0 {
+		return err
+	}
+	sort.Strings(details)
+	return fmt.Errorf("%w: resolution was constrained by held packages: %s", err, strings.Join(details, ", "))
+}
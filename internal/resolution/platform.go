@@ -0,0 +1,78 @@
+package resolution
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/blang/semver/v4"
+	"github.com/perdasilva/olmcli/internal/store"
+)
+
+// maxOpenShiftVersionProperty caps the OpenShift minor version a bundle can be installed on
+const maxOpenShiftVersionProperty = "olm.maxOpenShiftVersion"
+
+// PlatformVersion is the version of the cluster bundles are resolved for
+type PlatformVersion struct {
+	// KubeVersion is the Kubernetes version of the cluster
+	KubeVersion *semver.Version
+	// OpenShiftVersion is the OpenShift version of the cluster, nil if the cluster isn't an OpenShift cluster
+	OpenShiftVersion *semver.Version
+}
+
+func (p *PlatformVersion) String() string {
+	if p.OpenShiftVersion != nil {
+		return fmt.Sprintf("kubernetes %s, openshift %s", p.KubeVersion, p.OpenShiftVersion)
+	}
+	return fmt.Sprintf("kubernetes %s", p.KubeVersion)
+}
+
+// PlatformVersionSource provides the platform version at resolution time, e.g. from the cluster
+type PlatformVersionSource func(ctx context.Context) (*PlatformVersion, error)
+
+// PlatformExclusion excludes bundles whose csv minKubeVersion is higher than the platform's
+// Kubernetes version, or whose olm.maxOpenShiftVersion is lower than the platform's OpenShift minor version
+func PlatformExclusion(platform *PlatformVersion) Exclusion {
+	return func(bundle *store.CachedBundle) (string, bool) {
+		if platform.KubeVersion != nil && bundle.Metadata != nil && bundle.Metadata.MinKubeVersion != "" {
+			minKubeVersion, err := semver.ParseTolerant(bundle.Metadata.MinKubeVersion)
+			if err != nil {
+				return fmt.Sprintf("invalid minKubeVersion %q", bundle.Metadata.MinKubeVersion), true
+			}
+			if platform.KubeVersion.LT(minKubeVersion) {
+				return fmt.Sprintf("requires kubernetes %s or later, cluster runs %s", minKubeVersion, platform.KubeVersion), true
+			}
+		}
+		if platform.OpenShiftVersion != nil {
+			for _, prop := range bundle.GetProperties() {
+				if prop.GetType() != maxOpenShiftVersionProperty {
+					continue
+				}
+				maxOpenShiftVersion, err := parseMaxOpenShiftVersion(prop.GetValue())
+				if err != nil {
+					return fmt.Sprintf("invalid %s %s", maxOpenShiftVersionProperty, prop.GetValue()), true
+				}
+				// only the major and minor versions are compared, a 4.8 maximum allows any 4.8.z cluster
+				clusterVersion := semver.Version{Major: platform.OpenShiftVersion.Major, Minor: platform.OpenShiftVersion.Minor}
+				if clusterVersion.GT(maxOpenShiftVersion) {
+					return fmt.Sprintf("supports openshift up to %d.%d, cluster runs %s", maxOpenShiftVersion.Major, maxOpenShiftVersion.Minor, platform.OpenShiftVersion), true
+				}
+			}
+		}
+		return "", false
+	}
+}
+
+// parseMaxOpenShiftVersion parses olm.maxOpenShiftVersion values, which are json strings
+// (or numbers) holding a major.minor version
+func parseMaxOpenShiftVersion(value string) (semver.Version, error) {
+	var version interface{}
+	if err := json.Unmarshal([]byte(value), &version); err != nil {
+		return semver.Version{}, err
+	}
+	parsed, err := semver.ParseTolerant(fmt.Sprint(version))
+	if err != nil {
+		return semver.Version{}, err
+	}
+	return semver.Version{Major: parsed.Major, Minor: parsed.Minor}, nil
+}
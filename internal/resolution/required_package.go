@@ -76,6 +76,10 @@ func (r *RequiredPackage) GetVariables(ctx context.Context, source *OLMEntitySou
 	return []OLMVariable{NewRequiredPackageVariable(r.getVariableID(), bundles...)}, nil
 }
 
+func (r *RequiredPackage) String() string {
+	return fmt.Sprintf("%s (repository %s, channel %s, version %s)", r.packageName, r.repositoryName, r.channelName, r.versionRange)
+}
+
 func (r *RequiredPackage) getVariableID() sat.Identifier {
 	return sat.Identifier(fmt.Sprintf("required package %s from repository %s, channel %s, in semver range %s", r.packageName, r.repositoryName, r.channelName, r.versionRange))
 }
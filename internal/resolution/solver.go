@@ -2,6 +2,8 @@ package resolution
 
 import (
 	"context"
+	"fmt"
+	"sort"
 
 	v2 "github.com/operator-framework/deppy/pkg/v2"
 	"github.com/perdasilva/olmcli/internal/store"
@@ -29,31 +31,149 @@ func byTopology(i1 *Installable, i2 *Installable) bool {
 }
 
 type OLMSolver struct {
-	olmEntitySource *OLMEntitySource
-	logger          *logrus.Logger
+	packageDB             store.PackageDatabase
+	platformVersionSource PlatformVersionSource
+	holdSource            HoldSource
+	exclusions            []Exclusion
+	logger                *logrus.Logger
 }
 
-func NewOLMSolver(packageDB store.PackageDatabase, logger *logrus.Logger) *OLMSolver {
-	return &OLMSolver{
-		olmEntitySource: &OLMEntitySource{
-			packageDB,
-		},
-		logger: logger,
+type SolverOption func(solver *OLMSolver)
+
+// WithPlatformVersionSource excludes bundles that are incompatible with the platform version
+// provided by source. If the source fails, resolution proceeds without the compatibility checks
+func WithPlatformVersionSource(source PlatformVersionSource) SolverOption {
+	return func(solver *OLMSolver) {
+		solver.platformVersionSource = source
 	}
 }
 
-func (s *OLMSolver) Solve(ctx context.Context, requiredPackages ...*RequiredPackage) ([]Installable, error) {
-	variableSource, err := OLMVariableSource(requiredPackages, s.logger)
+// WithHoldSource keeps held packages within their hold's version range. If the source fails,
+// resolution fails, so held packages are never moved
+func WithHoldSource(source HoldSource) SolverOption {
+	return func(solver *OLMSolver) {
+		solver.holdSource = source
+	}
+}
+
+// WithExclusions hides the bundles rejected by the exclusions from resolution, e.g. a deny list
+func WithExclusions(exclusions ...Exclusion) SolverOption {
+	return func(solver *OLMSolver) {
+		solver.exclusions = append(solver.exclusions, exclusions...)
+	}
+}
+
+func NewOLMSolver(packageDB store.PackageDatabase, logger *logrus.Logger, options ...SolverOption) *OLMSolver {
+	solver := &OLMSolver{
+		packageDB: packageDB,
+		logger:    logger,
+	}
+	for _, opt := range options {
+		opt(solver)
+	}
+	return solver
+}
+
+func (s *OLMSolver) holds(ctx context.Context) ([]Hold, error) {
+	if s.holdSource == nil {
+		return nil, nil
+	}
+	holds, err := s.holdSource(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error reading package holds: %w", err)
+	}
+	for _, hold := range holds {
+		s.logger.Debugf("Package %s", &hold)
+	}
+	return holds, nil
+}
+
+// activeExclusions returns the configured exclusions, followed by the holds and the platform exclusion
+func (s *OLMSolver) activeExclusions(ctx context.Context, holds []Hold) []Exclusion {
+	exclusions := append([]Exclusion(nil), s.exclusions...)
+	if len(holds) > 0 {
+		exclusions = append(exclusions, HoldExclusion(holds))
+	}
+	if s.platformVersionSource != nil {
+		platform, err := s.platformVersionSource(ctx)
+		switch {
+		case err != nil:
+			s.logger.Warnf("Skipping cluster version compatibility checks: %s", err)
+		case platform != nil:
+			s.logger.Debugf("Resolving for %s", platform)
+			exclusions = append(exclusions, PlatformExclusion(platform))
+		}
+	}
+	return exclusions
+}
+
+// EntitySource returns the bundles the solver resolves from, without the bundles its exclusions, the package
+// holds and the platform version rule out
+func (s *OLMSolver) EntitySource(ctx context.Context) (*OLMEntitySource, error) {
+	holds, err := s.holds(ctx)
 	if err != nil {
 		return nil, err
 	}
-	deppySolver, err := v2.NewDeppySolver[*store.CachedBundle, OLMVariable, *OLMEntitySource](s.olmEntitySource, variableSource)
+	return s.entitySource(ctx, holds), nil
+}
+
+func (s *OLMSolver) entitySource(ctx context.Context, holds []Hold) *OLMEntitySource {
+	return NewOLMEntitySource(s.packageDB, s.logger, s.activeExclusions(ctx, holds)...)
+}
+
+// Dependencies returns the candidates of each dependency of the bundle, most preferred first, among the
+// bundles that aren't excluded from resolution. Dependencies no bundle satisfies have no candidates
+func (s *OLMSolver) Dependencies(ctx context.Context, bundle store.CachedBundle) ([][]store.CachedBundle, error) {
+	olmEntitySource, err := s.EntitySource(ctx)
 	if err != nil {
 		return nil, err
 	}
+	dependencies, _, err := NewBundleVariableSource().bundleDependencies(ctx, olmEntitySource, &bundle)
+	return dependencies, err
+}
+
+// ExcludedBundle is a candidate bundle hidden from resolution
+type ExcludedBundle struct {
+	BundleID string
+	Reason   string
+}
+
+func (s *OLMSolver) Solve(ctx context.Context, requiredPackages ...*RequiredPackage) ([]Installable, error) {
+	installables, _, err := s.SolveWithExclusions(ctx, requiredPackages...)
+	return installables, err
+}
+
+// SolveWithExclusions solves like Solve, and also returns the candidate bundles that were excluded from
+// resolution and why, ordered by bundle id. They are returned even if resolution fails, since they often
+// explain the failure
+func (s *OLMSolver) SolveWithExclusions(ctx context.Context, requiredPackages ...*RequiredPackage) ([]Installable, []ExcludedBundle, error) {
+	holds, err := s.holds(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	olmEntitySource := s.entitySource(ctx, holds)
+	excluded := func() []ExcludedBundle {
+		var excludedBundles []ExcludedBundle
+		for bundleID, reason := range olmEntitySource.Excluded() {
+			excludedBundles = append(excludedBundles, ExcludedBundle{BundleID: bundleID, Reason: reason})
+		}
+		sort.Slice(excludedBundles, func(i, j int) bool {
+			return excludedBundles[i].BundleID < excludedBundles[j].BundleID
+		})
+		return excludedBundles
+	}
+
+	variableSource, err := OLMVariableSource(requiredPackages, s.logger)
+	if err != nil {
+		return nil, nil, err
+	}
+	deppySolver, err := v2.NewDeppySolver[*store.CachedBundle, OLMVariable, *OLMEntitySource](olmEntitySource, variableSource)
+	if err != nil {
+		return nil, nil, err
+	}
 	solution, err := deppySolver.Solve(ctx)
 	if err != nil {
-		return nil, err
+		return nil, excluded(), holdsError(err, holds, olmEntitySource.Excluded())
 	}
 
 	selectedVariables := map[string]*BundleVariable{}
@@ -77,5 +197,5 @@ func (s *OLMSolver) Solve(ctx context.Context, requiredPackages ...*RequiredPack
 		})
 	}
 	Sort(installables, byTopology)
-	return installables, nil
+	return installables, excluded(), nil
 }
@@ -2,6 +2,7 @@ package resolution
 
 import (
 	"sort"
+	"strings"
 
 	"github.com/blang/semver/v4"
 	"github.com/perdasilva/olmcli/internal/store"
@@ -47,11 +48,27 @@ func ByChannelAndVersion(e1 *store.CachedBundle, e2 *store.CachedBundle) bool {
 		return e1.ChannelName < e2.ChannelName
 	}
 
-	return semver.MustParse(e1.Version).GT(semver.MustParse(e2.Version))
+	return compareVersions(e1.Version, e2.Version) > 0
 }
 
 var _ Comparable[store.CachedBundle] = ByVersionIncreasing
 
 func ByVersionIncreasing(e1 *store.CachedBundle, e2 *store.CachedBundle) bool {
-	return semver.MustParse(e1.Version).LT(semver.MustParse(e2.Version))
+	return compareVersions(e1.Version, e2.Version) < 0
+}
+
+// compareVersions compares bundle versions. Invalid versions sort below valid ones, and are
+// compared to each other as strings so the order is deterministic
+func compareVersions(v1 string, v2 string) int {
+	version1, err1 := semver.Parse(v1)
+	version2, err2 := semver.Parse(v2)
+	switch {
+	case err1 == nil && err2 == nil:
+		return version1.Compare(version2)
+	case err1 == nil:
+		return 1
+	case err2 == nil:
+		return -1
+	}
+	return strings.Compare(v1, v2)
 }
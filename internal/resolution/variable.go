@@ -62,20 +62,30 @@ var _ sat.Variable = &BundleVariable{}
 
 type BundleVariable struct {
 	*store.CachedBundle
+	dependencies        [][]store.CachedBundle
 	orderedDependencies []store.CachedBundle
+	conflicts           []store.CachedBundle
 	constraints         []sat.Constraint
 }
 
-func NewBundleVariable(entity *store.CachedBundle, orderedDependencies ...store.CachedBundle) OLMVariable {
+// NewBundleVariable creates the variable of a bundle with one dependency constraint per dependency
+// and a conflict with each of the conflicting bundles. Each dependency lists its candidate bundles
+// in order of preference and is unsatisfiable if empty
+func NewBundleVariable(entity *store.CachedBundle, conflicts []store.CachedBundle, dependencies ...[]store.CachedBundle) OLMVariable {
 	var constraints []sat.Constraint
-	if len(orderedDependencies) > 0 {
-		constraints = []sat.Constraint{
-			sat.Dependency(toIdentifierIDs(orderedDependencies)...),
-		}
+	var orderedDependencies []store.CachedBundle
+	for _, dependency := range dependencies {
+		constraints = append(constraints, sat.Dependency(toIdentifierIDs(dependency)...))
+		orderedDependencies = append(orderedDependencies, dependency...)
+	}
+	for _, id := range toIdentifierIDs(conflicts) {
+		constraints = append(constraints, sat.Conflict(id))
 	}
 	return &BundleVariable{
 		CachedBundle:        entity,
+		dependencies:        dependencies,
 		orderedDependencies: orderedDependencies,
+		conflicts:           conflicts,
 		constraints:         constraints,
 	}
 }
@@ -92,6 +102,16 @@ func (b BundleVariable) OrderedEntities() []store.CachedBundle {
 	return b.orderedDependencies
 }
 
+// Dependencies returns the candidate bundles of each of the bundle's dependencies
+func (b BundleVariable) Dependencies() [][]store.CachedBundle {
+	return b.dependencies
+}
+
+// Conflicts returns the bundles that can't be installed alongside the bundle
+func (b BundleVariable) Conflicts() []store.CachedBundle {
+	return b.conflicts
+}
+
 func toIdentifierIDs(entities []store.CachedBundle) []sat.Identifier {
 	ids := make([]sat.Identifier, len(entities))
 	for index, _ := range entities {
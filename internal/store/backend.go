@@ -0,0 +1,63 @@
+package store
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+)
+
+// DefaultBackend is the storage backend used when none is configured
+const DefaultBackend = "bolt"
+
+// BackendFunc opens a package database. Backends that persist their content keep their
+// files in the given directory
+type BackendFunc func(directory string, logger *logrus.Logger, options ...DatabaseOption) (PackageDatabase, error)
+
+var (
+	backendsLock sync.RWMutex
+	backends     = map[string]BackendFunc{}
+)
+
+// RegisterBackend makes a storage backend available by name. It panics if the name is
+// already registered or open is nil
+func RegisterBackend(name string, open BackendFunc) {
+	backendsLock.Lock()
+	defer backendsLock.Unlock()
+	if open == nil {
+		panic("backend " + name + " is nil")
+	}
+	if _, ok := backends[name]; ok {
+		panic("backend " + name + " is already registered")
+	}
+	backends[name] = open
+}
+
+// Backends returns the names of the registered storage backends
+func Backends() []string {
+	backendsLock.RLock()
+	defer backendsLock.RUnlock()
+	names := make([]string, 0, len(backends))
+	for name := range backends {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// OpenPackageDatabase opens a package database with the named storage backend. The default
+// backend is used if the name is empty
+func OpenPackageDatabase(backend string, directory string, logger *logrus.Logger, options ...DatabaseOption) (PackageDatabase, error) {
+	if backend == "" {
+		backend = DefaultBackend
+	}
+	backendsLock.RLock()
+	open, ok := backends[backend]
+	backendsLock.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown storage backend %q: must be one of %s", backend, strings.Join(Backends(), ", "))
+	}
+	return open(directory, logger, options...)
+}
@@ -0,0 +1,167 @@
+package store
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+
+	"github.com/boltdb/bolt"
+)
+
+// indexKeySeparator separates the index key from the primary key in index buckets.
+// It can't appear in either key, so index keys never prefix-match each other by accident
+const indexKeySeparator = "\x00"
+
+// IndexFunction returns the keys an entry can be looked up by in an index
+type IndexFunction[E IdentifiableEntry] func(entry *E) []string
+
+type boltDBIndex[E IdentifiableEntry] struct {
+	name       string
+	bucketName []byte
+	keys       IndexFunction[E]
+}
+
+// WithIndex adds a secondary index to the table. The index is stored in its own bucket
+// and kept up to date in the same transaction as the table's inserts and deletes
+func WithIndex[E IdentifiableEntry](name string, keys IndexFunction[E]) TableOption[E] {
+	return func(table *BoltDBTable[E]) {
+		table.indexes = append(table.indexes, &boltDBIndex[E]{
+			name:       name,
+			bucketName: []byte(fmt.Sprintf("%s.index.%s", table.tableName, name)),
+			keys:       keys,
+		})
+	}
+}
+
+// Lookup returns the entries whose index key is equal to value
+func (b *BoltDBTable[E]) Lookup(indexName string, value string) ([]E, error) {
+	var entries []E
+	err := b.database.View(func(tx *bolt.Tx) error {
+		var err error
+		entries, err = b.LookupInTransaction(tx, indexName, value)
+		return err
+	})
+	return entries, err
+}
+
+func (b *BoltDBTable[E]) LookupInTransaction(tx *bolt.Tx, indexName string, value string) ([]E, error) {
+	return b.seekIndex(tx, indexName, value+indexKeySeparator)
+}
+
+// SeekIndex returns the entries whose index key starts with prefix
+func (b *BoltDBTable[E]) SeekIndex(indexName string, prefix string) ([]E, error) {
+	var entries []E
+	err := b.database.View(func(tx *bolt.Tx) error {
+		var err error
+		entries, err = b.seekIndex(tx, indexName, prefix)
+		return err
+	})
+	return entries, err
+}
+
+// RebuildIndexesInTransaction recreates all indexes from the table's entries
+func (b *BoltDBTable[E]) RebuildIndexesInTransaction(tx *bolt.Tx) error {
+	if !(tx.Writable()) {
+		return fmt.Errorf("transaction is not writable")
+	}
+	for _, index := range b.indexes {
+		if err := tx.DeleteBucket(index.bucketName); err != nil && !errors.Is(err, bolt.ErrBucketNotFound) {
+			return err
+		}
+		if _, err := tx.CreateBucket(index.bucketName); err != nil {
+			return err
+		}
+	}
+	return tx.Bucket(b.tableName).ForEach(func(key, value []byte) error {
+		entry, err := b.decode(value)
+		if err != nil {
+			return err
+		}
+		return b.indexEntry(tx, entry)
+	})
+}
+
+func (b *BoltDBTable[E]) seekIndex(tx *bolt.Tx, indexName string, prefix string) ([]E, error) {
+	index, err := b.getIndex(indexName)
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []E
+	seen := map[string]struct{}{}
+	bucket := tx.Bucket(b.tableName)
+	c := tx.Bucket(index.bucketName).Cursor()
+	prefixBytes := []byte(prefix)
+	for k, primaryKey := c.Seek(prefixBytes); k != nil && bytes.HasPrefix(k, prefixBytes); k, primaryKey = c.Next() {
+		// an entry can match a prefix through several of its index keys
+		if _, ok := seen[string(primaryKey)]; ok {
+			continue
+		}
+		seen[string(primaryKey)] = struct{}{}
+		value := bucket.Get(primaryKey)
+		if value == nil {
+			return nil, fmt.Errorf("index %s of table %s references missing entry %s", indexName, b.tableName, primaryKey)
+		}
+		entry, err := b.decode(value)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, *entry)
+	}
+	return entries, nil
+}
+
+func (b *BoltDBTable[E]) getIndex(indexName string) (*boltDBIndex[E], error) {
+	for _, index := range b.indexes {
+		if index.name == indexName {
+			return index, nil
+		}
+	}
+	return nil, fmt.Errorf("table %s has no index %s", b.tableName, indexName)
+}
+
+func (b *BoltDBTable[E]) indexEntry(tx *bolt.Tx, entry *E) error {
+	primaryKey := []byte((*entry).EntryID())
+	for _, index := range b.indexes {
+		bucket := tx.Bucket(index.bucketName)
+		for _, key := range index.keys(entry) {
+			if err := bucket.Put(indexEntryKey(key, primaryKey), primaryKey); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (b *BoltDBTable[E]) unindexEntry(tx *bolt.Tx, entry *E) error {
+	primaryKey := []byte((*entry).EntryID())
+	for _, index := range b.indexes {
+		bucket := tx.Bucket(index.bucketName)
+		for _, key := range index.keys(entry) {
+			if err := bucket.Delete(indexEntryKey(key, primaryKey)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// unindexKey removes the index entries of the entry stored under key, if any
+func (b *BoltDBTable[E]) unindexKey(tx *bolt.Tx, key []byte) error {
+	if len(b.indexes) == 0 {
+		return nil
+	}
+	value := tx.Bucket(b.tableName).Get(key)
+	if value == nil {
+		return nil
+	}
+	entry, err := b.decode(value)
+	if err != nil {
+		return err
+	}
+	return b.unindexEntry(tx, entry)
+}
+
+func indexEntryKey(key string, primaryKey []byte) []byte {
+	return append([]byte(key+indexKeySeparator), primaryKey...)
+}
@@ -2,7 +2,7 @@ package store
 
 import (
 	"bytes"
-	"encoding/json"
+	"errors"
 	"fmt"
 
 	"github.com/boltdb/bolt"
@@ -18,9 +18,20 @@ type FilterFunction[E IdentifiableEntry] func(*E) (bool, error)
 type BoltDBTable[E IdentifiableEntry] struct {
 	tableName []byte
 	database  *bolt.DB
+	codec     Codec[E]
+	indexes   []*boltDBIndex[E]
 }
 
-func NewBoltDBTable[E IdentifiableEntry](database *bolt.DB, tableName string) (*BoltDBTable[E], error) {
+type TableOption[E IdentifiableEntry] func(table *BoltDBTable[E])
+
+// WithCodec sets the codec used to encode the table entries. Entries are encoded as json by default
+func WithCodec[E IdentifiableEntry](codec Codec[E]) TableOption[E] {
+	return func(table *BoltDBTable[E]) {
+		table.codec = codec
+	}
+}
+
+func NewBoltDBTable[E IdentifiableEntry](database *bolt.DB, tableName string, options ...TableOption[E]) (*BoltDBTable[E], error) {
 	if database == nil {
 		return nil, fmt.Errorf("database parameter is nil")
 	}
@@ -29,27 +40,36 @@ func NewBoltDBTable[E IdentifiableEntry](database *bolt.DB, tableName string) (*
 		return nil, fmt.Errorf("tableName parameter is empty")
 	}
 
-	return &BoltDBTable[E]{
+	table := &BoltDBTable[E]{
 		tableName: []byte(tableName),
 		database:  database,
-	}, nil
+		codec:     JSONCodec[E]{},
+	}
+	for _, opt := range options {
+		opt(table)
+	}
+	return table, nil
 }
 
 func (b *BoltDBTable[E]) Get(key string) (*E, error) {
 	var entry *E = nil
 	err := b.database.View(func(tx *bolt.Tx) error {
-		bucket := tx.Bucket(b.tableName)
-		valueBytes := bucket.Get([]byte(key))
-		if valueBytes == nil {
-			return nil
-		}
 		var err error
-		entry, err = b.decode(valueBytes)
+		entry, err = b.GetInTransaction(tx, key)
 		return err
 	})
 	return entry, err
 }
 
+func (b *BoltDBTable[E]) GetInTransaction(tx *bolt.Tx, key string) (*E, error) {
+	bucket := tx.Bucket(b.tableName)
+	valueBytes := bucket.Get([]byte(key))
+	if valueBytes == nil {
+		return nil, nil
+	}
+	return b.decode(valueBytes)
+}
+
 func (b *BoltDBTable[E]) Has(key string) (bool, error) {
 	entry, err := b.Get(key)
 	return entry != nil, err
@@ -57,6 +77,13 @@ func (b *BoltDBTable[E]) Has(key string) (bool, error) {
 
 func (b *BoltDBTable[E]) Create() error {
 	return b.database.Update(func(tx *bolt.Tx) error {
+		// index buckets are created even if the table already exists so indexes
+		// can be added to existing tables
+		for _, index := range b.indexes {
+			if _, err := tx.CreateBucketIfNotExists(index.bucketName); err != nil {
+				return err
+			}
+		}
 		_, err := tx.CreateBucket(b.tableName)
 		return err
 	})
@@ -64,6 +91,11 @@ func (b *BoltDBTable[E]) Create() error {
 
 func (b *BoltDBTable[E]) Delete() error {
 	return b.database.Update(func(tx *bolt.Tx) error {
+		for _, index := range b.indexes {
+			if err := tx.DeleteBucket(index.bucketName); err != nil && !errors.Is(err, bolt.ErrBucketNotFound) {
+				return err
+			}
+		}
 		return tx.DeleteBucket(b.tableName)
 	})
 }
@@ -105,20 +137,27 @@ func (b *BoltDBTable[E]) Search(fn FilterFunction[E]) ([]E, error) {
 func (b *BoltDBTable[E]) Seek(prefix string) ([]E, error) {
 	var entries []E
 	err := b.database.View(func(tx *bolt.Tx) error {
-		c := tx.Bucket(b.tableName).Cursor()
-		prefix := []byte(prefix)
-		for k, v := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, v = c.Next() {
-			entry, err := b.decode(v)
-			if err != nil {
-				return err
-			}
-			entries = append(entries, *entry)
-		}
-		return nil
+		var err error
+		entries, err = b.SeekInTransaction(tx, prefix)
+		return err
 	})
 	return entries, err
 }
 
+func (b *BoltDBTable[E]) SeekInTransaction(tx *bolt.Tx, prefix string) ([]E, error) {
+	var entries []E
+	c := tx.Bucket(b.tableName).Cursor()
+	prefixBytes := []byte(prefix)
+	for k, v := c.Seek(prefixBytes); k != nil && bytes.HasPrefix(k, prefixBytes); k, v = c.Next() {
+		entry, err := b.decode(v)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, *entry)
+	}
+	return entries, nil
+}
+
 func (b *BoltDBTable[E]) Insert(entry *E) error {
 	return b.database.Update(func(tx *bolt.Tx) error {
 		return b.InsertInTransaction(tx, entry)
@@ -134,7 +173,14 @@ func (b *BoltDBTable[E]) InsertInTransaction(tx *bolt.Tx, entry *E) error {
 	if err != nil {
 		return err
 	}
-	return bucket.Put([]byte((*entry).EntryID()), valueBytes)
+	key := []byte((*entry).EntryID())
+	if err := b.unindexKey(tx, key); err != nil {
+		return err
+	}
+	if err := bucket.Put(key, valueBytes); err != nil {
+		return err
+	}
+	return b.indexEntry(tx, entry)
 }
 
 func (b *BoltDBTable[E]) DeleteEntryWithKey(key string) error {
@@ -148,6 +194,9 @@ func (b *BoltDBTable[E]) DeleteEntryWithKeyInTransaction(tx *bolt.Tx, key string
 		return fmt.Errorf("transaction is not writable")
 	}
 	bucket := tx.Bucket(b.tableName)
+	if err := b.unindexKey(tx, []byte(key)); err != nil {
+		return err
+	}
 	return bucket.Delete([]byte(key))
 }
 
@@ -165,7 +214,15 @@ func (b *BoltDBTable[E]) DeleteEntriesWithPrefixInTransaction(tx *bolt.Tx, prefi
 	bucket := tx.Bucket(b.tableName)
 	cursor := bucket.Cursor()
 	prefixBytes := []byte(prefix)
+	var keys [][]byte
 	for key, _ := cursor.Seek(prefixBytes); key != nil && bytes.HasPrefix(key, prefixBytes); key, _ = cursor.Next() {
+		keys = append(keys, append([]byte(nil), key...))
+	}
+	// keys are deleted after iterating since deleting while iterating skips entries
+	for _, key := range keys {
+		if err := b.unindexKey(tx, key); err != nil {
+			return err
+		}
 		if err := bucket.Delete(key); err != nil {
 			return err
 		}
@@ -175,10 +232,10 @@ func (b *BoltDBTable[E]) DeleteEntriesWithPrefixInTransaction(tx *bolt.Tx, prefi
 
 func (b *BoltDBTable[E]) Iterate(fn IterationFunction[E]) error {
 	return b.database.View(func(tx *bolt.Tx) error {
-		b := tx.Bucket(b.tableName)
-		return b.ForEach(func(key, value []byte) error {
-			entry := new(E)
-			if err := json.Unmarshal(value, entry); err != nil {
+		bucket := tx.Bucket(b.tableName)
+		return bucket.ForEach(func(key, value []byte) error {
+			entry, err := b.decode(value)
+			if err != nil {
 				return err
 			}
 			return fn(entry)
@@ -187,14 +244,9 @@ func (b *BoltDBTable[E]) Iterate(fn IterationFunction[E]) error {
 }
 
 func (b *BoltDBTable[E]) encode(entry *E) ([]byte, error) {
-	return json.Marshal(entry)
+	return b.codec.Encode(entry)
 }
 
 func (b *BoltDBTable[E]) decode(data []byte) (*E, error) {
-	entry := new(E)
-	if err := json.Unmarshal(data, entry); err != nil {
-		return nil, err
-	}
-	return entry, nil
-
+	return b.codec.Decode(data)
 }
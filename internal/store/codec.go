@@ -0,0 +1,77 @@
+package store
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"io"
+)
+
+// Codec encodes and decodes table entries
+type Codec[E any] interface {
+	Encode(entry *E) ([]byte, error)
+	Decode(data []byte) (*E, error)
+}
+
+var _ Codec[CachedBundle] = JSONCodec[CachedBundle]{}
+
+// JSONCodec encodes entries as json
+type JSONCodec[E any] struct{}
+
+func (JSONCodec[E]) Encode(entry *E) ([]byte, error) {
+	return json.Marshal(entry)
+}
+
+func (JSONCodec[E]) Decode(data []byte) (*E, error) {
+	entry := new(E)
+	if err := json.Unmarshal(data, entry); err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
+
+var _ Codec[CachedBundle] = &GzipCodec[CachedBundle]{}
+
+// GzipCodec compresses the output of another codec. Bundles carry their full CSV and manifests,
+// which compress well, so this considerably reduces the size of large catalogs
+type GzipCodec[E any] struct {
+	Codec Codec[E]
+}
+
+func NewGzipCodec[E any](codec Codec[E]) *GzipCodec[E] {
+	return &GzipCodec[E]{
+		Codec: codec,
+	}
+}
+
+func (g *GzipCodec[E]) Encode(entry *E) ([]byte, error) {
+	data, err := g.Codec.Encode(entry)
+	if err != nil {
+		return nil, err
+	}
+	var buffer bytes.Buffer
+	writer, err := gzip.NewWriterLevel(&buffer, gzip.BestSpeed)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := writer.Write(data); err != nil {
+		return nil, err
+	}
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+	return buffer.Bytes(), nil
+}
+
+func (g *GzipCodec[E]) Decode(data []byte) (*E, error) {
+	reader, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+	decompressed, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, err
+	}
+	return g.Codec.Decode(decompressed)
+}
@@ -0,0 +1,119 @@
+package store
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"github.com/blang/semver/v4"
+	"github.com/operator-framework/api/pkg/constraints"
+	"github.com/operator-framework/operator-registry/alpha/property"
+	"github.com/operator-framework/operator-registry/pkg/api"
+)
+
+var (
+	celEnvironmentOnce sync.Once
+	celEnvironment     *constraints.CelEnvironment
+)
+
+// bundleConstraints parses the generic olm.constraint properties of a bundle and its olm.gvk.required
+// properties. Required gvks already listed in the bundle's required apis are skipped, since those are
+// resolved from the required apis. Invalid constraints are replaced by unsatisfiable constraints and
+// returned as problems, so the bundle is cached but can't be installed
+func bundleConstraints(bundle *api.Bundle) ([]constraints.Constraint, []error) {
+	requiredAPIs := map[property.GVK]struct{}{}
+	for _, gvk := range bundle.GetRequiredApis() {
+		requiredAPIs[property.GVK{Group: gvk.GetGroup(), Version: gvk.GetVersion(), Kind: gvk.GetKind()}] = struct{}{}
+	}
+
+	var bundleConstraints []constraints.Constraint
+	var problems []error
+	invalid := func(err error) {
+		problems = append(problems, err)
+		bundleConstraints = append(bundleConstraints, unsatisfiableConstraint(err))
+	}
+	addRequiredGVK := func(value string) {
+		gvk := property.GVKRequired{}
+		if err := json.Unmarshal([]byte(value), &gvk); err != nil {
+			invalid(fmt.Errorf("invalid %s property: %w", property.TypeGVKRequired, err))
+			return
+		}
+		key := property.GVK{Group: gvk.Group, Version: gvk.Version, Kind: gvk.Kind}
+		if _, ok := requiredAPIs[key]; ok {
+			return
+		}
+		requiredAPIs[key] = struct{}{}
+		bundleConstraints = append(bundleConstraints, constraints.Constraint{
+			GVK: &constraints.GVKConstraint{Group: gvk.Group, Version: gvk.Version, Kind: gvk.Kind},
+		})
+	}
+
+	for _, prop := range bundle.GetProperties() {
+		switch prop.GetType() {
+		case constraints.OLMConstraintType:
+			constraint, err := constraints.Parse(json.RawMessage(prop.GetValue()))
+			if err == nil {
+				err = validateConstraint(constraint)
+			}
+			if err != nil {
+				invalid(fmt.Errorf("invalid %s property: %w", constraints.OLMConstraintType, err))
+				continue
+			}
+			bundleConstraints = append(bundleConstraints, constraint)
+		case property.TypeGVKRequired:
+			addRequiredGVK(prop.GetValue())
+		}
+	}
+
+	// required gvks are also converted to olm.gvk dependencies
+	for _, dependency := range bundle.GetDependencies() {
+		if dependency.GetType() == property.TypeGVK {
+			addRequiredGVK(dependency.GetValue())
+		}
+	}
+	return bundleConstraints, problems
+}
+
+// unsatisfiableConstraint is a constraint no bundle satisfies: an any constraint without alternatives
+func unsatisfiableConstraint(err error) constraints.Constraint {
+	return constraints.Constraint{
+		FailureMessage: err.Error(),
+		Any:            &constraints.CompoundConstraint{},
+	}
+}
+
+// validateConstraint checks the version ranges and cel rules of a constraint and its nested constraints
+func validateConstraint(constraint constraints.Constraint) error {
+	switch {
+	case constraint.Package != nil:
+		if constraint.Package.VersionRange == "" {
+			return nil
+		}
+		if _, err := semver.ParseRange(constraint.Package.VersionRange); err != nil {
+			return fmt.Errorf("invalid version range %q for package %s: %w", constraint.Package.VersionRange, constraint.Package.PackageName, err)
+		}
+		return nil
+	case constraint.GVK != nil:
+		return nil
+	case constraint.Cel != nil:
+		celEnvironmentOnce.Do(func() {
+			celEnvironment = constraints.NewCelEnvironment()
+		})
+		if _, err := celEnvironment.Validate(constraint.Cel.Rule); err != nil {
+			return fmt.Errorf("invalid cel rule %q: %w", constraint.Cel.Rule, err)
+		}
+		return nil
+	}
+	for _, compound := range []*constraints.CompoundConstraint{constraint.All, constraint.Any, constraint.Not} {
+		if compound == nil {
+			continue
+		}
+		for _, nested := range compound.Constraints {
+			if err := validateConstraint(nested); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return fmt.Errorf("constraint has no package, gvk, cel, all, any or not clause")
+}
@@ -0,0 +1,178 @@
+package store
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+)
+
+const (
+	categoriesAnnotation   = "categories"
+	capabilitiesAnnotation = "capabilities"
+	almExamplesAnnotation  = "alm-examples"
+	// maxAnnotationLength is the length of the longest annotation kept in the metadata, longer
+	// annotations hold embedded documents that aren't worth searching
+	maxAnnotationLength = 256
+)
+
+// CSVMetadata is the discovery metadata of a bundle, extracted from its ClusterServiceVersion. Only
+// what 'olm show' and 'olm search' need is kept, the rest of the csv remains in the bundle's CsvJson
+type CSVMetadata struct {
+	DisplayName  string       `json:"displayName,omitempty"`
+	Description  string       `json:"description,omitempty"`
+	Keywords     []string     `json:"keywords,omitempty"`
+	Categories   []string     `json:"categories,omitempty"`
+	Capabilities string       `json:"capabilities,omitempty"`
+	Provider     string       `json:"provider,omitempty"`
+	Maintainers  []Maintainer `json:"maintainers,omitempty"`
+	Links        []Link       `json:"links,omitempty"`
+	// Icons are only kept in the metadata of packages
+	Icons          []Icon        `json:"icons,omitempty"`
+	InstallModes   []InstallMode `json:"installModes,omitempty"`
+	MinKubeVersion string        `json:"minKubeVersion,omitempty"`
+	// Annotations are the csv annotations that are searched, see searchableAnnotations
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+type Maintainer struct {
+	Name  string `json:"name,omitempty"`
+	Email string `json:"email,omitempty"`
+}
+
+type Link struct {
+	Name string `json:"name,omitempty"`
+	URL  string `json:"url,omitempty"`
+}
+
+type Icon struct {
+	MediaType string `json:"mediatype,omitempty"`
+	Data      string `json:"base64data,omitempty"`
+}
+
+type InstallMode struct {
+	Type      string `json:"type"`
+	Supported bool   `json:"supported"`
+}
+
+// SupportedInstallModes returns the install mode types the bundle supports
+func (c *CSVMetadata) SupportedInstallModes() []string {
+	var supported []string
+	for _, installMode := range c.InstallModes {
+		if installMode.Supported {
+			supported = append(supported, installMode.Type)
+		}
+	}
+	return supported
+}
+
+// clusterServiceVersion is the subset of a ClusterServiceVersion read into CSVMetadata
+type clusterServiceVersion struct {
+	Metadata struct {
+		Annotations map[string]string `json:"annotations"`
+	} `json:"metadata"`
+	Spec struct {
+		DisplayName string   `json:"displayName"`
+		Description string   `json:"description"`
+		Keywords    []string `json:"keywords"`
+		Provider    struct {
+			Name string `json:"name"`
+		} `json:"provider"`
+		Maintainers    []Maintainer  `json:"maintainers"`
+		Links          []Link        `json:"links"`
+		Icon           []Icon        `json:"icon"`
+		InstallModes   []InstallMode `json:"installModes"`
+		MinKubeVersion string        `json:"minKubeVersion"`
+	} `json:"spec"`
+}
+
+// ParseCSVMetadata extracts the discovery metadata from a ClusterServiceVersion json document
+func ParseCSVMetadata(csvJson string) (*CSVMetadata, error) {
+	if csvJson == "" {
+		return &CSVMetadata{}, nil
+	}
+	csv := &clusterServiceVersion{}
+	if err := json.Unmarshal([]byte(csvJson), csv); err != nil {
+		return nil, err
+	}
+	annotations := csv.Metadata.Annotations
+	return &CSVMetadata{
+		DisplayName:    csv.Spec.DisplayName,
+		Description:    csv.Spec.Description,
+		Keywords:       csv.Spec.Keywords,
+		Categories:     splitCategories(annotations[categoriesAnnotation]),
+		Capabilities:   annotations[capabilitiesAnnotation],
+		Provider:       csv.Spec.Provider.Name,
+		Maintainers:    csv.Spec.Maintainers,
+		Links:          csv.Spec.Links,
+		Icons:          csv.Spec.Icon,
+		InstallModes:   csv.Spec.InstallModes,
+		MinKubeVersion: csv.Spec.MinKubeVersion,
+		Annotations:    searchableAnnotations(annotations),
+	}, nil
+}
+
+// searchableAnnotations leaves out the annotations holding embedded documents, such as alm-examples
+func searchableAnnotations(annotations map[string]string) map[string]string {
+	var searchable map[string]string
+	for key, value := range annotations {
+		if key == almExamplesAnnotation || len(value) > maxAnnotationLength {
+			continue
+		}
+		if searchable == nil {
+			searchable = map[string]string{}
+		}
+		searchable[key] = value
+	}
+	return searchable
+}
+
+func splitCategories(categories string) []string {
+	var split []string
+	for _, category := range strings.Split(categories, ",") {
+		if category = strings.TrimSpace(category); category != "" {
+			split = append(split, category)
+		}
+	}
+	return split
+}
+
+// bundleMetadata returns the bundle's csv metadata without its icons, falling back to empty metadata
+// if the csv can't be read so the bundle can still be cached and searched by name
+func bundleMetadata(bundle *CachedBundle) *CSVMetadata {
+	metadata := PackageMetadata(bundle)
+	metadata.Icons = nil
+	return metadata
+}
+
+// PackageMetadata returns the metadata of the package whose head is the bundle, which unlike the
+// bundle's own metadata includes the csv icons
+func PackageMetadata(bundle *CachedBundle) *CSVMetadata {
+	metadata, err := ParseCSVMetadata(bundle.GetCsvJson())
+	if err != nil {
+		return &CSVMetadata{}
+	}
+	return metadata
+}
+
+// isPackageHead returns true if the bundle is the head of its package's default channel,
+// whose metadata describes the package
+func isPackageHead(pkg *CachedPackage, bundle *CachedBundle) bool {
+	if bundle.ChannelName != pkg.GetDefaultChannelName() {
+		return false
+	}
+	for _, channel := range pkg.GetChannels() {
+		if channel.GetName() == bundle.ChannelName {
+			return channel.GetCsvName() == bundle.CsvName
+		}
+	}
+	return false
+}
+
+func sortedAnnotations(annotations map[string]string) []string {
+	var sorted []string
+	for key, value := range annotations {
+		sorted = append(sorted, key+"="+value)
+	}
+	sort.Strings(sorted)
+	return sorted
+}
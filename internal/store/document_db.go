@@ -0,0 +1,556 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/perdasilva/olmcli/internal/repository"
+	"github.com/sirupsen/logrus"
+)
+
+// documentStore is a transactional key/value store that groups documents in collections. It is
+// all a backend needs to provide to store the package database as a documentPackageDatabase
+type documentStore interface {
+	View(fn func(tx documentTx) error) error
+	Update(fn func(tx documentTx) error) error
+	Close() error
+}
+
+type documentTx interface {
+	// Get returns nil if the collection has no document with the key
+	Get(collection string, key string) ([]byte, error)
+	Put(collection string, key string, value []byte) error
+	Delete(collection string, key string) error
+	// Scan calls fn with every document whose key starts with prefix, in key order. fn may
+	// modify the collection, the documents it adds or deletes don't change the scan
+	Scan(collection string, prefix string, fn func(key string, value []byte) error) error
+}
+
+// documentCollection stores entries of type E in a collection of a documentStore
+type documentCollection[E IdentifiableEntry] struct {
+	name  string
+	codec Codec[E]
+}
+
+func (c documentCollection[E]) get(tx documentTx, key string) (*E, error) {
+	value, err := tx.Get(c.name, key)
+	if err != nil || value == nil {
+		return nil, err
+	}
+	return c.codec.Decode(value)
+}
+
+func (c documentCollection[E]) put(tx documentTx, entry *E) error {
+	value, err := c.codec.Encode(entry)
+	if err != nil {
+		return err
+	}
+	return tx.Put(c.name, (*entry).EntryID(), value)
+}
+
+func (c documentCollection[E]) scan(tx documentTx, prefix string, fn IterationFunction[E]) error {
+	return tx.Scan(c.name, prefix, func(_ string, value []byte) error {
+		entry, err := c.codec.Decode(value)
+		if err != nil {
+			return err
+		}
+		return fn(entry)
+	})
+}
+
+func (c documentCollection[E]) list(tx documentTx, prefix string) ([]E, error) {
+	var entries []E
+	err := c.scan(tx, prefix, func(entry *E) error {
+		entries = append(entries, *entry)
+		return nil
+	})
+	return entries, err
+}
+
+func (c documentCollection[E]) deletePrefix(tx documentTx, prefix string) error {
+	return deleteDocuments(tx, c.name, prefix)
+}
+
+func deleteDocuments(tx documentTx, collection string, prefix string) error {
+	return tx.Scan(collection, prefix, func(key string, _ []byte) error {
+		return tx.Delete(collection, key)
+	})
+}
+
+var (
+	repositoryDocuments = documentCollection[CachedRepository]{name: repositoriesBucket, codec: JSONCodec[CachedRepository]{}}
+	packageDocuments    = documentCollection[CachedPackage]{name: packagesBucket, codec: JSONCodec[CachedPackage]{}}
+	bundleDocuments     = documentCollection[CachedBundle]{name: bundlesBucket, codec: bundleCodec()}
+	gvkDocuments        = documentCollection[CachedGVKBundle]{name: gvkBucket, codec: JSONCodec[CachedGVKBundle]{}}
+	historyDocuments    = documentCollection[CachedOperation]{name: historyBucket, codec: JSONCodec[CachedOperation]{}}
+	warningDocuments    = documentCollection[CachedWarning]{name: warningsBucket, codec: JSONCodec[CachedWarning]{}}
+)
+
+// bundleIndexCollection names the collection of a bundle index, using the same scheme as the bolt index buckets
+func bundleIndexCollection(index BundleIndex) string {
+	return fmt.Sprintf("%s.index.%s", bundlesBucket, index)
+}
+
+var _ PackageDatabase = &documentPackageDatabase{}
+
+// documentPackageDatabase implements the package database on top of a documentStore. It keeps
+// the same tables and bundle indexes as the bolt database, one collection each. Bolt migrations
+// work on bolt buckets, so databases with an older schema must be rebuilt
+type documentPackageDatabase struct {
+	store  documentStore
+	logger *logrus.Logger
+}
+
+func newDocumentPackageDatabase(store documentStore, logger *logrus.Logger, options ...DatabaseOption) (PackageDatabase, error) {
+	if logger == nil {
+		panic("logger is nil")
+	}
+
+	config := &databaseConfig{}
+	for _, opt := range options {
+		opt(config)
+	}
+
+	var schemaVersion int
+	readSchemaVersion := func(tx documentTx) error {
+		var err error
+		schemaVersion, err = readDocumentSchemaVersion(tx)
+		if err != nil || schemaVersion != 0 {
+			return err
+		}
+		// documents are only written with a schema version, so this is a new database
+		schemaVersion = CurrentSchemaVersion()
+		if config.readOnly {
+			return nil
+		}
+		return writeDocumentSchemaVersion(tx, schemaVersion)
+	}
+	transaction := store.Update
+	if config.readOnly {
+		transaction = store.View
+	}
+	if err := transaction(readSchemaVersion); err != nil {
+		store.Close()
+		return nil, err
+	}
+
+	packageDatabase := &documentPackageDatabase{
+		store:  store,
+		logger: logger,
+	}
+	if !config.skipMigration && schemaVersion != CurrentSchemaVersion() {
+		if err := packageDatabase.Migrate(context.Background()); err != nil {
+			store.Close()
+			return nil, err
+		}
+	}
+	return packageDatabase, nil
+}
+
+// readDocumentSchemaVersion returns the schema version of the database, or 0 if it has none
+func readDocumentSchemaVersion(tx documentTx) (int, error) {
+	value, err := tx.Get(metadataBucket, schemaVersionKey)
+	if err != nil || value == nil {
+		return 0, err
+	}
+	version, err := strconv.Atoi(string(value))
+	if err != nil {
+		return 0, fmt.Errorf("invalid schema version %q: %w", value, err)
+	}
+	return version, nil
+}
+
+func writeDocumentSchemaVersion(tx documentTx, version int) error {
+	return tx.Put(metadataBucket, schemaVersionKey, []byte(strconv.Itoa(version)))
+}
+
+func (d *documentPackageDatabase) HasRepository(_ context.Context, repoName string) (bool, error) {
+	var found bool
+	err := d.store.View(func(tx documentTx) error {
+		repository, err := repositoryDocuments.get(tx, repoName)
+		found = repository != nil
+		return err
+	})
+	return found, err
+}
+
+func (d *documentPackageDatabase) ListRepositories(_ context.Context) ([]CachedRepository, error) {
+	return listDocuments(d.store, repositoryDocuments, "")
+}
+
+func (d *documentPackageDatabase) ListPackages(_ context.Context) ([]CachedPackage, error) {
+	return listDocuments(d.store, packageDocuments, "")
+}
+
+func (d *documentPackageDatabase) ListBundles(_ context.Context) ([]CachedBundle, error) {
+	return listDocuments(d.store, bundleDocuments, "")
+}
+
+func (d *documentPackageDatabase) ListBundlesForGVK(_ context.Context, group string, version string, kind string) ([]CachedBundle, error) {
+	var bundles []CachedBundle
+	err := d.store.View(func(tx documentTx) error {
+		prefix := strings.Join([]string{group, version, kind}, keySeparator) + keySeparator
+		return gvkDocuments.scan(tx, prefix, func(gvkBundle *CachedGVKBundle) error {
+			bundle, err := bundleDocuments.get(tx, gvkBundle.BundleID)
+			if bundle != nil {
+				bundles = append(bundles, *bundle)
+			}
+			return err
+		})
+	})
+	return bundles, err
+}
+
+func (d *documentPackageDatabase) ListGVKs(_ context.Context) (map[string][]CachedBundle, error) {
+	result := map[string][]CachedBundle{}
+	err := d.store.View(func(tx documentTx) error {
+		return gvkDocuments.scan(tx, "", func(gvkBundle *CachedGVKBundle) error {
+			bundle, err := bundleDocuments.get(tx, gvkBundle.BundleID)
+			if bundle != nil {
+				result[gvkBundle.GVK] = append(result[gvkBundle.GVK], *bundle)
+			}
+			return err
+		})
+	})
+	return result, err
+}
+
+func (d *documentPackageDatabase) SearchBundles(_ context.Context, searchTerm string, options ...SearchOption) ([]BundleSearchResult, error) {
+	return searchBundles(d.iterateBundles, searchTerm, options...)
+}
+
+func (d *documentPackageDatabase) SearchPackages(ctx context.Context, searchTerm string, options ...SearchOption) ([]PackageSearchResult, error) {
+	bundleResults, err := d.SearchBundles(ctx, searchTerm, options...)
+	if err != nil {
+		return nil, err
+	}
+	return searchPackages(bundleResults, func(packageID string) (*CachedPackage, error) {
+		return d.GetPackage(ctx, packageID)
+	})
+}
+
+func (d *documentPackageDatabase) CacheRepository(ctx context.Context, repository repository.Repository) error {
+	if repository == nil {
+		panic("repository is nil")
+	}
+
+	d.logger.Debugln("Caching repository from ", repository.Source())
+	err := d.store.Update(func(tx documentTx) error {
+		return cacheRepository(ctx, repository, &documentRepositoryWriter{tx: tx}, d.logger)
+	})
+	d.logger.Debugln("Done...")
+	return err
+}
+
+func (d *documentPackageDatabase) RemoveRepository(_ context.Context, repoName string) error {
+	return d.store.Update(func(tx documentTx) error {
+		return removeRepository(tx, repoName)
+	})
+}
+
+func removeRepository(tx documentTx, repoName string) error {
+	if err := tx.Delete(repositoriesBucket, repoName); err != nil {
+		return err
+	}
+
+	prefix := repoName + keySeparator
+	if err := packageDocuments.deletePrefix(tx, prefix); err != nil {
+		return err
+	}
+	if err := warningDocuments.deletePrefix(tx, prefix); err != nil {
+		return err
+	}
+	return bundleDocuments.scan(tx, prefix, func(bundle *CachedBundle) error {
+		return deleteBundle(tx, bundle)
+	})
+}
+
+func (d *documentPackageDatabase) ExportRepository(_ context.Context, repoName string) (*RepositorySnapshot, error) {
+	snapshot := &RepositorySnapshot{}
+	err := d.store.View(func(tx documentTx) error {
+		repository, err := repositoryDocuments.get(tx, repoName)
+		if err != nil {
+			return err
+		}
+		if repository == nil {
+			return fmt.Errorf("repository %s not found", repoName)
+		}
+		snapshot.Repository = *repository
+		prefix := repoName + keySeparator
+		if snapshot.Packages, err = packageDocuments.list(tx, prefix); err != nil {
+			return err
+		}
+		if snapshot.Bundles, err = bundleDocuments.list(tx, prefix); err != nil {
+			return err
+		}
+		snapshot.Warnings, err = warningDocuments.list(tx, prefix)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return snapshot, nil
+}
+
+func (d *documentPackageDatabase) ImportRepository(_ context.Context, snapshot *RepositorySnapshot) error {
+	if err := snapshot.validate(); err != nil {
+		return err
+	}
+	return d.store.Update(func(tx documentTx) error {
+		if err := removeRepository(tx, snapshot.Repository.RepositoryName); err != nil {
+			return err
+		}
+		return importRepository(snapshot, &documentRepositoryWriter{tx: tx})
+	})
+}
+
+func (d *documentPackageDatabase) GetPackage(_ context.Context, packageID string) (*CachedPackage, error) {
+	return getDocument(d.store, packageDocuments, packageID)
+}
+
+func (d *documentPackageDatabase) GetBundle(_ context.Context, bundleID string) (*CachedBundle, error) {
+	return getDocument(d.store, bundleDocuments, bundleID)
+}
+
+func (d *documentPackageDatabase) IterateBundles(_ context.Context, fn func(bundle *CachedBundle) error) error {
+	return d.iterateBundles(fn)
+}
+
+func (d *documentPackageDatabase) iterateBundles(fn IterationFunction[CachedBundle]) error {
+	return d.store.View(func(tx documentTx) error {
+		return bundleDocuments.scan(tx, "", fn)
+	})
+}
+
+func (d *documentPackageDatabase) GetBundlesForPackage(ctx context.Context, packageName string, options ...PackageSearchOption) ([]CachedBundle, error) {
+	searchOptions := &packageSearchConfig{}
+	searchOptions.applyOptions(options...)
+
+	entries, err := d.LookupBundles(ctx, BundlesByPackage, packageName)
+	if err != nil {
+		return nil, err
+	}
+
+	var bundles []CachedBundle
+	for index := range entries {
+		if searchOptions.keep(&entries[index]) {
+			bundles = append(bundles, entries[index])
+		}
+	}
+	return bundles, nil
+}
+
+func (d *documentPackageDatabase) LookupBundles(_ context.Context, index BundleIndex, value string) ([]CachedBundle, error) {
+	if _, ok := bundleIndexFunctions()[index]; !ok {
+		return nil, fmt.Errorf("table %s has no index %s", bundlesBucket, index)
+	}
+
+	var bundles []CachedBundle
+	err := d.store.View(func(tx documentTx) error {
+		return tx.Scan(bundleIndexCollection(index), value+indexKeySeparator, func(_ string, bundleID []byte) error {
+			bundle, err := bundleDocuments.get(tx, string(bundleID))
+			if err != nil {
+				return err
+			}
+			if bundle == nil {
+				return fmt.Errorf("index %s of table %s references missing entry %s", index, bundlesBucket, bundleID)
+			}
+			bundles = append(bundles, *bundle)
+			return nil
+		})
+	})
+	return bundles, err
+}
+
+func (d *documentPackageDatabase) SchemaVersion(_ context.Context) (int, error) {
+	var version int
+	err := d.store.View(func(tx documentTx) error {
+		var err error
+		version, err = readDocumentSchemaVersion(tx)
+		return err
+	})
+	return version, err
+}
+
+// Migrate fails with ErrRebuildRequired if the database has an older schema
+func (d *documentPackageDatabase) Migrate(ctx context.Context) error {
+	version, err := d.SchemaVersion(ctx)
+	if err != nil {
+		return err
+	}
+	pending, err := pendingMigrations(version)
+	if err != nil {
+		return err
+	}
+	if len(pending) > 0 {
+		return fmt.Errorf("package database schema version %d can't be upgraded in place: %w", version, ErrRebuildRequired)
+	}
+	return nil
+}
+
+// Reset removes all cached packages and bundles, keeping the repository records and the
+// install history, and stamps the database with the current schema version
+func (d *documentPackageDatabase) Reset(_ context.Context) error {
+	return d.store.Update(resetDocuments)
+}
+
+func resetDocuments(tx documentTx) error {
+	collections := []string{packagesBucket, bundlesBucket, gvkBucket, warningsBucket}
+	for index := range bundleIndexFunctions() {
+		collections = append(collections, bundleIndexCollection(index))
+	}
+	for _, collection := range collections {
+		if err := deleteDocuments(tx, collection, ""); err != nil {
+			return err
+		}
+	}
+	return writeDocumentSchemaVersion(tx, CurrentSchemaVersion())
+}
+
+// ReplaceRepositories resets the database and imports the snapshots in a single transaction
+func (d *documentPackageDatabase) ReplaceRepositories(_ context.Context, snapshots []RepositorySnapshot) error {
+	return d.store.Update(func(tx documentTx) error {
+		if err := resetDocuments(tx); err != nil {
+			return err
+		}
+		for index := range snapshots {
+			snapshot := &snapshots[index]
+			if err := snapshot.validate(); err != nil {
+				return err
+			}
+			if err := removeRepository(tx, snapshot.Repository.RepositoryName); err != nil {
+				return err
+			}
+			if err := importRepository(snapshot, &documentRepositoryWriter{tx: tx}); err != nil {
+				return fmt.Errorf("error importing repository %s: %w", snapshot.Repository.RepositoryName, err)
+			}
+		}
+		return nil
+	})
+}
+
+func (d *documentPackageDatabase) RecordOperation(_ context.Context, operation *CachedOperation) error {
+	return d.store.Update(func(tx documentTx) error {
+		return historyDocuments.put(tx, operation)
+	})
+}
+
+func (d *documentPackageDatabase) ListOperations(_ context.Context) ([]CachedOperation, error) {
+	return listDocuments(d.store, historyDocuments, "")
+}
+
+func (d *documentPackageDatabase) GetOperation(_ context.Context, operationID string) (*CachedOperation, error) {
+	return getDocument(d.store, historyDocuments, operationID)
+}
+
+func (d *documentPackageDatabase) ListWarnings(_ context.Context, repositories ...string) ([]CachedWarning, error) {
+	if len(repositories) == 0 {
+		return listDocuments(d.store, warningDocuments, "")
+	}
+	var warnings []CachedWarning
+	for _, repository := range repositories {
+		repositoryWarnings, err := listDocuments(d.store, warningDocuments, repository+keySeparator)
+		if err != nil {
+			return nil, err
+		}
+		warnings = append(warnings, repositoryWarnings...)
+	}
+	return warnings, nil
+}
+
+func (d *documentPackageDatabase) Close() error {
+	return d.store.Close()
+}
+
+func getDocument[E IdentifiableEntry](store documentStore, collection documentCollection[E], key string) (*E, error) {
+	var entry *E
+	err := store.View(func(tx documentTx) error {
+		var err error
+		entry, err = collection.get(tx, key)
+		return err
+	})
+	return entry, err
+}
+
+func listDocuments[E IdentifiableEntry](store documentStore, collection documentCollection[E], prefix string) ([]E, error) {
+	var entries []E
+	err := store.View(func(tx documentTx) error {
+		var err error
+		entries, err = collection.list(tx, prefix)
+		return err
+	})
+	return entries, err
+}
+
+// putBundle stores the bundle along with its index and gvk entries, replacing the entries of
+// a previously stored version of the bundle
+func putBundle(tx documentTx, bundle *CachedBundle) error {
+	previous, err := bundleDocuments.get(tx, bundle.BundleID)
+	if err != nil {
+		return err
+	}
+	if previous != nil {
+		if err := deleteBundle(tx, previous); err != nil {
+			return err
+		}
+	}
+	if err := bundleDocuments.put(tx, bundle); err != nil {
+		return err
+	}
+	for index, keys := range bundleIndexFunctions() {
+		for _, key := range keys(bundle) {
+			if err := tx.Put(bundleIndexCollection(index), key+indexKeySeparator+bundle.BundleID, []byte(bundle.BundleID)); err != nil {
+				return err
+			}
+		}
+	}
+	for _, gvkBundle := range gvkBundles(bundle) {
+		if err := gvkDocuments.put(tx, &gvkBundle); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// deleteBundle deletes the bundle along with its index and gvk entries
+func deleteBundle(tx documentTx, bundle *CachedBundle) error {
+	for index, keys := range bundleIndexFunctions() {
+		for _, key := range keys(bundle) {
+			if err := tx.Delete(bundleIndexCollection(index), key+indexKeySeparator+bundle.BundleID); err != nil {
+				return err
+			}
+		}
+	}
+	for _, gvkBundle := range gvkBundles(bundle) {
+		if err := tx.Delete(gvkBucket, gvkBundle.GVKID); err != nil {
+			return err
+		}
+	}
+	return tx.Delete(bundlesBucket, bundle.BundleID)
+}
+
+type documentRepositoryWriter struct {
+	tx documentTx
+}
+
+func (w *documentRepositoryWriter) deleteWarnings(repoName string) error {
+	return warningDocuments.deletePrefix(w.tx, repoName+keySeparator)
+}
+
+func (w *documentRepositoryWriter) putBundle(bundle *CachedBundle) error {
+	return putBundle(w.tx, bundle)
+}
+
+func (w *documentRepositoryWriter) putWarning(warning *CachedWarning) error {
+	return warningDocuments.put(w.tx, warning)
+}
+
+func (w *documentRepositoryWriter) putPackage(pkg *CachedPackage) error {
+	return packageDocuments.put(w.tx, pkg)
+}
+
+func (w *documentRepositoryWriter) putRepository(repository *CachedRepository) error {
+	return repositoryDocuments.put(w.tx, repository)
+}
@@ -0,0 +1,212 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/operator-framework/operator-registry/alpha/property"
+	"github.com/operator-framework/operator-registry/pkg/api"
+	"github.com/operator-framework/operator-registry/pkg/client"
+	"github.com/perdasilva/olmcli/internal/repository"
+	"github.com/sirupsen/logrus"
+)
+
+var _ repository.Repository = &fixtureRepository{}
+
+// fixtureRepository serves a generated catalog. Each package has channels stable-0..stable-n, each
+// channel a replaces chain of bundles. Every bundle provides a gvk of its package and the shared
+// fixture gvk, and every package but the first depends on the previous package
+type fixtureRepository struct {
+	source   string
+	digest   string
+	packages map[string]*api.Package
+	bundles  []*api.Bundle
+}
+
+// fixtureGVK is provided by every bundle of a fixture repository
+var fixtureGVK = &api.GroupVersionKind{Group: "fixture.example.com", Version: "v1", Kind: "Fixture"}
+
+func newFixtureRepository(source string, packageCount int, channelCount int, bundleCount int) *fixtureRepository {
+	repo := &fixtureRepository{
+		source:   source,
+		digest:   "sha256:" + strings.Repeat("0", 64),
+		packages: map[string]*api.Package{},
+	}
+	for p := 0; p < packageCount; p++ {
+		packageName := fixturePackageName(p)
+		pkg := &api.Package{Name: packageName, DefaultChannelName: fixtureChannelName(0)}
+		for c := 0; c < channelCount; c++ {
+			replaces := ""
+			for b := 0; b < bundleCount; b++ {
+				bundle := fixtureBundle(p, c, b, replaces)
+				repo.bundles = append(repo.bundles, bundle)
+				replaces = bundle.CsvName
+			}
+			pkg.Channels = append(pkg.Channels, &api.Channel{Name: fixtureChannelName(c), CsvName: replaces})
+		}
+		repo.packages[packageName] = pkg
+	}
+	return repo
+}
+
+func fixturePackageName(p int) string {
+	return fmt.Sprintf("package-%04d", p)
+}
+
+func fixtureChannelName(c int) string {
+	return fmt.Sprintf("stable-%d", c)
+}
+
+// fixturePackageGVK is provided by every bundle of the package
+func fixturePackageGVK(p int) *api.GroupVersionKind {
+	return &api.GroupVersionKind{Group: fixturePackageName(p) + ".example.com", Version: "v1", Kind: "Widget"}
+}
+
+func fixtureBundle(p int, c int, b int, replaces string) *api.Bundle {
+	packageName := fixturePackageName(p)
+	version := fmt.Sprintf("%d.%d.0", c+1, b)
+	csvName := fmt.Sprintf("%s.v%s", packageName, version)
+	providedAPIs := []*api.GroupVersionKind{fixturePackageGVK(p), fixtureGVK}
+	properties := []*api.Property{fixtureProperty(property.TypePackage, property.Package{PackageName: packageName, Version: version})}
+	for _, gvk := range providedAPIs {
+		properties = append(properties, fixtureProperty(property.TypeGVK, property.GVK{Group: gvk.Group, Version: gvk.Version, Kind: gvk.Kind}))
+	}
+	var dependencies []*api.Dependency
+	if p > 0 {
+		dependency := fixtureProperty(property.TypePackage, property.Package{PackageName: fixturePackageName(p - 1), Version: ">=1.0.0"})
+		dependencies = append(dependencies, &api.Dependency{Type: dependency.Type, Value: dependency.Value})
+	}
+	return &api.Bundle{
+		CsvName:      csvName,
+		PackageName:  packageName,
+		ChannelName:  fixtureChannelName(c),
+		BundlePath:   fmt.Sprintf("quay.io/fixture/%s-bundle:v%s", packageName, version),
+		Version:      version,
+		Replaces:     replaces,
+		ProvidedApis: providedAPIs,
+		Properties:   properties,
+		Dependencies: dependencies,
+		CsvJson:      fixtureCSV(packageName, csvName),
+	}
+}
+
+func fixtureProperty(propertyType string, value interface{}) *api.Property {
+	data, err := json.Marshal(value)
+	if err != nil {
+		panic(err)
+	}
+	return &api.Property{Type: propertyType, Value: string(data)}
+}
+
+// fixtureCSV returns a ClusterServiceVersion about as large as a typical operator's
+func fixtureCSV(packageName string, csvName string) string {
+	csv := map[string]interface{}{
+		"apiVersion": "operators.coreos.com/v1alpha1",
+		"kind":       "ClusterServiceVersion",
+		"metadata": map[string]interface{}{
+			"name": csvName,
+			"annotations": map[string]string{
+				categoriesAnnotation:   "Database, Monitoring",
+				capabilitiesAnnotation: "Basic Install",
+				"alm-examples":         strings.Repeat(`{"apiVersion":"v1","kind":"Widget","spec":{"size":3}}`, 20),
+			},
+		},
+		"spec": map[string]interface{}{
+			"displayName": strings.ToUpper(packageName),
+			"description": strings.Repeat(fmt.Sprintf("%s manages widgets on the cluster. ", packageName), 40),
+			"keywords":    []string{"widget", packageName},
+			"provider":    map[string]string{"name": "Fixture"},
+			"icon":        []Icon{{MediaType: "image/png", Data: strings.Repeat("iVBORw0KGgo", 200)}},
+		},
+	}
+	data, err := json.Marshal(csv)
+	if err != nil {
+		panic(err)
+	}
+	return string(data)
+}
+
+func (r *fixtureRepository) Connect(context.Context) error {
+	return nil
+}
+
+func (r *fixtureRepository) Close() error {
+	return nil
+}
+
+func (r *fixtureRepository) Source() string {
+	return r.source
+}
+
+func (r *fixtureRepository) Digest() string {
+	return r.digest
+}
+
+func (r *fixtureRepository) ListBundles(context.Context) (*client.BundleIterator, error) {
+	return client.NewBundleIterator(&fixtureBundleStream{bundles: r.bundles}), nil
+}
+
+func (r *fixtureRepository) GetPackage(_ context.Context, packageName string) (*api.Package, error) {
+	pkg, ok := r.packages[packageName]
+	if !ok {
+		return nil, fmt.Errorf("package %s not found", packageName)
+	}
+	return pkg, nil
+}
+
+func (r *fixtureRepository) GetBundle(context.Context, string, string, string) (*api.Bundle, error) {
+	return nil, fmt.Errorf("not implemented")
+}
+
+func (r *fixtureRepository) GetBundleInPackageChannel(context.Context, string, string) (*api.Bundle, error) {
+	return nil, fmt.Errorf("not implemented")
+}
+
+func (r *fixtureRepository) GetReplacementBundleInPackageChannel(context.Context, string, string, string) (*api.Bundle, error) {
+	return nil, fmt.Errorf("not implemented")
+}
+
+func (r *fixtureRepository) GetBundleThatProvides(context.Context, string, string, string) (*api.Bundle, error) {
+	return nil, fmt.Errorf("not implemented")
+}
+
+func (r *fixtureRepository) HealthCheck(context.Context, time.Duration) (bool, error) {
+	return true, nil
+}
+
+type fixtureBundleStream struct {
+	bundles []*api.Bundle
+	next    int
+}
+
+func (s *fixtureBundleStream) Recv() (*api.Bundle, error) {
+	if s.next == len(s.bundles) {
+		return nil, io.EOF
+	}
+	s.next++
+	return s.bundles[s.next-1], nil
+}
+
+func testLogger() *logrus.Logger {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	return logger
+}
+
+// openTestDatabase opens a package database of the backend in a temporary directory, closed when the test ends
+func openTestDatabase(tb testing.TB, backend string, options ...DatabaseOption) PackageDatabase {
+	tb.Helper()
+	packageDatabase, err := OpenPackageDatabase(backend, tb.TempDir(), testLogger(), options...)
+	if err != nil {
+		tb.Fatalf("error opening %s database: %v", backend, err)
+	}
+	tb.Cleanup(func() {
+		packageDatabase.Close()
+	})
+	return packageDatabase
+}
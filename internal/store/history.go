@@ -0,0 +1,86 @@
+package store
+
+import (
+	"context"
+	"time"
+)
+
+const (
+	historyBucket = "history"
+
+	// operationIDFormat sorts lexicographically in chronological order
+	operationIDFormat = "20060102150405.000000000"
+)
+
+type OperationType string
+
+const (
+	OperationInstall OperationType = "install"
+	OperationUpdate  OperationType = "update"
+	OperationApply   OperationType = "apply"
+)
+
+type OperationOutcome string
+
+const (
+	OperationSucceeded OperationOutcome = "succeeded"
+	OperationFailed    OperationOutcome = "failed"
+)
+
+// CachedOperation records an operation performed against a cluster
+type CachedOperation struct {
+	OperationID       string            `json:"id"`
+	Timestamp         time.Time         `json:"timestamp"`
+	Type              OperationType     `json:"type"`
+	RequestedPackages []string          `json:"requestedPackages,omitempty"`
+	Plan              []OperationBundle `json:"plan,omitempty"`
+	Cluster           string            `json:"cluster,omitempty"`
+	Context           string            `json:"context,omitempty"`
+	Outcome           OperationOutcome  `json:"outcome"`
+	Error             string            `json:"error,omitempty"`
+}
+
+// OperationBundle is a single step of an operation's plan
+type OperationBundle struct {
+	Action      string `json:"action"`
+	PackageName string `json:"packageName"`
+	BundleID    string `json:"bundleId,omitempty"`
+	FromVersion string `json:"fromVersion,omitempty"`
+	ToVersion   string `json:"toVersion,omitempty"`
+}
+
+func (c CachedOperation) EntryID() string {
+	return c.OperationID
+}
+
+// NewOperation creates an operation of the given type stamped with the current time
+func NewOperation(operationType OperationType) *CachedOperation {
+	now := time.Now().UTC()
+	return &CachedOperation{
+		OperationID: now.Format(operationIDFormat),
+		Timestamp:   now,
+		Type:        operationType,
+	}
+}
+
+// Finish records the outcome of the operation
+func (c *CachedOperation) Finish(err error) {
+	if err != nil {
+		c.Outcome = OperationFailed
+		c.Error = err.Error()
+		return
+	}
+	c.Outcome = OperationSucceeded
+}
+
+func (b *boltPackageDatabase) RecordOperation(_ context.Context, operation *CachedOperation) error {
+	return b.historyTable.Insert(operation)
+}
+
+func (b *boltPackageDatabase) ListOperations(_ context.Context) ([]CachedOperation, error) {
+	return b.historyTable.List()
+}
+
+func (b *boltPackageDatabase) GetOperation(_ context.Context, operationID string) (*CachedOperation, error) {
+	return b.historyTable.Get(operationID)
+}
@@ -0,0 +1,85 @@
+package store
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/operator-framework/operator-registry/alpha/property"
+)
+
+// BundleIndex names a secondary index of the bundles table
+type BundleIndex string
+
+const (
+	// BundlesByPackage indexes bundles by package name across repositories
+	BundlesByPackage BundleIndex = "package"
+	// BundlesByChannel indexes bundles by <package>/<channel>
+	BundlesByChannel BundleIndex = "channel"
+	// BundlesByImage indexes bundles by bundle image
+	BundlesByImage BundleIndex = "image"
+	// BundlesByCSVName indexes bundles by ClusterServiceVersion name
+	BundlesByCSVName BundleIndex = "csv"
+	// BundlesByProperty indexes bundles by provided property, see PropertyIndexKey
+	BundlesByProperty BundleIndex = "property"
+)
+
+// bundleIndexFunctions returns the key functions of the bundle indexes, shared by every backend
+func bundleIndexFunctions() map[BundleIndex]IndexFunction[CachedBundle] {
+	return map[BundleIndex]IndexFunction[CachedBundle]{
+		BundlesByPackage: func(bundle *CachedBundle) []string {
+			return []string{bundle.PackageName}
+		},
+		BundlesByChannel: func(bundle *CachedBundle) []string {
+			return []string{ChannelIndexKey(bundle.PackageName, bundle.ChannelName)}
+		},
+		BundlesByImage: func(bundle *CachedBundle) []string {
+			if bundle.BundlePath == "" {
+				return nil
+			}
+			return []string{bundle.BundlePath}
+		},
+		BundlesByCSVName: func(bundle *CachedBundle) []string {
+			return []string{bundle.CsvName}
+		},
+		BundlesByProperty: func(bundle *CachedBundle) []string {
+			keys := make([]string, 0, len(bundle.Properties))
+			for _, prop := range bundle.Properties {
+				keys = append(keys, PropertyIndexKey(prop.GetType(), prop.GetValue()))
+			}
+			return keys
+		},
+	}
+}
+
+func bundleIndexes() []TableOption[CachedBundle] {
+	var options []TableOption[CachedBundle]
+	for name, keys := range bundleIndexFunctions() {
+		options = append(options, WithIndex[CachedBundle](string(name), keys))
+	}
+	return options
+}
+
+// ChannelIndexKey returns the BundlesByChannel key of a package's channel
+func ChannelIndexKey(packageName string, channelName string) string {
+	return strings.Join([]string{packageName, channelName}, keySeparator)
+}
+
+// PropertyIndexKey returns the BundlesByProperty key of a property. Package properties are keyed
+// by package name (olm.package/<package>) and gvk properties by gvk (olm.gvk/<group>/<version>/<kind>),
+// so bundles providing any version of a package or gvk can be found with a single seek. Other
+// properties are keyed by their raw value
+func PropertyIndexKey(propertyType string, value string) string {
+	switch propertyType {
+	case property.TypePackage:
+		pkg := &property.Package{}
+		if err := json.Unmarshal([]byte(value), pkg); err == nil {
+			return strings.Join([]string{propertyType, pkg.PackageName}, keySeparator)
+		}
+	case property.TypeGVK:
+		gvk := &property.GVK{}
+		if err := json.Unmarshal([]byte(value), gvk); err == nil {
+			return strings.Join([]string{propertyType, gvk.Group, gvk.Version, gvk.Kind}, keySeparator)
+		}
+	}
+	return strings.Join([]string{propertyType, value}, keySeparator)
+}
@@ -0,0 +1,74 @@
+package store
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/blang/semver/v4"
+)
+
+const warningsBucket = "warnings"
+
+// CachedWarning is a problem found in a bundle when its repository was cached. Bundles with
+// warnings are still cached: invalid versions sort below valid ones and invalid dependencies
+// can't be satisfied during resolution
+type CachedWarning struct {
+	WarningID  string `json:"id"`
+	Repository string `json:"repository"`
+	BundleID   string `json:"bundleId"`
+	Field      string `json:"field"`
+	Message    string `json:"message"`
+}
+
+func (c CachedWarning) EntryID() string {
+	return c.WarningID
+}
+
+// lintBundle validates the bundle fields resolution depends on
+func lintBundle(bundle *CachedBundle, constraintProblems []error) []CachedWarning {
+	var warnings []CachedWarning
+	warn := func(field string, format string, args ...interface{}) {
+		warnings = append(warnings, CachedWarning{
+			WarningID:  fmt.Sprintf("%s%s%04d", bundle.BundleID, keySeparator, len(warnings)),
+			Repository: bundle.Repository,
+			BundleID:   bundle.BundleID,
+			Field:      field,
+			Message:    fmt.Sprintf(format, args...),
+		})
+	}
+
+	if bundle.Version == "" {
+		warn("version", "bundle has no version")
+	} else if _, err := semver.Parse(bundle.Version); err != nil {
+		warn("version", "invalid version %q: %s", bundle.Version, err)
+	}
+	if bundle.SkipRange != "" {
+		if _, err := semver.ParseRange(bundle.SkipRange); err != nil {
+			warn("skipRange", "invalid skip range %q: %s", bundle.SkipRange, err)
+		}
+	}
+	for _, dependency := range bundle.PackageDependencies {
+		if _, err := semver.ParseRange(dependency.Version); err != nil {
+			warn("dependencies", "invalid version range %q for package dependency %s: %s", dependency.Version, dependency.PackageName, err)
+		}
+	}
+	for _, problem := range constraintProblems {
+		warn("constraints", "%s", problem)
+	}
+	return warnings
+}
+
+func (b *boltPackageDatabase) ListWarnings(_ context.Context, repositories ...string) ([]CachedWarning, error) {
+	if len(repositories) == 0 {
+		return b.warningTable.List()
+	}
+	var warnings []CachedWarning
+	for _, repository := range repositories {
+		repositoryWarnings, err := b.warningTable.Seek(repository + keySeparator)
+		if err != nil {
+			return nil, err
+		}
+		warnings = append(warnings, repositoryWarnings...)
+	}
+	return warnings, nil
+}
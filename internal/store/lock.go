@@ -0,0 +1,60 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// ErrDatabaseLocked is returned when the package database is still locked by another
+// process once the open timeout expires
+var ErrDatabaseLocked = errors.New("package database is locked")
+
+// lockOwnerPath is the file recording the pid of the process that has the database open for writing.
+// The database file itself is locked with flock, which doesn't tell who holds the lock. Readers aren't
+// recorded and a writer that crashed leaves its pid behind, so the recorded pid may not hold the lock
+func lockOwnerPath(databasePath string) string {
+	return databasePath + ".lock"
+}
+
+func writeLockOwner(databasePath string) error {
+	return os.WriteFile(lockOwnerPath(databasePath), []byte(strconv.Itoa(os.Getpid())), 0600)
+}
+
+// removeLockOwner removes the lock file if it still records this process
+func removeLockOwner(databasePath string) {
+	if pid, err := readLockOwner(databasePath); err == nil && pid == os.Getpid() {
+		_ = os.Remove(lockOwnerPath(databasePath))
+	}
+}
+
+func readLockOwner(databasePath string) (int, error) {
+	data, err := os.ReadFile(lockOwnerPath(databasePath))
+	if err != nil {
+		return 0, err
+	}
+	return strconv.Atoi(strings.TrimSpace(string(data)))
+}
+
+// processAlive reports whether a process with the pid is running
+func processAlive(pid int) bool {
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	// signal 0 only checks that the process exists and may be signaled
+	err = process.Signal(syscall.Signal(0))
+	return err == nil || errors.Is(err, syscall.EPERM)
+}
+
+// lockedError names the recorded writer only while it is running, the lock is otherwise held by
+// readers or by a process that didn't record itself
+func lockedError(databasePath string) error {
+	if pid, err := readLockOwner(databasePath); err == nil && processAlive(pid) {
+		return fmt.Errorf("%w by pid %d (%s)", ErrDatabaseLocked, pid, databasePath)
+	}
+	return fmt.Errorf("%w by another process (%s)", ErrDatabaseLocked, databasePath)
+}
@@ -0,0 +1,71 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLockedError(t *testing.T) {
+	// a process that has exited leaves a stale pid behind
+	exited := exec.Command("true")
+	if err := exited.Run(); err != nil {
+		t.Skipf("can't run a process: %v", err)
+	}
+
+	for _, tt := range []struct {
+		name     string
+		owner    string
+		expected string
+	}{
+		{name: "running writer", owner: strconv.Itoa(os.Getpid()), expected: fmt.Sprintf("by pid %d", os.Getpid())},
+		{name: "crashed writer", owner: strconv.Itoa(exited.Process.Pid), expected: "by another process"},
+		{name: "readers only", expected: "by another process"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			databasePath := path.Join(t.TempDir(), "olm.db")
+			if tt.owner != "" {
+				if err := os.WriteFile(lockOwnerPath(databasePath), []byte(tt.owner), 0600); err != nil {
+					t.Fatal(err)
+				}
+			}
+			err := lockedError(databasePath)
+			if !errors.Is(err, ErrDatabaseLocked) {
+				t.Errorf("expected ErrDatabaseLocked, got %v", err)
+			}
+			if !strings.Contains(err.Error(), tt.expected) {
+				t.Errorf("expected the error to say %q, got %q", tt.expected, err)
+			}
+		})
+	}
+}
+
+func TestFailedOpenReleasesLock(t *testing.T) {
+	databasePath := path.Join(t.TempDir(), "olm.db")
+	cacheMigrationFixture(t, databasePath)
+
+	// a later schema version whose content can't be upgraded in place makes opening fail
+	current := migrations
+	migrations = append(append([]Migration(nil), current...), Migration{Version: len(current) + 1, Description: "rebuild"})
+	defer func() {
+		migrations = current
+	}()
+
+	if _, err := NewPackageDatabase(databasePath, testLogger()); !errors.Is(err, ErrRebuildRequired) {
+		t.Fatalf("expected opening the database to require a rebuild, got %v", err)
+	}
+	if _, err := os.Stat(lockOwnerPath(databasePath)); !os.IsNotExist(err) {
+		t.Errorf("expected the lock owner file to be removed, got %v", err)
+	}
+	packageDatabase, err := NewPackageDatabase(databasePath, testLogger(), WithoutMigration(), WithOpenTimeout(100*time.Millisecond))
+	if err != nil {
+		t.Fatalf("expected the database to be unlocked, got %v", err)
+	}
+	packageDatabase.Close()
+}
@@ -0,0 +1,156 @@
+package store
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+)
+
+func init() {
+	RegisterBackend("memory", func(_ string, logger *logrus.Logger, options ...DatabaseOption) (PackageDatabase, error) {
+		return NewMemoryPackageDatabase(logger, options...)
+	})
+}
+
+// NewMemoryPackageDatabase creates an empty package database that only lives as long as the process.
+// It is meant for tests and ephemeral runs that don't need to keep a cache between invocations
+func NewMemoryPackageDatabase(logger *logrus.Logger, options ...DatabaseOption) (PackageDatabase, error) {
+	return newDocumentPackageDatabase(&memoryStore{collections: map[string]map[string][]byte{}}, logger, options...)
+}
+
+var _ documentStore = &memoryStore{}
+
+// memoryStore keeps the documents in maps. Updates are copy-on-write: a transaction copies the
+// collections it modifies and swaps them in when it commits, so readers see a consistent
+// snapshot without holding a lock while they run
+type memoryStore struct {
+	lock        sync.Mutex
+	writeLock   sync.Mutex
+	collections map[string]map[string][]byte
+	closed      bool
+}
+
+func (m *memoryStore) snapshot() (map[string]map[string][]byte, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	if m.closed {
+		return nil, fmt.Errorf("database is closed")
+	}
+	return m.collections, nil
+}
+
+func (m *memoryStore) View(fn func(tx documentTx) error) error {
+	collections, err := m.snapshot()
+	if err != nil {
+		return err
+	}
+	return fn(&memoryTx{collections: collections})
+}
+
+func (m *memoryStore) Update(fn func(tx documentTx) error) error {
+	// writers are serialized so no update is lost when transactions are swapped in
+	m.writeLock.Lock()
+	defer m.writeLock.Unlock()
+
+	collections, err := m.snapshot()
+	if err != nil {
+		return err
+	}
+	tx := &memoryTx{
+		collections: make(map[string]map[string][]byte, len(collections)),
+		writable:    true,
+		copied:      map[string]struct{}{},
+	}
+	for name, documents := range collections {
+		tx.collections[name] = documents
+	}
+	if err := fn(tx); err != nil {
+		return err
+	}
+
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.collections = tx.collections
+	return nil
+}
+
+func (m *memoryStore) Close() error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.closed = true
+	m.collections = nil
+	return nil
+}
+
+type memoryTx struct {
+	collections map[string]map[string][]byte
+	writable    bool
+	// copied holds the collections already copied by this transaction
+	copied map[string]struct{}
+}
+
+func (t *memoryTx) Get(collection string, key string) ([]byte, error) {
+	return t.collections[collection][key], nil
+}
+
+func (t *memoryTx) Put(collection string, key string, value []byte) error {
+	documents, err := t.writableCollection(collection)
+	if err != nil {
+		return err
+	}
+	documents[key] = append([]byte(nil), value...)
+	return nil
+}
+
+func (t *memoryTx) Delete(collection string, key string) error {
+	if _, ok := t.collections[collection][key]; !ok {
+		return nil
+	}
+	documents, err := t.writableCollection(collection)
+	if err != nil {
+		return err
+	}
+	delete(documents, key)
+	return nil
+}
+
+func (t *memoryTx) Scan(collection string, prefix string, fn func(key string, value []byte) error) error {
+	documents := t.collections[collection]
+	keys := make([]string, 0, len(documents))
+	for key := range documents {
+		if strings.HasPrefix(key, prefix) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	// values are collected before fn is called since fn may modify the collection
+	values := make([][]byte, 0, len(keys))
+	for _, key := range keys {
+		values = append(values, documents[key])
+	}
+	for index, key := range keys {
+		if err := fn(key, values[index]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writableCollection returns the transaction's copy of the collection, copying it on first write
+func (t *memoryTx) writableCollection(collection string) (map[string][]byte, error) {
+	if !t.writable {
+		return nil, fmt.Errorf("transaction is not writable")
+	}
+	if _, ok := t.copied[collection]; !ok {
+		documents := make(map[string][]byte, len(t.collections[collection]))
+		for key, value := range t.collections[collection] {
+			documents[key] = value
+		}
+		t.collections[collection] = documents
+		t.copied[collection] = struct{}{}
+	}
+	return t.collections[collection], nil
+}
@@ -0,0 +1,309 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/boltdb/bolt"
+)
+
+const (
+	metadataBucket   = "metadata"
+	schemaVersionKey = "schemaVersion"
+)
+
+// ErrRebuildRequired is returned when the database schema can't be upgraded in place
+// and the package database needs to be rebuilt from its repository sources
+var ErrRebuildRequired = errors.New("package database needs to be rebuilt: run 'olm db rebuild'")
+
+// Migration upgrades the database schema from Version-1 to Version
+type Migration struct {
+	Version     int
+	Description string
+	// Migrate upgrades the database in place. It is nil if the cached content
+	// can't be upgraded in place and must be rebuilt from the repository sources
+	Migrate func(tx *bolt.Tx) error
+}
+
+// migrations must be ordered by version, starting at 1, without gaps
+var migrations = []Migration{
+	{
+		Version:     1,
+		Description: "add schema version",
+		Migrate: func(tx *bolt.Tx) error {
+			// databases created before schema versioning only lack the version marker
+			return nil
+		},
+	},
+	{
+		Version:     2,
+		Description: "compress bundles and store bundle references in the gvk index",
+		Migrate:     compressBundlesAndReferenceGVKBundles,
+	},
+	{
+		Version:     3,
+		Description: "add secondary indexes to the bundles table",
+		Migrate: func(tx *bolt.Tx) error {
+			bundleTable, err := NewBoltDBTable[CachedBundle](tx.DB(), bundlesBucket, bundleTableOptions()...)
+			if err != nil {
+				return err
+			}
+			return bundleTable.RebuildIndexesInTransaction(tx)
+		},
+	},
+	{
+		Version:     4,
+		Description: "store csv metadata on bundles and packages",
+		Migrate:     extractCSVMetadata,
+	},
+	{
+		Version:     5,
+		Description: "store olm.constraint and olm.gvk.required dependencies on bundles",
+		Migrate: func(tx *bolt.Tx) error {
+			return updateBundles(tx, func(bundle *CachedBundle) error {
+				bundle.Constraints, _ = bundleConstraints(bundle.Bundle)
+				return nil
+			})
+		},
+	},
+	{
+		Version:     6,
+		Description: "record bundle validation warnings",
+		Migrate:     lintBundles,
+	},
+	{
+		Version:     7,
+		Description: "keep icons on packages only and drop embedded documents from csv annotations",
+		Migrate:     extractCSVMetadata,
+	},
+}
+
+// CurrentSchemaVersion is the schema version written by this version of olm
+func CurrentSchemaVersion() int {
+	return migrations[len(migrations)-1].Version
+}
+
+// readSchemaVersion returns the schema version of the database. Databases without a version
+// marker are either new (and get the current version) or predate schema versioning (version 0)
+func readSchemaVersion(tx *bolt.Tx) int {
+	metadata := tx.Bucket([]byte(metadataBucket))
+	if metadata == nil {
+		if tx.Bucket([]byte(repositoriesBucket)) != nil {
+			return 0
+		}
+		return CurrentSchemaVersion()
+	}
+	version, err := strconv.Atoi(string(metadata.Get([]byte(schemaVersionKey))))
+	if err != nil {
+		return 0
+	}
+	return version
+}
+
+func writeSchemaVersion(tx *bolt.Tx, version int) error {
+	metadata, err := tx.CreateBucketIfNotExists([]byte(metadataBucket))
+	if err != nil {
+		return err
+	}
+	return metadata.Put([]byte(schemaVersionKey), []byte(strconv.Itoa(version)))
+}
+
+// pendingMigrations returns the migrations needed to upgrade a database at the given version
+func pendingMigrations(version int) ([]Migration, error) {
+	if version > CurrentSchemaVersion() {
+		return nil, fmt.Errorf("package database schema version %d is newer than the supported version %d: upgrade olm", version, CurrentSchemaVersion())
+	}
+	var pending []Migration
+	for _, migration := range migrations {
+		if migration.Version > version {
+			pending = append(pending, migration)
+		}
+	}
+	return pending, nil
+}
+
+func (b *boltPackageDatabase) SchemaVersion(_ context.Context) (int, error) {
+	var version int
+	err := b.database.View(func(tx *bolt.Tx) error {
+		version = readSchemaVersion(tx)
+		return nil
+	})
+	return version, err
+}
+
+// Migrate upgrades the database to the current schema version. If any pending migration can't be
+// applied in place, nothing is changed and ErrRebuildRequired is returned
+func (b *boltPackageDatabase) Migrate(_ context.Context) error {
+	return b.database.Update(func(tx *bolt.Tx) error {
+		version := readSchemaVersion(tx)
+		pending, err := pendingMigrations(version)
+		if err != nil {
+			return err
+		}
+		for _, migration := range pending {
+			if migration.Migrate == nil {
+				return fmt.Errorf("migration to schema version %d (%s) can't be applied in place: %w", migration.Version, migration.Description, ErrRebuildRequired)
+			}
+		}
+		for _, migration := range pending {
+			b.logger.Debugf("Migrating package database to schema version %d: %s", migration.Version, migration.Description)
+			if err := migration.Migrate(tx); err != nil {
+				return fmt.Errorf("error migrating package database to schema version %d: %w", migration.Version, err)
+			}
+		}
+		return writeSchemaVersion(tx, CurrentSchemaVersion())
+	})
+}
+
+// Reset removes all cached packages and bundles, keeping the repository records and the
+// install history, and stamps the database with the current schema version. The repositories
+// must be cached again to repopulate the database
+func (b *boltPackageDatabase) Reset(_ context.Context) error {
+	return b.database.Update(b.resetInTransaction)
+}
+
+func (b *boltPackageDatabase) resetInTransaction(tx *bolt.Tx) error {
+	for _, bucket := range []string{packagesBucket, bundlesBucket, gvkBucket, warningsBucket} {
+		if err := tx.DeleteBucket([]byte(bucket)); err != nil && !errors.Is(err, bolt.ErrBucketNotFound) {
+			return err
+		}
+		if _, err := tx.CreateBucket([]byte(bucket)); err != nil {
+			return err
+		}
+	}
+	// the bundle indexes would otherwise reference the removed bundles
+	if err := b.bundleTable.RebuildIndexesInTransaction(tx); err != nil {
+		return err
+	}
+	return writeSchemaVersion(tx, CurrentSchemaVersion())
+}
+
+func compressBundlesAndReferenceGVKBundles(tx *bolt.Tx) error {
+	legacyCodec := JSONCodec[CachedBundle]{}
+	if err := recodeBucket(tx, bundlesBucket, func(data []byte) ([]byte, error) {
+		bundle, err := legacyCodec.Decode(data)
+		if err != nil {
+			return nil, err
+		}
+		return bundleCodec().Encode(bundle)
+	}); err != nil {
+		return err
+	}
+
+	// legacy gvk entries embed the whole bundle, whose id is serialized as "id"
+	gvkCodec := JSONCodec[CachedGVKBundle]{}
+	return recodeBucket(tx, gvkBucket, func(data []byte) ([]byte, error) {
+		legacyGVKBundle := &struct {
+			GVKID    string `json:"gvkId"`
+			GVK      string `json:"gvk"`
+			BundleID string `json:"id"`
+		}{}
+		if err := json.Unmarshal(data, legacyGVKBundle); err != nil {
+			return nil, err
+		}
+		return gvkCodec.Encode(&CachedGVKBundle{
+			GVKID:    legacyGVKBundle.GVKID,
+			GVK:      legacyGVKBundle.GVK,
+			BundleID: legacyGVKBundle.BundleID,
+		})
+	})
+}
+
+func extractCSVMetadata(tx *bolt.Tx) error {
+	packageTable, err := NewBoltDBTable[CachedPackage](tx.DB(), packagesBucket)
+	if err != nil {
+		return err
+	}
+	return updateBundles(tx, func(bundle *CachedBundle) error {
+		bundle.Metadata = bundleMetadata(bundle)
+		pkg, err := packageTable.GetInTransaction(tx, GetPackageKey(bundle.Repository, bundle.PackageName))
+		if err != nil {
+			return err
+		}
+		if pkg != nil && isPackageHead(pkg, bundle) {
+			pkg.Metadata = PackageMetadata(bundle)
+			return packageTable.InsertInTransaction(tx, pkg)
+		}
+		return nil
+	})
+}
+
+func lintBundles(tx *bolt.Tx) error {
+	if _, err := tx.CreateBucketIfNotExists([]byte(warningsBucket)); err != nil {
+		return err
+	}
+	warningTable, err := NewBoltDBTable[CachedWarning](tx.DB(), warningsBucket)
+	if err != nil {
+		return err
+	}
+	return updateBundles(tx, func(bundle *CachedBundle) error {
+		var constraintProblems []error
+		bundle.Constraints, constraintProblems = bundleConstraints(bundle.Bundle)
+		for _, warning := range lintBundle(bundle, constraintProblems) {
+			if err := warningTable.InsertInTransaction(tx, &warning); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// updateBundles applies fn to every cached bundle and writes the updated bundles back, keeping
+// the bundle indexes up to date
+func updateBundles(tx *bolt.Tx, fn func(bundle *CachedBundle) error) error {
+	bundleTable, err := NewBoltDBTable[CachedBundle](tx.DB(), bundlesBucket, bundleTableOptions()...)
+	if err != nil {
+		return err
+	}
+
+	// collect the bundles first, the bucket can't be modified while iterating over it
+	var bundles []CachedBundle
+	if err := tx.Bucket([]byte(bundlesBucket)).ForEach(func(_, value []byte) error {
+		bundle, err := bundleCodec().Decode(value)
+		if err != nil {
+			return err
+		}
+		bundles = append(bundles, *bundle)
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	for index := range bundles {
+		if err := fn(&bundles[index]); err != nil {
+			return fmt.Errorf("error updating bundle %s: %w", bundles[index].BundleID, err)
+		}
+		if err := bundleTable.InsertInTransaction(tx, &bundles[index]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// recodeBucket rewrites every value in the bucket with the output of fn
+func recodeBucket(tx *bolt.Tx, bucketName string, fn func(data []byte) ([]byte, error)) error {
+	bucket := tx.Bucket([]byte(bucketName))
+	if bucket == nil {
+		return nil
+	}
+	recoded := map[string][]byte{}
+	if err := bucket.ForEach(func(key, value []byte) error {
+		data, err := fn(value)
+		if err != nil {
+			return fmt.Errorf("error recoding %s entry %s: %w", bucketName, key, err)
+		}
+		recoded[string(key)] = data
+		return nil
+	}); err != nil {
+		return err
+	}
+	for key, value := range recoded {
+		if err := bucket.Put([]byte(key), value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,255 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"path"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/boltdb/bolt"
+	"github.com/operator-framework/operator-registry/alpha/property"
+)
+
+// cacheMigrationFixture caches a fixture repository in a new bolt database at databasePath, one of whose
+// bundles has a required gvk and an invalid skip range, and returns the cached bundles and packages
+func cacheMigrationFixture(t *testing.T, databasePath string) ([]CachedBundle, []CachedPackage) {
+	t.Helper()
+	ctx := context.Background()
+	repo := newFixtureRepository(firstRepositorySource, 2, 2, 2)
+	repo.bundles[0].SkipRange = "not-a-range"
+	repo.bundles[0].Properties = append(repo.bundles[0].Properties, fixtureProperty(property.TypeGVKRequired, property.GVKRequired{Group: "required.example.com", Version: "v1", Kind: "Required"}))
+
+	packageDatabase, err := NewPackageDatabase(databasePath, testLogger())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer packageDatabase.Close()
+	if err := packageDatabase.CacheRepository(ctx, repo); err != nil {
+		t.Fatal(err)
+	}
+	bundles, err := packageDatabase.ListBundles(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	packages, err := packageDatabase.ListPackages(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return bundles, packages
+}
+
+// downgradeDatabase rewrites the bolt database at databasePath in the format of the given schema version
+func downgradeDatabase(t *testing.T, databasePath string, version int) {
+	t.Helper()
+	db, err := bolt.Open(databasePath, 0600, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		if version < 6 {
+			if err := tx.DeleteBucket([]byte(warningsBucket)); err != nil {
+				return err
+			}
+		}
+		if err := recodeBucket(tx, bundlesBucket, func(data []byte) ([]byte, error) {
+			bundle, err := bundleCodec().Decode(data)
+			if err != nil {
+				return nil, err
+			}
+			if version < 5 {
+				bundle.Constraints = nil
+			}
+			if version < 4 {
+				bundle.Metadata = nil
+			}
+			if version < 2 {
+				return JSONCodec[CachedBundle]{}.Encode(bundle)
+			}
+			return bundleCodec().Encode(bundle)
+		}); err != nil {
+			return err
+		}
+		if version < 4 {
+			if err := recodeBucket(tx, packagesBucket, func(data []byte) ([]byte, error) {
+				pkg, err := JSONCodec[CachedPackage]{}.Decode(data)
+				if err != nil {
+					return nil, err
+				}
+				pkg.Metadata = nil
+				return JSONCodec[CachedPackage]{}.Encode(pkg)
+			}); err != nil {
+				return err
+			}
+		}
+		if version < 3 {
+			var indexBuckets [][]byte
+			if err := tx.ForEach(func(name []byte, _ *bolt.Bucket) error {
+				if strings.HasPrefix(string(name), bundlesBucket+".index.") {
+					indexBuckets = append(indexBuckets, append([]byte(nil), name...))
+				}
+				return nil
+			}); err != nil {
+				return err
+			}
+			for _, name := range indexBuckets {
+				if err := tx.DeleteBucket(name); err != nil {
+					return err
+				}
+			}
+		}
+		if version < 2 {
+			// legacy gvk entries embed the whole bundle
+			bundles := tx.Bucket([]byte(bundlesBucket))
+			if err := recodeBucket(tx, gvkBucket, func(data []byte) ([]byte, error) {
+				gvkBundle, err := JSONCodec[CachedGVKBundle]{}.Decode(data)
+				if err != nil {
+					return nil, err
+				}
+				legacyGVKBundle := map[string]interface{}{}
+				if err := json.Unmarshal(bundles.Get([]byte(gvkBundle.BundleID)), &legacyGVKBundle); err != nil {
+					return nil, err
+				}
+				legacyGVKBundle["gvkId"] = gvkBundle.GVKID
+				legacyGVKBundle["gvk"] = gvkBundle.GVK
+				return json.Marshal(legacyGVKBundle)
+			}); err != nil {
+				return err
+			}
+		}
+		if version == 0 {
+			return tx.DeleteBucket([]byte(metadataBucket))
+		}
+		return tx.Bucket([]byte(metadataBucket)).Put([]byte(schemaVersionKey), []byte(strconv.Itoa(version)))
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func sortedBundles(bundles []CachedBundle) []CachedBundle {
+	sort.Slice(bundles, func(i, j int) bool {
+		return bundles[i].BundleID < bundles[j].BundleID
+	})
+	return bundles
+}
+
+func TestMigrate(t *testing.T) {
+	ctx := context.Background()
+	for version := 0; version < CurrentSchemaVersion(); version++ {
+		t.Run("from version "+strconv.Itoa(version), func(t *testing.T) {
+			databasePath := path.Join(t.TempDir(), "olm.db")
+			expectedBundles, expectedPackages := cacheMigrationFixture(t, databasePath)
+			downgradeDatabase(t, databasePath, version)
+
+			legacy, err := NewPackageDatabase(databasePath, testLogger(), WithoutMigration())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if schemaVersion, err := legacy.SchemaVersion(ctx); err != nil || schemaVersion != version {
+				t.Fatalf("expected schema version %d, got %d (%v)", version, schemaVersion, err)
+			}
+			legacy.Close()
+
+			packageDatabase, err := NewPackageDatabase(databasePath, testLogger())
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer packageDatabase.Close()
+			if schemaVersion, err := packageDatabase.SchemaVersion(ctx); err != nil || schemaVersion != CurrentSchemaVersion() {
+				t.Fatalf("expected schema version %d, got %d (%v)", CurrentSchemaVersion(), schemaVersion, err)
+			}
+
+			bundles, err := packageDatabase.ListBundles(ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(sortedBundles(bundles), sortedBundles(expectedBundles)) {
+				t.Errorf("expected the migrated bundles to match the cached bundles")
+			}
+			packages, err := packageDatabase.ListPackages(ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(packages, expectedPackages) {
+				t.Errorf("expected the migrated packages to match the cached packages")
+			}
+
+			for index, keys := range bundleIndexFunctions() {
+				for _, bundle := range expectedBundles {
+					for _, key := range keys(&bundle) {
+						indexed, err := packageDatabase.LookupBundles(ctx, index, key)
+						if err != nil {
+							t.Fatal(err)
+						}
+						if !contains(bundleIDs(indexed), bundle.BundleID) {
+							t.Errorf("expected index %s to reference %s under %s", index, bundle.BundleID, key)
+						}
+					}
+				}
+			}
+			gvkBundles, err := packageDatabase.ListBundlesForGVK(ctx, fixtureGVK.Group, fixtureGVK.Version, fixtureGVK.Kind)
+			expectCount(t, "bundles providing the fixture gvk", gvkBundles, err, len(expectedBundles))
+			warnings, err := packageDatabase.ListWarnings(ctx)
+			expectCount(t, "warnings", warnings, err, 1)
+		})
+	}
+}
+
+func TestMigrateRebuildRequired(t *testing.T) {
+	ctx := context.Background()
+	databasePath := path.Join(t.TempDir(), "olm.db")
+	expectedBundles, _ := cacheMigrationFixture(t, databasePath)
+
+	// a later schema version whose content can't be upgraded in place
+	current := migrations
+	migrations = append(append([]Migration(nil), current...), Migration{Version: len(current) + 1, Description: "rebuild"})
+	defer func() {
+		migrations = current
+	}()
+
+	if _, err := NewPackageDatabase(databasePath, testLogger()); !errors.Is(err, ErrRebuildRequired) {
+		t.Fatalf("expected opening the database to require a rebuild, got %v", err)
+	}
+
+	// the database is left unchanged, so it can still be inspected
+	packageDatabase, err := NewPackageDatabase(databasePath, testLogger(), WithoutMigration())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer packageDatabase.Close()
+	if schemaVersion, err := packageDatabase.SchemaVersion(ctx); err != nil || schemaVersion != len(current) {
+		t.Errorf("expected schema version %d, got %d (%v)", len(current), schemaVersion, err)
+	}
+	if err := packageDatabase.Migrate(ctx); !errors.Is(err, ErrRebuildRequired) {
+		t.Errorf("expected migrating the database to require a rebuild, got %v", err)
+	}
+	bundles, err := packageDatabase.ListBundles(ctx)
+	expectCount(t, "bundles", bundles, err, len(expectedBundles))
+
+	// resetting stamps the current version, so the repositories can be cached again
+	if err := packageDatabase.Reset(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if schemaVersion, err := packageDatabase.SchemaVersion(ctx); err != nil || schemaVersion != CurrentSchemaVersion() {
+		t.Errorf("expected schema version %d after reset, got %d (%v)", CurrentSchemaVersion(), schemaVersion, err)
+	}
+	if err := packageDatabase.Migrate(ctx); err != nil {
+		t.Errorf("expected a reset database to need no migration, got %v", err)
+	}
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -5,12 +5,15 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"os"
+	"path"
 	"regexp"
 	"strings"
 	"time"
 
 	"github.com/blang/semver/v4"
 	"github.com/boltdb/bolt"
+	"github.com/operator-framework/api/pkg/constraints"
 	v2 "github.com/operator-framework/deppy/pkg/v2"
 	"github.com/operator-framework/operator-registry/alpha/property"
 	"github.com/operator-framework/operator-registry/pkg/api"
@@ -92,6 +95,7 @@ func InChannel(channel string) PackageSearchOption {
 type CachedRepository struct {
 	RepositoryName   string `json:"name"`
 	RepositorySource string `json:"source"`
+	RepositoryDigest string `json:"digest,omitempty"`
 }
 
 func (c CachedRepository) EntryID() string {
@@ -104,6 +108,9 @@ type CachedBundle struct {
 	Repository          string             `json:"repository"`
 	DefaultChannelName  string             `json:"defaultChannelName"`
 	PackageDependencies []property.Package `json:"packageDependencies"`
+	// Constraints are the bundle's olm.constraint and olm.gvk.required dependencies
+	Constraints []constraints.Constraint `json:"constraints,omitempty"`
+	Metadata    *CSVMetadata             `json:"metadata,omitempty"`
 }
 
 func (c CachedBundle) EntryID() string {
@@ -118,16 +125,20 @@ type CachedPackage struct {
 	*api.Package
 	PackageID  string `json:"id"`
 	Repository string `json:"repository"`
+	// Metadata is the csv metadata of the head of the default channel
+	Metadata *CSVMetadata `json:"metadata,omitempty"`
 }
 
 func (c CachedPackage) EntryID() string {
 	return c.PackageID
 }
 
+// CachedGVKBundle indexes the bundles providing a gvk. It only references the
+// bundle, which is stored once in the bundles table
 type CachedGVKBundle struct {
-	CachedBundle
-	GVKID string `json:"gvkId"`
-	GVK   string `json:"gvk"`
+	GVKID    string `json:"gvkId"`
+	GVK      string `json:"gvk"`
+	BundleID string `json:"bundleId"`
 }
 
 func (c CachedGVKBundle) EntryID() string {
@@ -141,14 +152,25 @@ type PackageDatabase interface {
 	ListBundles(ctx context.Context) ([]CachedBundle, error)
 	ListBundlesForGVK(ctx context.Context, group string, version string, kind string) ([]CachedBundle, error)
 	ListGVKs(ctx context.Context) (map[string][]CachedBundle, error)
-	SearchPackages(ctx context.Context, searchTerm string) ([]CachedPackage, error)
-	SearchBundles(ctx context.Context, searchTerm string) ([]CachedBundle, error)
+	SearchPackages(ctx context.Context, searchTerm string, options ...SearchOption) ([]PackageSearchResult, error)
+	SearchBundles(ctx context.Context, searchTerm string, options ...SearchOption) ([]BundleSearchResult, error)
 	CacheRepository(ctx context.Context, repository repository.Repository) error
 	RemoveRepository(ctx context.Context, repoName string) error
 	GetPackage(ctx context.Context, packageID string) (*CachedPackage, error)
 	GetBundle(ctx context.Context, bundleID string) (*CachedBundle, error)
 	IterateBundles(ctx context.Context, fn func(bundle *CachedBundle) error) error
 	GetBundlesForPackage(ctx context.Context, packageName string, options ...PackageSearchOption) ([]CachedBundle, error)
+	LookupBundles(ctx context.Context, index BundleIndex, value string) ([]CachedBundle, error)
+	SchemaVersion(ctx context.Context) (int, error)
+	Migrate(ctx context.Context) error
+	Reset(ctx context.Context) error
+	RecordOperation(ctx context.Context, operation *CachedOperation) error
+	ListOperations(ctx context.Context) ([]CachedOperation, error)
+	GetOperation(ctx context.Context, operationID string) (*CachedOperation, error)
+	ListWarnings(ctx context.Context, repositories ...string) ([]CachedWarning, error)
+	ExportRepository(ctx context.Context, repoName string) (*RepositorySnapshot, error)
+	ImportRepository(ctx context.Context, snapshot *RepositorySnapshot) error
+	ReplaceRepositories(ctx context.Context, snapshots []RepositorySnapshot) error
 	Close() error
 }
 
@@ -161,18 +183,112 @@ type boltPackageDatabase struct {
 	packageTable    *BoltDBTable[CachedPackage]
 	bundleTable     *BoltDBTable[CachedBundle]
 	gvkTable        *BoltDBTable[CachedGVKBundle]
+	historyTable    *BoltDBTable[CachedOperation]
+	warningTable    *BoltDBTable[CachedWarning]
 	logger          *logrus.Logger
+	// lockOwner is set if the database was opened for writing and its lock file records this process
+	lockOwner bool
 }
 
-func NewPackageDatabase(databasePath string, logger *logrus.Logger) (PackageDatabase, error) {
+type databaseConfig struct {
+	skipMigration bool
+	readOnly      bool
+	openTimeout   time.Duration
+}
+
+type DatabaseOption func(config *databaseConfig)
+
+// WithoutMigration opens the database without upgrading its schema. Use it to inspect,
+// migrate, or rebuild databases with an older schema
+func WithoutMigration() DatabaseOption {
+	return func(config *databaseConfig) {
+		config.skipMigration = true
+	}
+}
+
+// WithReadOnly opens the database for reading only. Read-only opens don't wait for each other,
+// only for processes writing to the database
+func WithReadOnly() DatabaseOption {
+	return func(config *databaseConfig) {
+		config.readOnly = true
+	}
+}
+
+// withReadOnly overrides the read-only option once a backend knows whether the database can be opened read-only
+func withReadOnly(readOnly bool) DatabaseOption {
+	return func(config *databaseConfig) {
+		config.readOnly = readOnly
+	}
+}
+
+// WithOpenTimeout sets how long to wait for another process to release the database before
+// failing with ErrDatabaseLocked. A zero timeout waits indefinitely
+func WithOpenTimeout(timeout time.Duration) DatabaseOption {
+	return func(config *databaseConfig) {
+		config.openTimeout = timeout
+	}
+}
+
+func init() {
+	RegisterBackend("bolt", func(directory string, logger *logrus.Logger, options ...DatabaseOption) (PackageDatabase, error) {
+		return NewPackageDatabase(path.Join(directory, "olm.db"), logger, options...)
+	})
+}
+
+func NewPackageDatabase(databasePath string, logger *logrus.Logger, options ...DatabaseOption) (_ PackageDatabase, err error) {
 	if logger == nil {
 		panic("logger is nil")
 	}
 
-	db, err := bolt.Open(databasePath, 0600, nil)
+	config := &databaseConfig{}
+	for _, opt := range options {
+		opt(config)
+	}
+
+	// a database that doesn't exist yet is created even by read-only opens
+	readOnly := config.readOnly
+	if _, err := os.Stat(databasePath); os.IsNotExist(err) {
+		readOnly = false
+	}
+
+	db, err := bolt.Open(databasePath, 0600, &bolt.Options{Timeout: config.openTimeout, ReadOnly: readOnly})
+	if errors.Is(err, bolt.ErrTimeout) {
+		return nil, lockedError(databasePath)
+	}
 	if err != nil {
 		return nil, err
 	}
+	if !readOnly {
+		if err := writeLockOwner(databasePath); err != nil {
+			logger.Debugf("error recording the database lock owner: %v", err)
+		}
+	}
+	// a database that fails to open is closed, so it isn't left locked
+	defer func() {
+		if err != nil {
+			db.Close()
+			if !readOnly {
+				removeLockOwner(databasePath)
+			}
+		}
+	}()
+
+	if readOnly {
+		return openReadOnlyPackageDatabase(databasePath, db, logger, config)
+	}
+
+	// the schema version must be read before the tables are created,
+	// otherwise a legacy database can't be told apart from a new one
+	var schemaVersion int
+	if err := db.Update(func(tx *bolt.Tx) error {
+		schemaVersion = readSchemaVersion(tx)
+		if tx.Bucket([]byte(metadataBucket)) == nil && schemaVersion == CurrentSchemaVersion() {
+			return writeSchemaVersion(tx, schemaVersion)
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
 
 	repositoryTable, err := createTableIgnoreExists[CachedRepository](db, repositoriesBucket)
 	if err != nil {
@@ -184,22 +300,86 @@ func NewPackageDatabase(databasePath string, logger *logrus.Logger) (PackageData
 		return nil, err
 	}
 
-	bundleTable, err := createTableIgnoreExists[CachedBundle](db, bundlesBucket)
+	bundleTable, err := createTableIgnoreExists[CachedBundle](db, bundlesBucket, bundleTableOptions()...)
 	if err != nil {
 		return nil, err
 	}
 
 	gvkTable, err := createTableIgnoreExists[CachedGVKBundle](db, gvkBucket)
+	if err != nil {
+		return nil, err
+	}
 
-	return &boltPackageDatabase{
+	historyTable, err := createTableIgnoreExists[CachedOperation](db, historyBucket)
+	if err != nil {
+		return nil, err
+	}
+
+	warningTable, err := createTableIgnoreExists[CachedWarning](db, warningsBucket)
+	if err != nil {
+		return nil, err
+	}
+
+	packageDatabase := &boltPackageDatabase{
 		databasePath:    databasePath,
 		database:        db,
 		repositoryTable: repositoryTable,
 		packageTable:    packageTable,
 		bundleTable:     bundleTable,
 		gvkTable:        gvkTable,
+		historyTable:    historyTable,
+		warningTable:    warningTable,
 		logger:          logger,
-	}, nil
+		lockOwner:       true,
+	}
+
+	if !config.skipMigration && schemaVersion != CurrentSchemaVersion() {
+		if err := packageDatabase.Migrate(context.Background()); err != nil {
+			return nil, err
+		}
+	}
+	return packageDatabase, nil
+}
+
+// openReadOnlyPackageDatabase wraps a database opened read-only. Its schema can't be upgraded
+// so it must already be at the current version. The caller closes the database on errors
+func openReadOnlyPackageDatabase(databasePath string, db *bolt.DB, logger *logrus.Logger, config *databaseConfig) (PackageDatabase, error) {
+	var schemaVersion int
+	if err := db.View(func(tx *bolt.Tx) error {
+		schemaVersion = readSchemaVersion(tx)
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	if !config.skipMigration && schemaVersion != CurrentSchemaVersion() {
+		return nil, fmt.Errorf("package database schema version %d can't be read without migrating it to version %d: run 'olm db migrate'", schemaVersion, CurrentSchemaVersion())
+	}
+
+	packageDatabase := &boltPackageDatabase{
+		databasePath: databasePath,
+		database:     db,
+		logger:       logger,
+	}
+	var err error
+	if packageDatabase.repositoryTable, err = NewBoltDBTable[CachedRepository](db, repositoriesBucket); err != nil {
+		return nil, err
+	}
+	if packageDatabase.packageTable, err = NewBoltDBTable[CachedPackage](db, packagesBucket); err != nil {
+		return nil, err
+	}
+	if packageDatabase.bundleTable, err = NewBoltDBTable[CachedBundle](db, bundlesBucket, bundleTableOptions()...); err != nil {
+		return nil, err
+	}
+	if packageDatabase.gvkTable, err = NewBoltDBTable[CachedGVKBundle](db, gvkBucket); err != nil {
+		return nil, err
+	}
+	if packageDatabase.historyTable, err = NewBoltDBTable[CachedOperation](db, historyBucket); err != nil {
+		return nil, err
+	}
+	if packageDatabase.warningTable, err = NewBoltDBTable[CachedWarning](db, warningsBucket); err != nil {
+		return nil, err
+	}
+	return packageDatabase, nil
 }
 
 func (b *boltPackageDatabase) HasRepository(_ context.Context, repoName string) (bool, error) {
@@ -212,33 +392,106 @@ func (b *boltPackageDatabase) ListRepositories(_ context.Context) ([]CachedRepos
 
 func (b *boltPackageDatabase) RemoveRepository(_ context.Context, repoName string) error {
 	return b.database.Batch(func(tx *bolt.Tx) error {
-		// delete repository entry
-		if err := b.repositoryTable.DeleteEntryWithKeyInTransaction(tx, repoName); err != nil {
-			return err
+		return b.removeRepositoryInTransaction(tx, repoName)
+	})
+}
+
+func (b *boltPackageDatabase) removeRepositoryInTransaction(tx *bolt.Tx, repoName string) error {
+	// delete repository entry
+	if err := b.repositoryTable.DeleteEntryWithKeyInTransaction(tx, repoName); err != nil {
+		return err
+	}
+
+	// delete packages
+	prefix := repoName + keySeparator
+	if err := b.packageTable.DeleteEntriesWithPrefixInTransaction(tx, prefix); err != nil {
+		return err
+	}
+
+	// delete warnings
+	if err := b.warningTable.DeleteEntriesWithPrefixInTransaction(tx, prefix); err != nil {
+		return err
+	}
+
+	// update gvk pre-calculation
+	deletedBundles, err := b.bundleTable.SeekInTransaction(tx, prefix)
+	if err != nil {
+		return err
+	}
+	for _, deletedBundle := range deletedBundles {
+		for _, providedAPI := range deletedBundle.ProvidedApis {
+			key := GetGVKKey(providedAPI, deletedBundle.BundleID)
+			if err := b.gvkTable.DeleteEntryWithKeyInTransaction(tx, key); err != nil {
+				return err
+			}
 		}
+	}
+
+	// delete bundles
+	return b.bundleTable.DeleteEntriesWithPrefixInTransaction(tx, prefix)
+}
 
-		// delete packages
+// ExportRepository returns the cached content of the repository
+func (b *boltPackageDatabase) ExportRepository(_ context.Context, repoName string) (*RepositorySnapshot, error) {
+	snapshot := &RepositorySnapshot{}
+	err := b.database.View(func(tx *bolt.Tx) error {
+		repository, err := b.repositoryTable.GetInTransaction(tx, repoName)
+		if err != nil {
+			return err
+		}
+		if repository == nil {
+			return fmt.Errorf("repository %s not found", repoName)
+		}
+		snapshot.Repository = *repository
 		prefix := repoName + keySeparator
-		if err := b.packageTable.DeleteEntriesWithPrefixInTransaction(tx, prefix); err != nil {
+		if snapshot.Packages, err = b.packageTable.SeekInTransaction(tx, prefix); err != nil {
+			return err
+		}
+		if snapshot.Bundles, err = b.bundleTable.SeekInTransaction(tx, prefix); err != nil {
 			return err
 		}
+		snapshot.Warnings, err = b.warningTable.SeekInTransaction(tx, prefix)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return snapshot, nil
+}
 
-		// update gvk pre-calculation
-		deletedBundles, err := b.bundleTable.Seek(prefix)
-		if err != nil {
+// ImportRepository replaces the cached content of the snapshot's repository with the snapshot
+func (b *boltPackageDatabase) ImportRepository(_ context.Context, snapshot *RepositorySnapshot) error {
+	if err := snapshot.validate(); err != nil {
+		return err
+	}
+	return b.database.Batch(func(tx *bolt.Tx) error {
+		if err := b.removeRepositoryInTransaction(tx, snapshot.Repository.RepositoryName); err != nil {
 			return err
 		}
-		for _, deletedBundle := range deletedBundles {
-			for _, providedAPI := range deletedBundle.ProvidedApis {
-				key := GetGVKKey(providedAPI, deletedBundle.BundleID)
-				if err := b.gvkTable.DeleteEntryWithKeyInTransaction(tx, key); err != nil {
-					return err
-				}
+		return importRepository(snapshot, &boltRepositoryWriter{database: b, tx: tx})
+	})
+}
+
+// ReplaceRepositories resets the database and imports the snapshots in a single transaction, so
+// the cached content is left unchanged if any snapshot fails to import
+func (b *boltPackageDatabase) ReplaceRepositories(_ context.Context, snapshots []RepositorySnapshot) error {
+	return b.database.Update(func(tx *bolt.Tx) error {
+		if err := b.resetInTransaction(tx); err != nil {
+			return err
+		}
+		for index := range snapshots {
+			snapshot := &snapshots[index]
+			if err := snapshot.validate(); err != nil {
+				return err
+			}
+			if err := b.removeRepositoryInTransaction(tx, snapshot.Repository.RepositoryName); err != nil {
+				return err
+			}
+			if err := importRepository(snapshot, &boltRepositoryWriter{database: b, tx: tx}); err != nil {
+				return fmt.Errorf("error importing repository %s: %w", snapshot.Repository.RepositoryName, err)
 			}
 		}
-
-		// delete bundles
-		return b.bundleTable.DeleteEntriesWithPrefixInTransaction(tx, prefix)
+		return nil
 	})
 }
 
@@ -249,80 +502,175 @@ func (b *boltPackageDatabase) CacheRepository(ctx context.Context, repository re
 
 	b.logger.Debugln("Caching repository from ", repository.Source())
 	err := b.database.Batch(func(tx *bolt.Tx) error {
-		// extract repo name (in this case the name of the image)
-		repoName := getRepoName(repository.Source())
+		return cacheRepository(ctx, repository, &boltRepositoryWriter{database: b, tx: tx}, b.logger)
+	})
+	b.logger.Debugln("Done...")
+	return err
+}
 
-		// iterate over bundles and write them out to the database inc. their packages
-		bundleIterator, err := repository.ListBundles(ctx)
-		defaultChannelNameMap := map[string]string{}
+// repositoryWriter stores the content of a repository in a backend transaction
+type repositoryWriter interface {
+	deleteWarnings(repoName string) error
+	putBundle(bundle *CachedBundle) error
+	putWarning(warning *CachedWarning) error
+	putPackage(pkg *CachedPackage) error
+	putRepository(repository *CachedRepository) error
+}
 
-		if err != nil {
+// cacheRepository reads the repository's bundles and packages and writes them out, along with the
+// problems found in the bundles and the repository record
+func cacheRepository(ctx context.Context, repository repository.Repository, writer repositoryWriter, logger *logrus.Logger) error {
+	// extract repo name (in this case the name of the image)
+	repoName := GetRepositoryName(repository.Source())
+
+	// drop the warnings of previously cached content
+	if err := writer.deleteWarnings(repoName); err != nil {
+		return err
+	}
+
+	// iterate over bundles and write them out to the database inc. their packages
+	bundleIterator, err := repository.ListBundles(ctx)
+	packages := map[string]*CachedPackage{}
+	warningCount := 0
+
+	if err != nil {
+		return err
+	}
+
+	logger.Debugln("Inserting bundles...")
+	for bundle := bundleIterator.Next(); bundle != nil; bundle = bundleIterator.Next() {
+		pkgName := bundle.PackageName
+		if _, ok := packages[pkgName]; !ok {
+			pkg, err := repository.GetPackage(ctx, pkgName)
+			if err != nil {
+				return err
+			}
+			packages[pkgName] = NewCachedPackage(repoName, pkg)
+		}
+
+		cachedBundle, warnings := NewCachedBundle(repoName, bundle, packages[pkgName])
+		if err := writer.putBundle(cachedBundle); err != nil {
 			return err
 		}
 
-		b.logger.Debugln("Inserting bundles...")
-		for bundle := bundleIterator.Next(); bundle != nil; bundle = bundleIterator.Next() {
-			pkgName := bundle.PackageName
-			if _, ok := defaultChannelNameMap[pkgName]; !ok {
-				pkg, err := repository.GetPackage(ctx, pkgName)
-				if err != nil {
-					return err
-				}
-				cachedPackage := &CachedPackage{
-					PackageID:  GetPackageKey(repoName, pkg.GetName()),
-					Package:    pkg,
-					Repository: repoName,
-				}
-				if err := b.packageTable.InsertInTransaction(tx, cachedPackage); err != nil {
-					return err
-				}
-				defaultChannelNameMap[pkgName] = pkg.DefaultChannelName
+		for _, warning := range warnings {
+			logger.Debugf("%s: %s: %s", warning.BundleID, warning.Field, warning.Message)
+			if err := writer.putWarning(&warning); err != nil {
+				return err
 			}
+			warningCount++
+		}
+	}
 
-			var packageDependencies []property.Package
-			for _, dependency := range bundle.Dependencies {
-				switch dependency.GetType() {
-				case property.TypePackage:
-					packageDependency := &property.Package{}
-					if err := json.Unmarshal([]byte(dependency.GetValue()), packageDependency); err != nil {
-						return err
-					}
-					packageDependencies = append(packageDependencies, *packageDependency)
-				}
-			}
+	if warningCount > 0 {
+		logger.Warnf("Repository %s has %d invalid entries, run 'olm repo lint %s' for details", repoName, warningCount, repoName)
+	}
 
-			cachedBundle := &CachedBundle{
-				BundleID:            GetBundleKey(repoName, bundle),
-				Bundle:              bundle,
-				Repository:          repoName,
-				DefaultChannelName:  defaultChannelNameMap[bundle.PackageName],
-				PackageDependencies: packageDependencies,
-			}
-			if err := b.bundleTable.InsertInTransaction(tx, cachedBundle); err != nil {
-				return nil
-			}
+	logger.Debugln("Inserting packages...")
+	for _, cachedPackage := range packages {
+		if err := writer.putPackage(cachedPackage); err != nil {
+			return err
+		}
+	}
+
+	// add repo record
+	logger.Debugln("Adding repository record...")
+	return writer.putRepository(&CachedRepository{
+		RepositoryName:   repoName,
+		RepositorySource: repository.Source(),
+		RepositoryDigest: repository.Digest(),
+	})
+}
 
-			for _, gvk := range cachedBundle.ProvidedApis {
-				key := GetGVKKey(gvk, cachedBundle.BundleID)
-				if err := b.gvkTable.InsertInTransaction(tx, &CachedGVKBundle{
-					CachedBundle: *cachedBundle,
-					GVKID:        key,
-					GVK:          strings.Join([]string{gvk.GetGroup(), gvk.GetVersion(), gvk.GetKind()}, keySeparator),
-				}); err != nil {
-					return err
-				}
+// gvkBundles returns the gvk index entries of the bundle
+func gvkBundles(bundle *CachedBundle) []CachedGVKBundle {
+	entries := make([]CachedGVKBundle, 0, len(bundle.ProvidedApis))
+	for _, gvk := range bundle.ProvidedApis {
+		entries = append(entries, CachedGVKBundle{
+			GVKID:    GetGVKKey(gvk, bundle.BundleID),
+			GVK:      strings.Join([]string{gvk.GetGroup(), gvk.GetVersion(), gvk.GetKind()}, keySeparator),
+			BundleID: bundle.BundleID,
+		})
+	}
+	return entries
+}
+
+type boltRepositoryWriter struct {
+	database *boltPackageDatabase
+	tx       *bolt.Tx
+}
+
+func (w *boltRepositoryWriter) deleteWarnings(repoName string) error {
+	return w.database.warningTable.DeleteEntriesWithPrefixInTransaction(w.tx, repoName+keySeparator)
+}
+
+func (w *boltRepositoryWriter) putBundle(bundle *CachedBundle) error {
+	if err := w.database.bundleTable.InsertInTransaction(w.tx, bundle); err != nil {
+		return err
+	}
+	for _, gvkBundle := range gvkBundles(bundle) {
+		if err := w.database.gvkTable.InsertInTransaction(w.tx, &gvkBundle); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (w *boltRepositoryWriter) putWarning(warning *CachedWarning) error {
+	return w.database.warningTable.InsertInTransaction(w.tx, warning)
+}
+
+func (w *boltRepositoryWriter) putPackage(pkg *CachedPackage) error {
+	return w.database.packageTable.InsertInTransaction(w.tx, pkg)
+}
+
+func (w *boltRepositoryWriter) putRepository(repository *CachedRepository) error {
+	return w.database.repositoryTable.InsertInTransaction(w.tx, repository)
+}
+
+// NewCachedPackage wraps a package of the named repository
+func NewCachedPackage(repoName string, pkg *api.Package) *CachedPackage {
+	return &CachedPackage{
+		PackageID:  GetPackageKey(repoName, pkg.GetName()),
+		Package:    pkg,
+		Repository: repoName,
+	}
+}
+
+// NewCachedBundle wraps a bundle of the named repository, extracting its dependencies, constraints and
+// csv metadata, and returns the problems found in the bundle. The package's metadata is set from the
+// bundle if it is the head of the package's default channel
+func NewCachedBundle(repoName string, bundle *api.Bundle, pkg *CachedPackage) (*CachedBundle, []CachedWarning) {
+	bundleConstraints, constraintProblems := bundleConstraints(bundle)
+
+	var packageDependencies []property.Package
+	for _, dependency := range bundle.Dependencies {
+		switch dependency.GetType() {
+		case property.TypePackage:
+			packageDependency := &property.Package{}
+			if err := json.Unmarshal([]byte(dependency.GetValue()), packageDependency); err != nil {
+				err = fmt.Errorf("invalid %s dependency: %w", property.TypePackage, err)
+				constraintProblems = append(constraintProblems, err)
+				bundleConstraints = append(bundleConstraints, unsatisfiableConstraint(err))
+				continue
 			}
+			packageDependencies = append(packageDependencies, *packageDependency)
 		}
+	}
 
-		// add repo record
-		b.logger.Debugln("Adding repository record...")
-		return b.repositoryTable.InsertInTransaction(tx, &CachedRepository{
-			RepositoryName:   repoName,
-			RepositorySource: repository.Source(),
-		})
-	})
-	b.logger.Debugln("Done...")
-	return err
+	cachedBundle := &CachedBundle{
+		BundleID:            GetBundleKey(repoName, bundle),
+		Bundle:              bundle,
+		Repository:          repoName,
+		DefaultChannelName:  pkg.GetDefaultChannelName(),
+		PackageDependencies: packageDependencies,
+		Constraints:         bundleConstraints,
+	}
+	cachedBundle.Metadata = bundleMetadata(cachedBundle)
+	if isPackageHead(pkg, cachedBundle) {
+		pkg.Metadata = PackageMetadata(cachedBundle)
+	}
+	return cachedBundle, lintBundle(cachedBundle, constraintProblems)
 }
 
 func (b *boltPackageDatabase) ListPackages(_ context.Context) ([]CachedPackage, error) {
@@ -330,27 +678,46 @@ func (b *boltPackageDatabase) ListPackages(_ context.Context) ([]CachedPackage,
 }
 
 func (b *boltPackageDatabase) ListBundlesForGVK(_ context.Context, group string, version string, kind string) ([]CachedBundle, error) {
-	gvkBundles, err := b.gvkTable.Seek(fmt.Sprintf("%s%s", strings.Join([]string{group, version, kind}, keySeparator), keySeparator))
-	if err != nil {
-		return nil, err
-	}
-	bundles := make([]CachedBundle, len(gvkBundles))
-	for index, _ := range gvkBundles {
-		bundles[index] = gvkBundles[index].CachedBundle
-	}
-	return bundles, nil
+	var bundles []CachedBundle
+	err := b.database.View(func(tx *bolt.Tx) error {
+		gvkBundles, err := b.gvkTable.SeekInTransaction(tx, fmt.Sprintf("%s%s", strings.Join([]string{group, version, kind}, keySeparator), keySeparator))
+		if err != nil {
+			return err
+		}
+		bundles = make([]CachedBundle, 0, len(gvkBundles))
+		for _, gvkBundle := range gvkBundles {
+			bundle, err := b.bundleTable.GetInTransaction(tx, gvkBundle.BundleID)
+			if err != nil {
+				return err
+			}
+			if bundle != nil {
+				bundles = append(bundles, *bundle)
+			}
+		}
+		return nil
+	})
+	return bundles, err
 }
 
 func (b *boltPackageDatabase) ListGVKs(ctx context.Context) (map[string][]CachedBundle, error) {
-	gvkBundles, err := b.gvkTable.List()
-	if err != nil {
-		return nil, err
-	}
 	result := map[string][]CachedBundle{}
-	for _, gvkBundle := range gvkBundles {
-		result[gvkBundle.GVK] = append(result[gvkBundle.GVK], gvkBundle.CachedBundle)
-	}
-	return result, nil
+	err := b.database.View(func(tx *bolt.Tx) error {
+		gvkBundles, err := b.gvkTable.SeekInTransaction(tx, "")
+		if err != nil {
+			return err
+		}
+		for _, gvkBundle := range gvkBundles {
+			bundle, err := b.bundleTable.GetInTransaction(tx, gvkBundle.BundleID)
+			if err != nil {
+				return err
+			}
+			if bundle != nil {
+				result[gvkBundle.GVK] = append(result[gvkBundle.GVK], *bundle)
+			}
+		}
+		return nil
+	})
+	return result, err
 }
 
 func (b *boltPackageDatabase) ListBundles(_ context.Context) ([]CachedBundle, error) {
@@ -365,59 +732,29 @@ func (b *boltPackageDatabase) IterateBundles(_ context.Context, fn func(bundle *
 	return b.bundleTable.Iterate(fn)
 }
 
-func (b *boltPackageDatabase) SearchPackages(_ context.Context, searchTerm string) ([]CachedPackage, error) {
-	return b.packageTable.Search(func(pkg *CachedPackage) (bool, error) {
-		return strings.Index(pkg.GetName(), searchTerm) >= 0, nil
-	})
-}
-
-func (b *boltPackageDatabase) SearchBundles(_ context.Context, searchTerm string) ([]CachedBundle, error) {
-	return b.bundleTable.Search(func(bundle *CachedBundle) (bool, error) {
-		return strings.Index(bundle.CsvName, searchTerm) >= 0, nil
-	})
-}
+func (b *boltPackageDatabase) GetBundlesForPackage(_ context.Context, packageName string, options ...PackageSearchOption) ([]CachedBundle, error) {
+	searchOptions := &packageSearchConfig{}
+	searchOptions.applyOptions(options...)
 
-func (b *boltPackageDatabase) GetBundlesForPackage(ctx context.Context, packageName string, options ...PackageSearchOption) ([]CachedBundle, error) {
-	searchOptions, err := b.defaultPackageSearchConfig(ctx)
+	// the package index spans all repositories, so a single seek finds every bundle of the package
+	entries, err := b.bundleTable.Lookup(string(BundlesByPackage), packageName)
 	if err != nil {
 		return nil, err
 	}
-	searchOptions.applyOptions(options...)
-
-	type result struct {
-		bundles []CachedBundle
-		err     error
-	}
-	resultsChannel := make(chan result, len(searchOptions.repositories))
-	for _, repositoryName := range searchOptions.repositories {
-		go func(prefix string) {
-			entries, err := b.bundleTable.Seek(prefix)
-			resultsChannel <- result{entries, err}
-		}(fmt.Sprintf("%s%s%s%s", repositoryName, keySeparator, packageName, keySeparator))
-	}
 
 	var bundles []CachedBundle
-	var errs []error
-	for i := 0; i < cap(resultsChannel); i++ {
-		result := <-resultsChannel
-		if result.err == nil {
-			for _, b := range result.bundles {
-				if searchOptions.keep(&b) {
-					bundles = append(bundles, b)
-				}
-			}
-		} else {
-			errs = append(errs, err)
+	for index := range entries {
+		if searchOptions.keep(&entries[index]) {
+			bundles = append(bundles, entries[index])
 		}
 	}
-
-	if len(errs) > 0 {
-		return nil, errs[0]
-	}
-
 	return bundles, nil
 }
 
+func (b *boltPackageDatabase) LookupBundles(_ context.Context, index BundleIndex, value string) ([]CachedBundle, error) {
+	return b.bundleTable.Lookup(string(index), value)
+}
+
 func (b *boltPackageDatabase) GetPackage(_ context.Context, packageID string) (*CachedPackage, error) {
 	return b.packageTable.Get(packageID)
 }
@@ -427,24 +764,13 @@ func (b *boltPackageDatabase) GetBundle(_ context.Context, bundleID string) (*Ca
 }
 
 func (b *boltPackageDatabase) Close() error {
-	if b.database != nil {
-		return b.database.Close()
+	if b.database == nil {
+		return nil
 	}
-	return nil
-}
-
-func (b *boltPackageDatabase) defaultPackageSearchConfig(ctx context.Context) (*packageSearchConfig, error) {
-	repos, err := b.ListRepositories(ctx)
-	if err != nil {
-		return nil, err
-	}
-	repoNames := make([]string, len(repos))
-	for index, _ := range repos {
-		repoNames[index] = repos[index].RepositoryName
+	if b.lockOwner {
+		removeLockOwner(b.databasePath)
 	}
-	return &packageSearchConfig{
-		repositories: repoNames,
-	}, nil
+	return b.database.Close()
 }
 
 func GetBundleKey(repoName string, bundle *api.Bundle) string {
@@ -459,15 +785,24 @@ func GetGVKKey(gvk *api.GroupVersionKind, bundleID string) string {
 	return strings.Join([]string{gvk.GetGroup(), gvk.GetVersion(), gvk.GetKind(), bundleID}, keySeparator)
 }
 
-func getRepoName(repoSource string) string {
+func GetRepositoryName(repoSource string) string {
 	regex := regexp.MustCompile(imageRegexp)
 	match := regex.FindStringSubmatch(repoSource)
 	imageIndex := regex.SubexpIndex("image")
 	return match[imageIndex]
 }
 
-func createTableIgnoreExists[E IdentifiableEntry](database *bolt.DB, tableName string) (*BoltDBTable[E], error) {
-	table, err := NewBoltDBTable[E](database, tableName)
+// bundleCodec is the codec used for the bundles table
+func bundleCodec() Codec[CachedBundle] {
+	return NewGzipCodec[CachedBundle](JSONCodec[CachedBundle]{})
+}
+
+func bundleTableOptions() []TableOption[CachedBundle] {
+	return append([]TableOption[CachedBundle]{WithCodec[CachedBundle](bundleCodec())}, bundleIndexes()...)
+}
+
+func createTableIgnoreExists[E IdentifiableEntry](database *bolt.DB, tableName string, options ...TableOption[E]) (*BoltDBTable[E], error) {
+	table, err := NewBoltDBTable[E](database, tableName, options...)
 	if err != nil {
 		return nil, err
 	}
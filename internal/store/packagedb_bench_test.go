@@ -0,0 +1,99 @@
+package store
+
+import (
+	"context"
+	"testing"
+)
+
+// the large fixture catalog is about the size of the community operator catalog
+const (
+	largeCatalogPackages = 300
+	largeCatalogChannels = 3
+	largeCatalogBundles  = 10
+)
+
+func newLargeFixtureRepository() *fixtureRepository {
+	return newFixtureRepository("quay.io/fixture/large-catalog:latest", largeCatalogPackages, largeCatalogChannels, largeCatalogBundles)
+}
+
+// cachedLargeCatalog opens a bolt package database holding the large fixture catalog
+func cachedLargeCatalog(b *testing.B) PackageDatabase {
+	b.Helper()
+	packageDatabase := openTestDatabase(b, "bolt")
+	if err := packageDatabase.CacheRepository(context.Background(), newLargeFixtureRepository()); err != nil {
+		b.Fatal(err)
+	}
+	return packageDatabase
+}
+
+func BenchmarkCacheRepository(b *testing.B) {
+	repo := newLargeFixtureRepository()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		packageDatabase := openTestDatabase(b, "bolt")
+		b.StartTimer()
+		if err := packageDatabase.CacheRepository(context.Background(), repo); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkGetBundlesForPackage(b *testing.B) {
+	packageDatabase := cachedLargeCatalog(b)
+	ctx := context.Background()
+	b.Run("package", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			bundles, err := packageDatabase.GetBundlesForPackage(ctx, fixturePackageName(i%largeCatalogPackages))
+			if err != nil {
+				b.Fatal(err)
+			}
+			if len(bundles) != largeCatalogChannels*largeCatalogBundles {
+				b.Fatalf("expected %d bundles, got %d", largeCatalogChannels*largeCatalogBundles, len(bundles))
+			}
+		}
+	})
+	b.Run("channel", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			bundles, err := packageDatabase.GetBundlesForPackage(ctx, fixturePackageName(i%largeCatalogPackages), InChannel(fixtureChannelName(i%largeCatalogChannels)))
+			if err != nil {
+				b.Fatal(err)
+			}
+			if len(bundles) != largeCatalogBundles {
+				b.Fatalf("expected %d bundles, got %d", largeCatalogBundles, len(bundles))
+			}
+		}
+	})
+}
+
+func BenchmarkListBundlesForGVK(b *testing.B) {
+	packageDatabase := cachedLargeCatalog(b)
+	ctx := context.Background()
+	b.Run("package gvk", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			gvk := fixturePackageGVK(i % largeCatalogPackages)
+			bundles, err := packageDatabase.ListBundlesForGVK(ctx, gvk.Group, gvk.Version, gvk.Kind)
+			if err != nil {
+				b.Fatal(err)
+			}
+			if len(bundles) != largeCatalogChannels*largeCatalogBundles {
+				b.Fatalf("expected %d bundles, got %d", largeCatalogChannels*largeCatalogBundles, len(bundles))
+			}
+		}
+	})
+	b.Run("shared gvk", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			bundles, err := packageDatabase.ListBundlesForGVK(ctx, fixtureGVK.Group, fixtureGVK.Version, fixtureGVK.Kind)
+			if err != nil {
+				b.Fatal(err)
+			}
+			if len(bundles) != largeCatalogPackages*largeCatalogChannels*largeCatalogBundles {
+				b.Fatalf("expected %d bundles, got %d", largeCatalogPackages*largeCatalogChannels*largeCatalogBundles, len(bundles))
+			}
+		}
+	})
+}
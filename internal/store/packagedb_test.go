@@ -0,0 +1,462 @@
+package store
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/operator-framework/operator-registry/alpha/property"
+)
+
+// the conformance fixture caches two repositories that share package names, so lookups across
+// repositories are covered
+const (
+	firstRepositorySource  = "quay.io/fixture/first:latest"
+	secondRepositorySource = "quay.io/fixture/second:latest"
+	firstRepository        = "first"
+	secondRepository       = "second"
+)
+
+// openConformanceDatabase opens a package database of the backend caching both fixture repositories.
+// The first bundle of the first repository has an invalid skip range, so it has a warning
+func openConformanceDatabase(t *testing.T, backend string) PackageDatabase {
+	t.Helper()
+	packageDatabase := openTestDatabase(t, backend)
+	first := newFixtureRepository(firstRepositorySource, 3, 2, 3)
+	first.bundles[0].SkipRange = "not-a-range"
+	for _, repo := range []*fixtureRepository{first, newFixtureRepository(secondRepositorySource, 2, 1, 2)} {
+		if err := packageDatabase.CacheRepository(context.Background(), repo); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return packageDatabase
+}
+
+func bundleIDs(bundles []CachedBundle) []string {
+	ids := make([]string, 0, len(bundles))
+	for _, bundle := range bundles {
+		ids = append(ids, bundle.BundleID)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+func packageIDs(packages []CachedPackage) []string {
+	ids := make([]string, 0, len(packages))
+	for _, pkg := range packages {
+		ids = append(ids, pkg.PackageID)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+func repositoryNames(repositories []CachedRepository) []string {
+	names := make([]string, 0, len(repositories))
+	for _, repository := range repositories {
+		names = append(names, repository.RepositoryName)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func warningIDs(warnings []CachedWarning) []string {
+	ids := make([]string, 0, len(warnings))
+	for _, warning := range warnings {
+		ids = append(ids, warning.WarningID)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+func fixtureBundleID(repoName string, p int, c int, b int) string {
+	return GetBundleKey(repoName, fixtureBundle(p, c, b, ""))
+}
+
+func expectCount[T any](t *testing.T, what string, entries []T, err error, count int) {
+	t.Helper()
+	if err != nil {
+		t.Fatalf("error listing %s: %v", what, err)
+	}
+	if len(entries) != count {
+		t.Errorf("expected %d %s, got %d", count, what, len(entries))
+	}
+}
+
+// TestPackageDatabaseConformance runs the same checks against every registered storage backend
+func TestPackageDatabaseConformance(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		test func(t *testing.T, backend string)
+	}{
+		{name: "cache repository", test: testCacheRepository},
+		{name: "remove repository", test: testRemoveRepository},
+		{name: "lookup bundles", test: testLookupBundles},
+		{name: "reset", test: testReset},
+		{name: "export and import", test: testExportImport},
+		{name: "replace repositories", test: testReplaceRepositories},
+		{name: "history", test: testHistory},
+		{name: "warnings", test: testWarnings},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, backend := range Backends() {
+				t.Run(backend, func(t *testing.T) {
+					tt.test(t, backend)
+				})
+			}
+		})
+	}
+}
+
+func testCacheRepository(t *testing.T, backend string) {
+	ctx := context.Background()
+	packageDatabase := openConformanceDatabase(t, backend)
+
+	repositories, err := packageDatabase.ListRepositories(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if names := repositoryNames(repositories); !reflect.DeepEqual(names, []string{firstRepository, secondRepository}) {
+		t.Errorf("expected repositories first and second, got %v", names)
+	}
+	if ok, err := packageDatabase.HasRepository(ctx, firstRepository); err != nil || !ok {
+		t.Errorf("expected repository first to be cached, got %t, %v", ok, err)
+	}
+	packages, err := packageDatabase.ListPackages(ctx)
+	expectCount(t, "packages", packages, err, 3+2)
+	bundles, err := packageDatabase.ListBundles(ctx)
+	expectCount(t, "bundles", bundles, err, 3*2*3+2*1*2)
+	gvkBundles, err := packageDatabase.ListBundlesForGVK(ctx, fixtureGVK.Group, fixtureGVK.Version, fixtureGVK.Kind)
+	expectCount(t, "bundles providing the fixture gvk", gvkBundles, err, 3*2*3+2*1*2)
+
+	bundleID := fixtureBundleID(firstRepository, 1, 1, 2)
+	bundle, err := packageDatabase.GetBundle(ctx, bundleID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bundle == nil || bundle.Version != "2.2.0" || bundle.Repository != firstRepository {
+		t.Errorf("expected bundle %s at version 2.2.0, got %+v", bundleID, bundle)
+	}
+	pkg, err := packageDatabase.GetPackage(ctx, GetPackageKey(secondRepository, fixturePackageName(1)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pkg == nil || pkg.GetName() != fixturePackageName(1) || len(pkg.Channels) != 1 {
+		t.Errorf("expected package %s with a single channel, got %+v", fixturePackageName(1), pkg)
+	}
+}
+
+func testRemoveRepository(t *testing.T, backend string) {
+	ctx := context.Background()
+	packageDatabase := openConformanceDatabase(t, backend)
+	if err := packageDatabase.RemoveRepository(ctx, firstRepository); err != nil {
+		t.Fatal(err)
+	}
+
+	repositories, err := packageDatabase.ListRepositories(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if names := repositoryNames(repositories); !reflect.DeepEqual(names, []string{secondRepository}) {
+		t.Errorf("expected repository second, got %v", names)
+	}
+	if ok, err := packageDatabase.HasRepository(ctx, firstRepository); err != nil || ok {
+		t.Errorf("expected repository first to be removed, got %t, %v", ok, err)
+	}
+	packages, err := packageDatabase.ListPackages(ctx)
+	expectCount(t, "packages", packages, err, 2)
+	bundles, err := packageDatabase.ListBundles(ctx)
+	expectCount(t, "bundles", bundles, err, 2*1*2)
+	gvkBundles, err := packageDatabase.ListBundlesForGVK(ctx, fixtureGVK.Group, fixtureGVK.Version, fixtureGVK.Kind)
+	expectCount(t, "bundles providing the fixture gvk", gvkBundles, err, 2*1*2)
+	indexed, err := packageDatabase.LookupBundles(ctx, BundlesByPackage, fixturePackageName(2))
+	expectCount(t, "indexed bundles of a package of the removed repository", indexed, err, 0)
+	warnings, err := packageDatabase.ListWarnings(ctx)
+	expectCount(t, "warnings", warnings, err, 0)
+	if bundle, err := packageDatabase.GetBundle(ctx, fixtureBundleID(firstRepository, 0, 0, 0)); err != nil || bundle != nil {
+		t.Errorf("expected no bundle of the removed repository, got %+v, %v", bundle, err)
+	}
+}
+
+func testLookupBundles(t *testing.T, backend string) {
+	ctx := context.Background()
+	packageDatabase := openConformanceDatabase(t, backend)
+	gvk := fixturePackageGVK(1)
+	providedGVK := fixtureProperty(property.TypeGVK, property.GVK{Group: gvk.Group, Version: gvk.Version, Kind: gvk.Kind})
+
+	for _, tt := range []struct {
+		name     string
+		index    BundleIndex
+		value    string
+		expected []string
+	}{
+		{
+			name:  "package across repositories",
+			index: BundlesByPackage,
+			value: fixturePackageName(1),
+			expected: []string{
+				fixtureBundleID(firstRepository, 1, 0, 0), fixtureBundleID(firstRepository, 1, 0, 1), fixtureBundleID(firstRepository, 1, 0, 2),
+				fixtureBundleID(firstRepository, 1, 1, 0), fixtureBundleID(firstRepository, 1, 1, 1), fixtureBundleID(firstRepository, 1, 1, 2),
+				fixtureBundleID(secondRepository, 1, 0, 0), fixtureBundleID(secondRepository, 1, 0, 1),
+			},
+		},
+		{
+			name:  "channel",
+			index: BundlesByChannel,
+			value: ChannelIndexKey(fixturePackageName(2), fixtureChannelName(1)),
+			expected: []string{
+				fixtureBundleID(firstRepository, 2, 1, 0), fixtureBundleID(firstRepository, 2, 1, 1), fixtureBundleID(firstRepository, 2, 1, 2),
+			},
+		},
+		{
+			name:     "image",
+			index:    BundlesByImage,
+			value:    fixtureBundle(0, 1, 2, "").BundlePath,
+			expected: []string{fixtureBundleID(firstRepository, 0, 1, 2)},
+		},
+		{
+			name:     "csv name",
+			index:    BundlesByCSVName,
+			value:    fixtureBundle(0, 0, 1, "").CsvName,
+			expected: []string{fixtureBundleID(firstRepository, 0, 0, 1), fixtureBundleID(secondRepository, 0, 0, 1)},
+		},
+		{
+			name:  "provided property",
+			index: BundlesByProperty,
+			value: PropertyIndexKey(providedGVK.Type, providedGVK.Value),
+			expected: []string{
+				fixtureBundleID(firstRepository, 1, 0, 0), fixtureBundleID(firstRepository, 1, 0, 1), fixtureBundleID(firstRepository, 1, 0, 2),
+				fixtureBundleID(firstRepository, 1, 1, 0), fixtureBundleID(firstRepository, 1, 1, 1), fixtureBundleID(firstRepository, 1, 1, 2),
+				fixtureBundleID(secondRepository, 1, 0, 0), fixtureBundleID(secondRepository, 1, 0, 1),
+			},
+		},
+		{
+			name:  "unknown value",
+			index: BundlesByPackage,
+			value: "unknown",
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			bundles, err := packageDatabase.LookupBundles(ctx, tt.index, tt.value)
+			if err != nil {
+				t.Fatal(err)
+			}
+			expected := append([]string{}, tt.expected...)
+			sort.Strings(expected)
+			if ids := bundleIDs(bundles); !reflect.DeepEqual(ids, expected) {
+				t.Errorf("expected bundles %v, got %v", expected, ids)
+			}
+		})
+	}
+}
+
+func testReset(t *testing.T, backend string) {
+	ctx := context.Background()
+	packageDatabase := openConformanceDatabase(t, backend)
+	operation := NewOperation(OperationInstall)
+	operation.Finish(nil)
+	if err := packageDatabase.RecordOperation(ctx, operation); err != nil {
+		t.Fatal(err)
+	}
+	if err := packageDatabase.Reset(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	packages, err := packageDatabase.ListPackages(ctx)
+	expectCount(t, "packages", packages, err, 0)
+	bundles, err := packageDatabase.ListBundles(ctx)
+	expectCount(t, "bundles", bundles, err, 0)
+	gvkBundles, err := packageDatabase.ListBundlesForGVK(ctx, fixtureGVK.Group, fixtureGVK.Version, fixtureGVK.Kind)
+	expectCount(t, "bundles providing the fixture gvk", gvkBundles, err, 0)
+	for index := range bundleIndexFunctions() {
+		indexed, err := packageDatabase.LookupBundles(ctx, index, fixtureBundle(0, 0, 0, "").BundlePath)
+		expectCount(t, "bundles in index "+string(index), indexed, err, 0)
+	}
+	indexed, err := packageDatabase.LookupBundles(ctx, BundlesByPackage, fixturePackageName(0))
+	expectCount(t, "indexed bundles", indexed, err, 0)
+	warnings, err := packageDatabase.ListWarnings(ctx)
+	expectCount(t, "warnings", warnings, err, 0)
+
+	// the install history is kept
+	operations, err := packageDatabase.ListOperations(ctx)
+	expectCount(t, "operations", operations, err, 1)
+	version, err := packageDatabase.SchemaVersion(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if version != CurrentSchemaVersion() {
+		t.Errorf("expected schema version %d, got %d", CurrentSchemaVersion(), version)
+	}
+
+	if err := packageDatabase.CacheRepository(ctx, newFixtureRepository(firstRepositorySource, 1, 1, 2)); err != nil {
+		t.Fatal(err)
+	}
+	indexed, err = packageDatabase.LookupBundles(ctx, BundlesByPackage, fixturePackageName(0))
+	expectCount(t, "indexed bundles after caching again", indexed, err, 2)
+}
+
+func testReplaceRepositories(t *testing.T, backend string) {
+	ctx := context.Background()
+	packageDatabase := openConformanceDatabase(t, backend)
+	staging := openTestDatabase(t, backend)
+	if err := staging.CacheRepository(ctx, newFixtureRepository(firstRepositorySource, 1, 1, 2)); err != nil {
+		t.Fatal(err)
+	}
+	replacement, err := staging.ExportRepository(ctx, firstRepository)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the second snapshot fails to import after the database was reset and the first was imported
+	broken := RepositorySnapshot{
+		Repository: CachedRepository{RepositoryName: secondRepository, RepositorySource: secondRepositorySource},
+		Bundles:    replacement.Bundles,
+	}
+	if err := packageDatabase.ReplaceRepositories(ctx, []RepositorySnapshot{*replacement, broken}); err == nil {
+		t.Fatal("expected replacing with a snapshot of foreign bundles to fail")
+	}
+	bundles, err := packageDatabase.ListBundles(ctx)
+	expectCount(t, "bundles after the failed replacement", bundles, err, 3*2*3+2*1*2)
+	indexed, err := packageDatabase.LookupBundles(ctx, BundlesByPackage, fixturePackageName(2))
+	expectCount(t, "indexed bundles after the failed replacement", indexed, err, 2*3)
+	warnings, err := packageDatabase.ListWarnings(ctx)
+	expectCount(t, "warnings after the failed replacement", warnings, err, 1)
+
+	if err := packageDatabase.ReplaceRepositories(ctx, []RepositorySnapshot{*replacement}); err != nil {
+		t.Fatal(err)
+	}
+	bundles, err = packageDatabase.ListBundles(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected, ids := bundleIDs(replacement.Bundles), bundleIDs(bundles); !reflect.DeepEqual(ids, expected) {
+		t.Errorf("expected bundles %v, got %v", expected, ids)
+	}
+	indexed, err = packageDatabase.LookupBundles(ctx, BundlesByPackage, fixturePackageName(0))
+	expectCount(t, "indexed bundles after the replacement", indexed, err, 2)
+	warnings, err = packageDatabase.ListWarnings(ctx)
+	expectCount(t, "warnings after the replacement", warnings, err, 0)
+}
+
+func testExportImport(t *testing.T, backend string) {
+	ctx := context.Background()
+	packageDatabase := openConformanceDatabase(t, backend)
+	snapshot, err := packageDatabase.ExportRepository(ctx, firstRepository)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if snapshot.Repository.RepositoryName != firstRepository || snapshot.Repository.RepositorySource != firstRepositorySource {
+		t.Errorf("expected a snapshot of repository first, got %+v", snapshot.Repository)
+	}
+	if len(snapshot.Packages) != 3 || len(snapshot.Bundles) != 3*2*3 || len(snapshot.Warnings) != 1 {
+		t.Errorf("expected 3 packages, 18 bundles and 1 warning, got %d, %d and %d", len(snapshot.Packages), len(snapshot.Bundles), len(snapshot.Warnings))
+	}
+	if _, err := packageDatabase.ExportRepository(ctx, "unknown"); err == nil {
+		t.Error("expected exporting an unknown repository to fail")
+	}
+
+	// every backend imports the snapshots of every other backend
+	for _, target := range Backends() {
+		t.Run("into "+target, func(t *testing.T) {
+			imported := openTestDatabase(t, target)
+			if err := imported.ImportRepository(ctx, snapshot); err != nil {
+				t.Fatal(err)
+			}
+			// importing again replaces the repository
+			if err := imported.ImportRepository(ctx, snapshot); err != nil {
+				t.Fatal(err)
+			}
+			reexported, err := imported.ExportRepository(ctx, firstRepository)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if reexported.Repository != snapshot.Repository {
+				t.Errorf("expected repository %+v, got %+v", snapshot.Repository, reexported.Repository)
+			}
+			if expected, ids := packageIDs(snapshot.Packages), packageIDs(reexported.Packages); !reflect.DeepEqual(ids, expected) {
+				t.Errorf("expected packages %v, got %v", expected, ids)
+			}
+			if expected, ids := bundleIDs(snapshot.Bundles), bundleIDs(reexported.Bundles); !reflect.DeepEqual(ids, expected) {
+				t.Errorf("expected bundles %v, got %v", expected, ids)
+			}
+			if expected, ids := warningIDs(snapshot.Warnings), warningIDs(reexported.Warnings); !reflect.DeepEqual(ids, expected) {
+				t.Errorf("expected warnings %v, got %v", expected, ids)
+			}
+			// the imported bundles are indexed
+			indexed, err := imported.LookupBundles(ctx, BundlesByChannel, ChannelIndexKey(fixturePackageName(0), fixtureChannelName(1)))
+			expectCount(t, "indexed bundles", indexed, err, 3)
+			gvk := fixturePackageGVK(2)
+			gvkBundles, err := imported.ListBundlesForGVK(ctx, gvk.Group, gvk.Version, gvk.Kind)
+			expectCount(t, "bundles providing a package gvk", gvkBundles, err, 2*3)
+		})
+	}
+}
+
+func testHistory(t *testing.T, backend string) {
+	ctx := context.Background()
+	packageDatabase := openConformanceDatabase(t, backend)
+
+	install := NewOperation(OperationInstall)
+	install.RequestedPackages = []string{fixturePackageName(0)}
+	install.Plan = []OperationBundle{{Action: "install", PackageName: fixturePackageName(0), BundleID: fixtureBundleID(firstRepository, 0, 0, 2), ToVersion: "1.2.0"}}
+	install.Finish(nil)
+	update := NewOperation(OperationUpdate)
+	update.Finish(context.Canceled)
+	for _, operation := range []*CachedOperation{install, update} {
+		if err := packageDatabase.RecordOperation(ctx, operation); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	operations, err := packageDatabase.ListOperations(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(operations) != 2 || operations[0].OperationID != install.OperationID || operations[1].OperationID != update.OperationID {
+		t.Fatalf("expected the operations in chronological order, got %+v", operations)
+	}
+	operation, err := packageDatabase.GetOperation(ctx, install.OperationID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if operation == nil || !reflect.DeepEqual(operation.Plan, install.Plan) || operation.Outcome != OperationSucceeded || !operation.Timestamp.Equal(install.Timestamp) {
+		t.Errorf("expected operation %+v, got %+v", install, operation)
+	}
+	operation, err = packageDatabase.GetOperation(ctx, update.OperationID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if operation == nil || operation.Outcome != OperationFailed || operation.Error != context.Canceled.Error() {
+		t.Errorf("expected a failed operation, got %+v", operation)
+	}
+	if operation, err := packageDatabase.GetOperation(ctx, "unknown"); err != nil || operation != nil {
+		t.Errorf("expected no unknown operation, got %+v, %v", operation, err)
+	}
+}
+
+func testWarnings(t *testing.T, backend string) {
+	ctx := context.Background()
+	packageDatabase := openConformanceDatabase(t, backend)
+
+	warnings, err := packageDatabase.ListWarnings(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(warnings) != 1 {
+		t.Fatalf("expected a single warning, got %+v", warnings)
+	}
+	if warning := warnings[0]; warning.BundleID != fixtureBundleID(firstRepository, 0, 0, 0) || warning.Field != "skipRange" || warning.Repository != firstRepository {
+		t.Errorf("expected a skipRange warning on the first bundle, got %+v", warning)
+	}
+	warnings, err = packageDatabase.ListWarnings(ctx, secondRepository)
+	expectCount(t, "warnings of the second repository", warnings, err, 0)
+	warnings, err = packageDatabase.ListWarnings(ctx, firstRepository, secondRepository)
+	expectCount(t, "warnings of both repositories", warnings, err, 1)
+
+	// caching the repository again replaces its warnings
+	if err := packageDatabase.CacheRepository(ctx, newFixtureRepository(firstRepositorySource, 1, 1, 1)); err != nil {
+		t.Fatal(err)
+	}
+	warnings, err = packageDatabase.ListWarnings(ctx)
+	expectCount(t, "warnings after caching the repository again", warnings, err, 0)
+}
@@ -0,0 +1,355 @@
+package store
+
+import (
+	"context"
+	"sort"
+	"strings"
+)
+
+// search field weights, matches in more specific fields rank higher
+const (
+	nameWeight         = 10
+	displayNameWeight  = 8
+	keywordWeight      = 6
+	categoryWeight     = 6
+	providedKindWeight = 5
+	providerWeight     = 4
+	descriptionWeight  = 2
+	annotationWeight   = 1
+)
+
+// match quality multipliers
+const (
+	exactMatch     = 4
+	prefixMatch    = 3
+	substringMatch = 2
+	fuzzyMatch     = 1
+)
+
+type searchConfig struct {
+	repositories []string
+	channel      string
+	providesKind string
+	category     string
+	capabilities string
+	fuzzy        bool
+}
+
+func (s *searchConfig) applyOptions(options ...SearchOption) {
+	for _, opt := range options {
+		opt(s)
+	}
+}
+
+func (s *searchConfig) keep(bundle *CachedBundle) bool {
+	if len(s.repositories) > 0 {
+		in := false
+		for _, repo := range s.repositories {
+			if repo == bundle.Repository {
+				in = true
+			}
+		}
+		if !in {
+			return false
+		}
+	}
+	if s.channel != "" && s.channel != bundle.ChannelName {
+		return false
+	}
+	if s.providesKind != "" {
+		provides := false
+		for _, gvk := range bundle.GetProvidedApis() {
+			if strings.EqualFold(gvk.GetKind(), s.providesKind) {
+				provides = true
+			}
+		}
+		if !provides {
+			return false
+		}
+	}
+	if s.category != "" || s.capabilities != "" {
+		metadata := bundle.Metadata
+		if metadata == nil {
+			metadata = bundleMetadata(bundle)
+		}
+		if s.category != "" && !containsFold(metadata.Categories, s.category) {
+			return false
+		}
+		if s.capabilities != "" && !strings.EqualFold(metadata.Capabilities, s.capabilities) {
+			return false
+		}
+	}
+	return true
+}
+
+func containsFold(values []string, value string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, value) {
+			return true
+		}
+	}
+	return false
+}
+
+type SearchOption func(config *searchConfig)
+
+// SearchInRepositories restricts the search to the given repositories
+func SearchInRepositories(repositories ...string) SearchOption {
+	return func(config *searchConfig) {
+		config.repositories = repositories
+	}
+}
+
+// SearchInChannel restricts the search to bundles in the given channel
+func SearchInChannel(channel string) SearchOption {
+	return func(config *searchConfig) {
+		config.channel = channel
+	}
+}
+
+// SearchProvidingKind restricts the search to bundles providing an api of the given kind
+func SearchProvidingKind(kind string) SearchOption {
+	return func(config *searchConfig) {
+		config.providesKind = kind
+	}
+}
+
+// SearchInCategory restricts the search to bundles in the given csv category
+func SearchInCategory(category string) SearchOption {
+	return func(config *searchConfig) {
+		config.category = category
+	}
+}
+
+// SearchWithCapabilities restricts the search to bundles with the given capability level, e.g. "Full Lifecycle"
+func SearchWithCapabilities(capabilities string) SearchOption {
+	return func(config *searchConfig) {
+		config.capabilities = capabilities
+	}
+}
+
+// WithFuzzyMatching also matches terms within a small edit distance of a word
+func WithFuzzyMatching(fuzzy bool) SearchOption {
+	return func(config *searchConfig) {
+		config.fuzzy = fuzzy
+	}
+}
+
+// PackageSearchResult is a package matching a search, with its relevance score and the fields
+// that matched
+type PackageSearchResult struct {
+	CachedPackage
+	Score   int
+	Matches []string
+}
+
+// BundleSearchResult is a bundle matching a search, with its relevance score and the fields
+// that matched
+type BundleSearchResult struct {
+	CachedBundle
+	Score   int
+	Matches []string
+}
+
+type searchField struct {
+	name   string
+	weight int
+	values []string
+}
+
+func bundleSearchFields(bundle *CachedBundle) []searchField {
+	metadata := bundle.Metadata
+	if metadata == nil {
+		metadata = bundleMetadata(bundle)
+	}
+	var kinds []string
+	for _, gvk := range bundle.GetProvidedApis() {
+		kinds = append(kinds, gvk.GetKind())
+	}
+	return []searchField{
+		{name: "name", weight: nameWeight, values: []string{bundle.PackageName, bundle.CsvName}},
+		{name: "displayName", weight: displayNameWeight, values: []string{metadata.DisplayName}},
+		{name: "keywords", weight: keywordWeight, values: metadata.Keywords},
+		{name: "categories", weight: categoryWeight, values: metadata.Categories},
+		{name: "providedKinds", weight: providedKindWeight, values: kinds},
+		{name: "provider", weight: providerWeight, values: []string{metadata.Provider}},
+		{name: "description", weight: descriptionWeight, values: []string{metadata.Description}},
+		{name: "annotations", weight: annotationWeight, values: sortedAnnotations(metadata.Annotations)},
+	}
+}
+
+// scoreBundle scores a bundle against the search terms. Every term must match at least one
+// field; the score of a term is that of its best matching field
+func scoreBundle(bundle *CachedBundle, terms []string, fuzzy bool) (int, []string) {
+	fields := bundleSearchFields(bundle)
+	score := 0
+	matched := map[string]struct{}{}
+	for _, term := range terms {
+		best := 0
+		bestField := ""
+		for _, field := range fields {
+			for _, value := range field.values {
+				if quality := matchQuality(term, value, fuzzy); quality*field.weight > best {
+					best = quality * field.weight
+					bestField = field.name
+				}
+			}
+		}
+		if best == 0 {
+			return 0, nil
+		}
+		score += best
+		matched[bestField] = struct{}{}
+	}
+	var matches []string
+	for _, field := range fields {
+		if _, ok := matched[field.name]; ok {
+			matches = append(matches, field.name)
+		}
+	}
+	return score, matches
+}
+
+func matchQuality(term string, value string, fuzzy bool) int {
+	value = strings.ToLower(value)
+	switch {
+	case value == "":
+		return 0
+	case value == term:
+		return exactMatch
+	case strings.HasPrefix(value, term):
+		return prefixMatch
+	case strings.Contains(value, term):
+		return substringMatch
+	case !fuzzy:
+		return 0
+	}
+	for _, word := range strings.FieldsFunc(value, isWordSeparator) {
+		if levenshtein(term, word) <= maxEditDistance(term) {
+			return fuzzyMatch
+		}
+	}
+	return 0
+}
+
+func isWordSeparator(r rune) bool {
+	return !('a' <= r && r <= 'z' || '0' <= r && r <= '9')
+}
+
+// maxEditDistance is the number of typos tolerated for a term: none for very short terms,
+// one for short terms, and two otherwise
+func maxEditDistance(term string) int {
+	switch {
+	case len(term) < 4:
+		return 0
+	case len(term) < 8:
+		return 1
+	default:
+		return 2
+	}
+}
+
+func levenshtein(a string, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	previous := make([]int, len(rb)+1)
+	current := make([]int, len(rb)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		current[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			current[j] = minimum(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+	return previous[len(rb)]
+}
+
+func minimum(values ...int) int {
+	m := values[0]
+	for _, value := range values[1:] {
+		if value < m {
+			m = value
+		}
+	}
+	return m
+}
+
+func searchTerms(searchTerm string) []string {
+	return strings.Fields(strings.ToLower(searchTerm))
+}
+
+func (b *boltPackageDatabase) SearchBundles(_ context.Context, searchTerm string, options ...SearchOption) ([]BundleSearchResult, error) {
+	return searchBundles(b.bundleTable.Iterate, searchTerm, options...)
+}
+
+func (b *boltPackageDatabase) SearchPackages(ctx context.Context, searchTerm string, options ...SearchOption) ([]PackageSearchResult, error) {
+	bundleResults, err := b.SearchBundles(ctx, searchTerm, options...)
+	if err != nil {
+		return nil, err
+	}
+	return searchPackages(bundleResults, b.packageTable.Get)
+}
+
+// searchBundles scores the bundles visited by iterate against the search term. An empty search term
+// matches every bundle passing the search options
+func searchBundles(iterate func(fn IterationFunction[CachedBundle]) error, searchTerm string, options ...SearchOption) ([]BundleSearchResult, error) {
+	config := &searchConfig{}
+	config.applyOptions(options...)
+	terms := searchTerms(searchTerm)
+
+	var results []BundleSearchResult
+	err := iterate(func(bundle *CachedBundle) error {
+		if !config.keep(bundle) {
+			return nil
+		}
+		// without search terms every bundle passing the filters matches
+		if len(terms) == 0 {
+			results = append(results, BundleSearchResult{CachedBundle: *bundle})
+			return nil
+		}
+		if score, matches := scoreBundle(bundle, terms, config.fuzzy); score > 0 {
+			results = append(results, BundleSearchResult{CachedBundle: *bundle, Score: score, Matches: matches})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		return results[i].BundleID < results[j].BundleID
+	})
+	return results, nil
+}
+
+// searchPackages ranks each package by its best matching bundle
+func searchPackages(bundleResults []BundleSearchResult, getPackage func(packageID string) (*CachedPackage, error)) ([]PackageSearchResult, error) {
+	var results []PackageSearchResult
+	seen := map[string]struct{}{}
+	for _, bundleResult := range bundleResults {
+		// bundle results are sorted by score so the first bundle of a package is its best
+		packageID := GetPackageKey(bundleResult.Repository, bundleResult.PackageName)
+		if _, ok := seen[packageID]; ok {
+			continue
+		}
+		seen[packageID] = struct{}{}
+		pkg, err := getPackage(packageID)
+		if err != nil {
+			return nil, err
+		}
+		if pkg == nil {
+			continue
+		}
+		results = append(results, PackageSearchResult{CachedPackage: *pkg, Score: bundleResult.Score, Matches: bundleResult.Matches})
+	}
+	return results, nil
+}
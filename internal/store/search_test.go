@@ -0,0 +1,162 @@
+package store
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/operator-framework/operator-registry/pkg/api"
+)
+
+func searchFixtureBundle(packageName string, channel string, metadata *CSVMetadata, kinds ...string) CachedBundle {
+	bundle := &api.Bundle{
+		CsvName:     packageName + ".v1.0.0",
+		PackageName: packageName,
+		ChannelName: channel,
+		Version:     "1.0.0",
+	}
+	for _, kind := range kinds {
+		bundle.ProvidedApis = append(bundle.ProvidedApis, &api.GroupVersionKind{Group: "fixture.example.com", Version: "v1", Kind: kind})
+	}
+	return CachedBundle{
+		BundleID:   GetBundleKey(firstRepository, bundle),
+		Repository: firstRepository,
+		Bundle:     bundle,
+		Metadata:   metadata,
+	}
+}
+
+func TestSearchBundles(t *testing.T) {
+	bundles := []CachedBundle{
+		searchFixtureBundle("etcd", "stable", &CSVMetadata{DisplayName: "etcd", Description: "A distributed key value store"}, "EtcdCluster"),
+		searchFixtureBundle("etcd-backup", "stable", &CSVMetadata{DisplayName: "Backups", Description: "Scheduled backups"}, "EtcdBackup"),
+		searchFixtureBundle("vault", "stable", &CSVMetadata{DisplayName: "Vault", Description: "Secrets kept in etcd"}),
+		searchFixtureBundle("cassandra", "stable", &CSVMetadata{DisplayName: "Cassandra", Keywords: []string{"database"}, Categories: []string{"Database"}}),
+		searchFixtureBundle("postgres", "fast", &CSVMetadata{DisplayName: "PostgreSQL", Categories: []string{"Database"}, Provider: "Crunchy"}),
+	}
+	iterate := func(fn IterationFunction[CachedBundle]) error {
+		for index := range bundles {
+			if err := fn(&bundles[index]); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	for _, tt := range []struct {
+		name       string
+		searchTerm string
+		options    []SearchOption
+		// expected are the matching packages in rank order
+		expected []string
+		// matches are the matched fields of the first result
+		matches []string
+	}{
+		{
+			name:       "exact before prefix before description",
+			searchTerm: "etcd",
+			expected:   []string{"etcd", "etcd-backup", "vault"},
+			matches:    []string{"name"},
+		},
+		{
+			name:       "case insensitive",
+			searchTerm: "ETCD",
+			expected:   []string{"etcd", "etcd-backup", "vault"},
+		},
+		{
+			name:       "every term must match",
+			searchTerm: "etcd backups",
+			expected:   []string{"etcd-backup"},
+			matches:    []string{"name", "displayName"},
+		},
+		{
+			name:       "equal scores by bundle id",
+			searchTerm: "database",
+			expected:   []string{"cassandra", "postgres"},
+			matches:    []string{"keywords"},
+		},
+		{
+			name:       "provided kind",
+			searchTerm: "etcdcluster",
+			expected:   []string{"etcd"},
+			matches:    []string{"providedKinds"},
+		},
+		{
+			name:       "typo without fuzzy matching",
+			searchTerm: "cassadnra",
+		},
+		{
+			name:       "typo with fuzzy matching",
+			searchTerm: "cassadnra",
+			options:    []SearchOption{WithFuzzyMatching(true)},
+			expected:   []string{"cassandra"},
+		},
+		{
+			name:       "term and filter",
+			searchTerm: "etcd",
+			options:    []SearchOption{SearchProvidingKind("EtcdBackup")},
+			expected:   []string{"etcd-backup"},
+		},
+		{
+			name:     "empty term matches all by bundle id",
+			expected: []string{"cassandra", "etcd-backup", "etcd", "postgres", "vault"},
+		},
+		{
+			name:     "empty term with category",
+			options:  []SearchOption{SearchInCategory("database")},
+			expected: []string{"cassandra", "postgres"},
+		},
+		{
+			name:     "empty term with channel",
+			options:  []SearchOption{SearchInChannel("fast")},
+			expected: []string{"postgres"},
+		},
+		{
+			name:     "empty term with kind",
+			options:  []SearchOption{SearchProvidingKind("etcdcluster")},
+			expected: []string{"etcd"},
+		},
+		{
+			name:    "empty term with other repository",
+			options: []SearchOption{SearchInRepositories(secondRepository)},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			results, err := searchBundles(iterate, tt.searchTerm, tt.options...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var packages []string
+			for _, result := range results {
+				packages = append(packages, result.PackageName)
+			}
+			if !reflect.DeepEqual(packages, tt.expected) {
+				t.Errorf("expected %v, got %v", tt.expected, packages)
+			}
+			if tt.matches != nil && len(results) > 0 && !reflect.DeepEqual(results[0].Matches, tt.matches) {
+				t.Errorf("expected %s to match %v, got %v", results[0].PackageName, tt.matches, results[0].Matches)
+			}
+		})
+	}
+}
+
+func TestSearchPackagesWithoutTerms(t *testing.T) {
+	ctx := context.Background()
+	for _, backend := range Backends() {
+		t.Run(backend, func(t *testing.T) {
+			packageDatabase := openConformanceDatabase(t, backend)
+			packages, err := packageDatabase.SearchPackages(ctx, "", SearchInRepositories(secondRepository))
+			if err != nil {
+				t.Fatal(err)
+			}
+			var ids []string
+			for _, pkg := range packages {
+				ids = append(ids, pkg.PackageID)
+			}
+			expected := []string{GetPackageKey(secondRepository, fixturePackageName(0)), GetPackageKey(secondRepository, fixturePackageName(1))}
+			if !reflect.DeepEqual(ids, expected) {
+				t.Errorf("expected packages %v, got %v", expected, ids)
+			}
+		})
+	}
+}
@@ -0,0 +1,291 @@
+package store
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	snapshotManifestFile   = "manifest.json"
+	snapshotRepositoryDir  = "repositories"
+	snapshotRepositoryFile = "repository.json"
+	snapshotPackagesFile   = "packages.jsonl"
+	snapshotBundlesFile    = "bundles.jsonl"
+	snapshotWarningsFile   = "warnings.jsonl"
+)
+
+// RepositorySnapshot is the cached content of a repository. The gvk and bundle indexes
+// aren't part of it, they are rebuilt from the bundles when the snapshot is imported
+type RepositorySnapshot struct {
+	Repository CachedRepository
+	Packages   []CachedPackage
+	Bundles    []CachedBundle
+	Warnings   []CachedWarning
+}
+
+// snapshotManifest describes the content of a snapshot archive
+type snapshotManifest struct {
+	SchemaVersion int       `json:"schemaVersion"`
+	Created       time.Time `json:"created"`
+	Repositories  []string  `json:"repositories"`
+}
+
+// validate checks that every entry of the snapshot belongs to its repository
+func (r *RepositorySnapshot) validate() error {
+	repoName := r.Repository.RepositoryName
+	if repoName == "" {
+		return fmt.Errorf("snapshot repository has no name")
+	}
+	prefix := repoName + keySeparator
+	for _, pkg := range r.Packages {
+		if pkg.Repository != repoName || !strings.HasPrefix(pkg.PackageID, prefix) {
+			return fmt.Errorf("package %s doesn't belong to repository %s", pkg.PackageID, repoName)
+		}
+	}
+	for _, bundle := range r.Bundles {
+		if bundle.Bundle == nil || bundle.Repository != repoName || !strings.HasPrefix(bundle.BundleID, prefix) {
+			return fmt.Errorf("bundle %s doesn't belong to repository %s", bundle.BundleID, repoName)
+		}
+	}
+	for _, warning := range r.Warnings {
+		if warning.Repository != repoName || !strings.HasPrefix(warning.WarningID, prefix) {
+			return fmt.Errorf("warning %s doesn't belong to repository %s", warning.WarningID, repoName)
+		}
+	}
+	return nil
+}
+
+// importRepository writes the content of the snapshot with writer
+func importRepository(snapshot *RepositorySnapshot, writer repositoryWriter) error {
+	for index := range snapshot.Bundles {
+		if err := writer.putBundle(&snapshot.Bundles[index]); err != nil {
+			return err
+		}
+	}
+	for index := range snapshot.Warnings {
+		if err := writer.putWarning(&snapshot.Warnings[index]); err != nil {
+			return err
+		}
+	}
+	for index := range snapshot.Packages {
+		if err := writer.putPackage(&snapshot.Packages[index]); err != nil {
+			return err
+		}
+	}
+	return writer.putRepository(&snapshot.Repository)
+}
+
+// WriteSnapshot writes the repositories to w as a gzipped tar archive
+func WriteSnapshot(w io.Writer, repositories []RepositorySnapshot) error {
+	gzipWriter := gzip.NewWriter(w)
+	tarWriter := tar.NewWriter(gzipWriter)
+	now := time.Now().UTC()
+
+	manifest := snapshotManifest{
+		SchemaVersion: CurrentSchemaVersion(),
+		Created:       now,
+	}
+	for _, repository := range repositories {
+		manifest.Repositories = append(manifest.Repositories, repository.Repository.RepositoryName)
+	}
+	manifestData, err := json.Marshal(manifest)
+	if err != nil {
+		return err
+	}
+	if err := writeTarFile(tarWriter, snapshotManifestFile, manifestData, now); err != nil {
+		return err
+	}
+
+	for _, repository := range repositories {
+		dir := path.Join(snapshotRepositoryDir, snapshotRepositoryDirName(repository.Repository.RepositoryName))
+		repositoryData, err := json.Marshal(repository.Repository)
+		if err != nil {
+			return err
+		}
+		if err := writeTarFile(tarWriter, path.Join(dir, snapshotRepositoryFile), repositoryData, now); err != nil {
+			return err
+		}
+		packagesData, err := encodeJSONLines(repository.Packages)
+		if err != nil {
+			return err
+		}
+		bundlesData, err := encodeJSONLines(repository.Bundles)
+		if err != nil {
+			return err
+		}
+		warningsData, err := encodeJSONLines(repository.Warnings)
+		if err != nil {
+			return err
+		}
+		if err := writeTarFile(tarWriter, path.Join(dir, snapshotPackagesFile), packagesData, now); err != nil {
+			return err
+		}
+		if err := writeTarFile(tarWriter, path.Join(dir, snapshotBundlesFile), bundlesData, now); err != nil {
+			return err
+		}
+		if err := writeTarFile(tarWriter, path.Join(dir, snapshotWarningsFile), warningsData, now); err != nil {
+			return err
+		}
+	}
+
+	if err := tarWriter.Close(); err != nil {
+		return err
+	}
+	return gzipWriter.Close()
+}
+
+// ReadSnapshot reads the repositories of a snapshot written by WriteSnapshot. Snapshots
+// written with another schema version are rejected
+func ReadSnapshot(r io.Reader) ([]RepositorySnapshot, error) {
+	gzipReader, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("invalid snapshot: %w", err)
+	}
+	defer gzipReader.Close()
+	tarReader := tar.NewReader(gzipReader)
+
+	var manifest *snapshotManifest
+	repositories := map[string]*RepositorySnapshot{}
+	repository := func(name string) *RepositorySnapshot {
+		if _, ok := repositories[name]; !ok {
+			repositories[name] = &RepositorySnapshot{}
+		}
+		return repositories[name]
+	}
+
+	for {
+		header, err := tarReader.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid snapshot: %w", err)
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		if header.Name == snapshotManifestFile {
+			manifest = &snapshotManifest{}
+			if err := json.NewDecoder(tarReader).Decode(manifest); err != nil {
+				return nil, fmt.Errorf("invalid snapshot manifest: %w", err)
+			}
+			if manifest.SchemaVersion != CurrentSchemaVersion() {
+				return nil, fmt.Errorf("snapshot has schema version %d but this version of olm uses schema version %d: export it again with this version of olm", manifest.SchemaVersion, CurrentSchemaVersion())
+			}
+			continue
+		}
+
+		dir, name := path.Split(header.Name)
+		repoDir, repoDirName := path.Split(path.Clean(dir))
+		if path.Clean(repoDir) != snapshotRepositoryDir {
+			return nil, fmt.Errorf("invalid snapshot: unexpected file %s", header.Name)
+		}
+		repoName, err := url.PathUnescape(repoDirName)
+		if err != nil {
+			return nil, fmt.Errorf("invalid snapshot: unexpected file %s", header.Name)
+		}
+		if manifest == nil {
+			return nil, fmt.Errorf("invalid snapshot: %s must be the first file", snapshotManifestFile)
+		}
+
+		switch name {
+		case snapshotRepositoryFile:
+			err = json.NewDecoder(tarReader).Decode(&repository(repoName).Repository)
+		case snapshotPackagesFile:
+			repository(repoName).Packages, err = decodeJSONLines[CachedPackage](tarReader)
+		case snapshotBundlesFile:
+			repository(repoName).Bundles, err = decodeJSONLines[CachedBundle](tarReader)
+		case snapshotWarningsFile:
+			repository(repoName).Warnings, err = decodeJSONLines[CachedWarning](tarReader)
+		default:
+			return nil, fmt.Errorf("invalid snapshot: unexpected file %s", header.Name)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid snapshot file %s: %w", header.Name, err)
+		}
+	}
+	if manifest == nil {
+		return nil, fmt.Errorf("invalid snapshot: %s not found", snapshotManifestFile)
+	}
+
+	snapshots := make([]RepositorySnapshot, 0, len(repositories))
+	for _, repoName := range manifest.Repositories {
+		repository, ok := repositories[repoName]
+		if !ok {
+			return nil, fmt.Errorf("invalid snapshot: repository %s not found", repoName)
+		}
+		if repository.Repository.RepositoryName != repoName {
+			return nil, fmt.Errorf("invalid snapshot: %s of repository %s names repository %q", snapshotRepositoryFile, repoName, repository.Repository.RepositoryName)
+		}
+		if err := repository.validate(); err != nil {
+			return nil, fmt.Errorf("invalid snapshot: %w", err)
+		}
+		snapshots = append(snapshots, *repository)
+	}
+	sort.Slice(snapshots, func(i, j int) bool {
+		return snapshots[i].Repository.RepositoryName < snapshots[j].Repository.RepositoryName
+	})
+	return snapshots, nil
+}
+
+// snapshotRepositoryDirName escapes the repository name for use as a single archive path element,
+// repository names of images in nested registry paths contain slashes
+func snapshotRepositoryDirName(repoName string) string {
+	return url.PathEscape(repoName)
+}
+
+func writeTarFile(tarWriter *tar.Writer, name string, data []byte, modTime time.Time) error {
+	if err := tarWriter.WriteHeader(&tar.Header{
+		Name:     name,
+		Mode:     0644,
+		Size:     int64(len(data)),
+		ModTime:  modTime,
+		Typeflag: tar.TypeReg,
+	}); err != nil {
+		return err
+	}
+	_, err := tarWriter.Write(data)
+	return err
+}
+
+// encodeJSONLines encodes each entry on its own line
+func encodeJSONLines[E any](entries []E) ([]byte, error) {
+	var buffer bytes.Buffer
+	encoder := json.NewEncoder(&buffer)
+	for index := range entries {
+		if err := encoder.Encode(&entries[index]); err != nil {
+			return nil, err
+		}
+	}
+	return buffer.Bytes(), nil
+}
+
+func decodeJSONLines[E any](r io.Reader) ([]E, error) {
+	var entries []E
+	scanner := bufio.NewScanner(r)
+	// bundles carry their manifests, so lines can be much longer than the scanner's default limit
+	scanner.Buffer(nil, 64*1024*1024)
+	for scanner.Scan() {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+		var entry E
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+	return entries, scanner.Err()
+}
@@ -0,0 +1,57 @@
+package store
+
+import (
+	"bytes"
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestSnapshotRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	for _, tt := range []struct {
+		name     string
+		source   string
+		repoName string
+	}{
+		{name: "image", source: "quay.io/fixture/catalog:latest", repoName: "catalog"},
+		{name: "nested image path", source: "docker.io/foo/bar/baz:1", repoName: "bar/baz"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			packageDatabase := openTestDatabase(t, "memory")
+			if err := packageDatabase.CacheRepository(ctx, newFixtureRepository(tt.source, 2, 1, 2)); err != nil {
+				t.Fatal(err)
+			}
+			snapshot, err := packageDatabase.ExportRepository(ctx, tt.repoName)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var archive bytes.Buffer
+			if err := WriteSnapshot(&archive, []RepositorySnapshot{*snapshot}); err != nil {
+				t.Fatal(err)
+			}
+			snapshots, err := ReadSnapshot(&archive)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(snapshots) != 1 {
+				t.Fatalf("expected a single repository, got %d", len(snapshots))
+			}
+			if snapshots[0].Repository != snapshot.Repository {
+				t.Errorf("expected repository %+v, got %+v", snapshot.Repository, snapshots[0].Repository)
+			}
+			if expected, ids := bundleIDs(snapshot.Bundles), bundleIDs(snapshots[0].Bundles); !reflect.DeepEqual(ids, expected) {
+				t.Errorf("expected bundles %v, got %v", expected, ids)
+			}
+
+			imported := openTestDatabase(t, "memory")
+			if err := imported.ImportRepository(ctx, &snapshots[0]); err != nil {
+				t.Fatal(err)
+			}
+			if ok, err := imported.HasRepository(ctx, tt.repoName); err != nil || !ok {
+				t.Errorf("expected repository %s to be imported, got %t, %v", tt.repoName, ok, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,177 @@
+//go:build sqlite
+
+// The sqlite backend links the sqlite driver with cgo, so it is only registered in binaries
+// built with the sqlite build tag: go build -tags sqlite
+
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/sirupsen/logrus"
+)
+
+func init() {
+	RegisterBackend("sqlite", func(directory string, logger *logrus.Logger, options ...DatabaseOption) (PackageDatabase, error) {
+		return NewSQLitePackageDatabase(path.Join(directory, "olm.sqlite"), logger, options...)
+	})
+}
+
+const defaultSQLiteBusyTimeout = 5 * time.Second
+
+const createDocumentsTable = `CREATE TABLE IF NOT EXISTS documents (
+	collection TEXT NOT NULL,
+	key BLOB NOT NULL,
+	value BLOB NOT NULL,
+	PRIMARY KEY (collection, key)
+) WITHOUT ROWID`
+
+// NewSQLitePackageDatabase opens the package database stored in the sqlite database at databasePath
+func NewSQLitePackageDatabase(databasePath string, logger *logrus.Logger, options ...DatabaseOption) (PackageDatabase, error) {
+	config := &databaseConfig{}
+	for _, opt := range options {
+		opt(config)
+	}
+
+	// sqlite retries locked statements until the busy timeout expires
+	busyTimeout := defaultSQLiteBusyTimeout
+	if config.openTimeout > 0 {
+		busyTimeout = config.openTimeout
+	}
+	dsn := fmt.Sprintf("file:%s?_busy_timeout=%d&_journal_mode=WAL", databasePath, busyTimeout.Milliseconds())
+	// a database that doesn't exist yet is created even by read-only opens
+	readOnly := config.readOnly
+	if _, err := os.Stat(databasePath); os.IsNotExist(err) {
+		readOnly = false
+	}
+	if readOnly {
+		dsn += "&mode=ro"
+	}
+
+	db, err := sql.Open("sqlite3", dsn)
+	if err != nil {
+		return nil, err
+	}
+	if !readOnly {
+		if _, err := db.Exec(createDocumentsTable); err != nil {
+			db.Close()
+			return nil, fmt.Errorf("error opening %s: %w", databasePath, err)
+		}
+	}
+	return newDocumentPackageDatabase(&sqliteStore{database: db}, logger, append(options, withReadOnly(readOnly))...)
+}
+
+var _ documentStore = &sqliteStore{}
+
+// sqliteStore keeps every collection in a single table keyed by collection and key. Keys are
+// stored as blobs so they compare bytewise, like bolt keys
+type sqliteStore struct {
+	database *sql.DB
+}
+
+func (s *sqliteStore) View(fn func(tx documentTx) error) error {
+	tx, err := s.database.BeginTx(context.Background(), &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	return fn(&sqliteTx{tx: tx})
+}
+
+func (s *sqliteStore) Update(fn func(tx documentTx) error) error {
+	tx, err := s.database.Begin()
+	if err != nil {
+		return err
+	}
+	if err := fn(&sqliteTx{tx: tx}); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+func (s *sqliteStore) Close() error {
+	return s.database.Close()
+}
+
+type sqliteTx struct {
+	tx *sql.Tx
+}
+
+func (t *sqliteTx) Get(collection string, key string) ([]byte, error) {
+	var value []byte
+	err := t.tx.QueryRow(`SELECT value FROM documents WHERE collection = ? AND key = ?`, collection, []byte(key)).Scan(&value)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	return value, err
+}
+
+func (t *sqliteTx) Put(collection string, key string, value []byte) error {
+	_, err := t.tx.Exec(`INSERT OR REPLACE INTO documents (collection, key, value) VALUES (?, ?, ?)`, collection, []byte(key), value)
+	return err
+}
+
+func (t *sqliteTx) Delete(collection string, key string) error {
+	_, err := t.tx.Exec(`DELETE FROM documents WHERE collection = ? AND key = ?`, collection, []byte(key))
+	return err
+}
+
+func (t *sqliteTx) Scan(collection string, prefix string, fn func(key string, value []byte) error) error {
+	var rows *sql.Rows
+	var err error
+	if upperBound, ok := prefixUpperBound([]byte(prefix)); ok {
+		rows, err = t.tx.Query(`SELECT key, value FROM documents WHERE collection = ? AND key >= ? AND key < ? ORDER BY key`, collection, []byte(prefix), upperBound)
+	} else {
+		rows, err = t.tx.Query(`SELECT key, value FROM documents WHERE collection = ? AND key >= ? ORDER BY key`, collection, []byte(prefix))
+	}
+	if err != nil {
+		return err
+	}
+
+	// the rows are read before fn is called since fn may query or modify the collection
+	var keys []string
+	var values [][]byte
+	for rows.Next() {
+		var key, value []byte
+		if err := rows.Scan(&key, &value); err != nil {
+			rows.Close()
+			return err
+		}
+		keys = append(keys, string(key))
+		values = append(values, value)
+	}
+	if err := rows.Close(); err != nil {
+		return err
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for index, key := range keys {
+		if err := fn(key, values[index]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// prefixUpperBound returns the smallest key greater than every key starting with prefix. There is
+// none if the prefix is empty or only made of 0xff bytes
+func prefixUpperBound(prefix []byte) ([]byte, bool) {
+	upperBound := append([]byte(nil), prefix...)
+	for i := len(upperBound) - 1; i >= 0; i-- {
+		if upperBound[i] < 0xff {
+			upperBound[i]++
+			return upperBound[:i+1], true
+		}
+	}
+	return nil, false
+}